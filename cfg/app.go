@@ -47,6 +47,17 @@ func ExampleApp(name string) *App {
 							Environment: []string{"GOFLAGS=-mod=vendor", "GO111MODULE=on"},
 						},
 					},
+					EnvironmentVariables: []EnvVarsInputs{
+						{
+							Names:    []string{"GOFLAGS", "CGO_*"},
+							Optional: true,
+						},
+					},
+					CommandOutput: []CommandOutputInput{
+						{
+							Command: []string{"go", "version"},
+						},
+					},
 				},
 				Output: Output{
 					File: []FileOutput{
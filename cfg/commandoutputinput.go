@@ -0,0 +1,38 @@
+package cfg
+
+import (
+	"strings"
+
+	"github.com/simplesurance/baur/v1/cfg/resolver"
+)
+
+// CommandOutputInput describes a command whose output is an input of a task.
+type CommandOutputInput struct {
+	Command  []string `toml:"command" comment:"Command to execute, the output that it prints to STDOUT is the input.\n The first element is the command, the following it's arguments.\n If the command element contains no path seperators,\n the path is looked up via the $PATH environment variable.\n The command must exit with code 0.\n Valid variables: $ROOT, $APPNAME."`
+	InAppDir bool     `toml:"in_app_dir" comment:"If true, the command is run in the application directory, otherwise in the repository root.\n Commands that are run in the repository root are executed once per baur invocation,\n commands that are run in the application directory once per application."`
+}
+
+func (c *CommandOutputInput) Resolve(resolvers resolver.Resolver) error {
+	for i, elem := range c.Command {
+		var err error
+
+		if c.Command[i], err = resolvers.Resolve(elem); err != nil {
+			return FieldErrorWrap(err, "command", elem)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks if the stored information is valid.
+func (c *CommandOutputInput) Validate() error {
+	if len(c.Command) == 0 {
+		return NewFieldError("can not be empty", "command")
+	}
+
+	if strings.TrimSpace(c.Command[0]) == "" {
+		return NewFieldError("first element can not be empty", "command")
+	}
+
+	return nil
+}
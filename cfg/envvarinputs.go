@@ -0,0 +1,51 @@
+package cfg
+
+import (
+	"path"
+
+	"github.com/simplesurance/baur/v1/cfg/resolver"
+)
+
+// EnvVarsInputs describes environment variables that are inputs of a task.
+type EnvVarsInputs struct {
+	Names    []string `toml:"names" comment:"Names of environment variables.\n Glob patterns (https://golang.org/pkg/path/#Match) are supported.\n Only the digests of the values are recorded, the values are not stored."`
+	Optional bool     `toml:"optional" comment:"If true, baur will not fail if a name does not match a set environment variable."`
+}
+
+// Merge appends the names in other to e.
+// If other is optional, e becomes optional.
+func (e *EnvVarsInputs) Merge(other *EnvVarsInputs) {
+	e.Names = append(e.Names, other.Names...)
+	e.Optional = e.Optional || other.Optional
+}
+
+func (e *EnvVarsInputs) Resolve(resolvers resolver.Resolver) error {
+	for i, name := range e.Names {
+		var err error
+
+		if e.Names[i], err = resolvers.Resolve(name); err != nil {
+			return FieldErrorWrap(err, "names", name)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks if the stored information is valid.
+func (e *EnvVarsInputs) Validate() error {
+	if len(e.Names) == 0 {
+		return NewFieldError("can not be empty", "names")
+	}
+
+	for _, name := range e.Names {
+		if len(name) == 0 {
+			return NewFieldError("can not contain empty elements", "names")
+		}
+
+		if _, err := path.Match(name, ""); err != nil {
+			return NewFieldError("invalid glob pattern", "names", name)
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package cfg
 
 import (
+	"path/filepath"
 	"strings"
 
 	"github.com/simplesurance/baur/v1/cfg/resolver"
@@ -10,11 +11,13 @@ import (
 type FileInputs struct {
 	Paths    []string `toml:"paths" comment:"Relative path to source files.\n Golang's Glob syntax (https://golang.org/pkg/path/filepath/#Match)\n and ** is supported to match files recursively.\n Valid variables: $ROOT, $APPNAME, $GITCOMMIT."`
 	Optional bool     `toml:"optional" comment:"If true, baur will not fail if a Path does not resolve to a file."`
+	Exclude  []string `toml:"exclude" comment:"Relative paths to files that are removed from the files that paths resolved to.\n The same syntax as in paths is supported.\n Valid variables: $ROOT, $APPNAME, $GITCOMMIT."`
 }
 
-// Merge appends the paths in other to f.
+// Merge appends the paths and exclude patterns in other to f.
 func (f *FileInputs) Merge(other *FileInputs) {
 	f.Paths = append(f.Paths, other.Paths...)
+	f.Exclude = append(f.Exclude, other.Exclude...)
 }
 
 func (f *FileInputs) Resolve(resolvers resolver.Resolver) error {
@@ -26,6 +29,14 @@ func (f *FileInputs) Resolve(resolvers resolver.Resolver) error {
 		}
 	}
 
+	for i, p := range f.Exclude {
+		var err error
+
+		if f.Exclude[i], err = resolvers.Resolve(p); err != nil {
+			return FieldErrorWrap(err, "exclude", p)
+		}
+	}
+
 	return nil
 }
 
@@ -42,5 +53,29 @@ func (f *FileInputs) Validate() error {
 		}
 	}
 
+	if err := validateExcludePatterns(f.Exclude); err != nil {
+		return FieldErrorWrap(err, "exclude")
+	}
+
+	return nil
+}
+
+// validateExcludePatterns validates the patterns of an exclude list, they
+// support the same syntax as glob paths of FileInputs.
+func validateExcludePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if len(pattern) == 0 {
+			return NewFieldError("can not contain empty elements", pattern)
+		}
+
+		if strings.Count(pattern, "**") > 1 {
+			return NewFieldError("'**' can only appear one time in a pattern", pattern)
+		}
+
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return NewFieldError("invalid glob pattern", pattern)
+		}
+	}
+
 	return nil
 }
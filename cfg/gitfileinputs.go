@@ -7,11 +7,13 @@ import "github.com/simplesurance/baur/v1/cfg/resolver"
 type GitFileInputs struct {
 	Paths    []string `toml:"paths" comment:"Relative paths to source files.\n Only files tracked by Git that are not in the .gitignore file are matched.\n The same patterns that git ls-files supports can be used.\n Valid variables: $ROOT, $APPNAME."`
 	Optional bool     `toml:"optional" comment:"If true, baur will not fail if a Path does not resolve to a file."`
+	Exclude  []string `toml:"exclude" comment:"Relative paths to files that are removed from the files that paths resolved to.\n Golang's Glob syntax (https://golang.org/pkg/path/filepath/#Match)\n and ** is supported to match files recursively.\n Valid variables: $ROOT, $APPNAME."`
 }
 
 // Merge merges two GitFileInputs structs
 func (g *GitFileInputs) Merge(other *GitFileInputs) {
 	g.Paths = append(g.Paths, other.Paths...)
+	g.Exclude = append(g.Exclude, other.Exclude...)
 }
 
 func (g *GitFileInputs) Resolve(resolvers resolver.Resolver) error {
@@ -23,5 +25,23 @@ func (g *GitFileInputs) Resolve(resolvers resolver.Resolver) error {
 		}
 	}
 
+	for i, p := range g.Exclude {
+		var err error
+
+		if g.Exclude[i], err = resolvers.Resolve(p); err != nil {
+			return FieldErrorWrap(err, "exclude", p)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks if the stored information is valid.
+func (g *GitFileInputs) Validate() error {
+	// TODO: validate the paths
+	if err := validateExcludePatterns(g.Exclude); err != nil {
+		return FieldErrorWrap(err, "exclude")
+	}
+
 	return nil
 }
@@ -239,6 +239,7 @@ func (db *IncludeDB) addTaskInclude(absPath string, include *TaskInclude) error
 		return fmt.Errorf("task include %q already exist, include specifiers must be unique", includeSpecifier(absPath, include.IncludeID))
 	}
 
+	include.filePath = absPath
 	idMap[include.IncludeID] = include
 	db.logf("includedb: loaded include %q", includeSpecifier(absPath, include.IncludeID))
 
@@ -256,6 +257,7 @@ func (db *IncludeDB) addOutputInclude(absPath string, include *OutputInclude) er
 		return fmt.Errorf("output include %q already exist, include specifiers must be unique", includeSpecifier(absPath, include.IncludeID))
 	}
 
+	include.filePath = absPath
 	idMap[include.IncludeID] = include
 	db.logf("includedb: loaded include %q", includeSpecifier(absPath, include.IncludeID))
 
@@ -273,6 +275,7 @@ func (db *IncludeDB) addInputInclude(absPath string, include *InputInclude) erro
 		return fmt.Errorf("input include %q already exist, include specifiers must be unique", includeSpecifier(absPath, include.IncludeID))
 	}
 
+	include.filePath = absPath
 	idMap[include.IncludeID] = include
 	db.logf("includedb: loaded include %q", includeSpecifier(absPath, include.IncludeID))
 
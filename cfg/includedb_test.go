@@ -386,6 +386,11 @@ func TestTaskInclude(t *testing.T) {
 									Tests:       false,
 								},
 							},
+							EnvironmentVariables: []EnvVarsInputs{
+								{
+									Names: []string{"GOFLAGS"},
+								},
+							},
 						},
 						{
 							IncludeID: "input2",
@@ -406,6 +411,12 @@ func TestTaskInclude(t *testing.T) {
 									Tests:       true,
 								},
 							},
+							EnvironmentVariables: []EnvVarsInputs{
+								{
+									Names:    []string{"CGO_*"},
+									Optional: true,
+								},
+							},
 						},
 					},
 
@@ -497,6 +508,10 @@ func TestTaskInclude(t *testing.T) {
 				for _, gs := range inputIncl.GolangSources {
 					assert.Contains(t, loadedTask.Input.GolangSources, gs)
 				}
+
+				for _, e := range inputIncl.EnvironmentVariables {
+					assert.Contains(t, loadedTask.Input.EnvironmentVariables, e)
+				}
 			}
 
 			for _, outputIncl := range tc.includeConfig.cfg.Output {
@@ -513,6 +528,62 @@ func TestTaskInclude(t *testing.T) {
 	}
 }
 
+func TestEnvVarsInputsMergeKeepsOptional(t *testing.T) {
+	e := EnvVarsInputs{Names: []string{"GOFLAGS"}}
+	e.Merge(&EnvVarsInputs{Names: []string{"CGO_*"}, Optional: true})
+
+	assert.Equal(t, EnvVarsInputs{Names: []string{"GOFLAGS", "CGO_*"}, Optional: true}, e)
+
+	e.Merge(&EnvVarsInputs{Names: []string{"NODE_ENV"}})
+	assert.True(t, e.Optional, "merging a non-optional input unset Optional")
+}
+
+func TestMergeRecordsIncludeFilesOfTasks(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	inputInclPath := filepath.Join(tmpdir, "inputs.toml")
+	outputInclPath := filepath.Join(tmpdir, "outputs.toml")
+	taskInclPath := filepath.Join(tmpdir, "tasks.toml")
+
+	cfgToFile(t, Include{Input: inputInclude()}, inputInclPath)
+	cfgToFile(t, Include{Output: outputInclude()}, outputInclPath)
+	cfgToFile(t, Include{
+		Task: TaskIncludes{
+			{
+				IncludeID: "check_task",
+				Name:      "check",
+				Command:   []string{"make", "check"},
+				Includes:  []string{"inputs.toml#inputs"},
+			},
+		},
+	}, taskInclPath)
+
+	app := App{
+		Name:     "testapp",
+		Includes: []string{"tasks.toml#check_task"},
+		Tasks: Tasks{
+			{
+				Name:     "build",
+				Command:  []string{"make"},
+				Includes: []string{"inputs.toml#inputs", "outputs.toml#outputs"},
+			},
+		},
+	}
+
+	appCfgPath := filepath.Join(tmpdir, ".app.toml")
+	require.NoError(t, app.ToFile(appCfgPath))
+
+	loadedApp, err := AppFromFile(appCfgPath)
+	require.NoError(t, err)
+
+	err = loadedApp.Merge(NewIncludeDB(t.Logf), &resolver.StrReplacement{Old: "$NOTHING"})
+	require.NoError(t, err)
+
+	require.Len(t, loadedApp.Tasks, 2)
+	assert.ElementsMatch(t, []string{inputInclPath, outputInclPath}, loadedApp.Tasks[0].IncludeFiles())
+	assert.ElementsMatch(t, []string{taskInclPath, inputInclPath}, loadedApp.Tasks[1].IncludeFiles())
+}
+
 func TestTaskIncludeFailsForNonExistingIncludeFile(t *testing.T) {
 	app := App{
 		Name: "testapp",
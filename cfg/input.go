@@ -6,9 +6,11 @@ import (
 
 // Input contains information about task inputs
 type Input struct {
-	Files         []FileInputs    `comment:"Inputs specified by file glob paths"`
-	GitFiles      []GitFileInputs `comment:"Inputs specified by path, matching only Git tracked files"`
-	GolangSources []GolangSources `comment:"Inputs specified by resolving dependencies of Golang source files or packages."`
+	Files                []FileInputs         `comment:"Inputs specified by file glob paths"`
+	GitFiles             []GitFileInputs      `comment:"Inputs specified by path, matching only Git tracked files"`
+	GolangSources        []GolangSources      `comment:"Inputs specified by resolving dependencies of Golang source files or packages."`
+	EnvironmentVariables []EnvVarsInputs      `comment:"Inputs specified by names of environment variables"`
+	CommandOutput        []CommandOutputInput `comment:"Inputs specified by the output of commands, like the version of a compiler"`
 }
 
 func (in *Input) FileInputs() []FileInputs {
@@ -23,11 +25,21 @@ func (in *Input) GolangSourcesInputs() []GolangSources {
 	return in.GolangSources
 }
 
+func (in *Input) EnvironmentVariablesInputs() []EnvVarsInputs {
+	return in.EnvironmentVariables
+}
+
+func (in *Input) CommandOutputInputs() []CommandOutputInput {
+	return in.CommandOutput
+}
+
 // Merge appends the information in other to in.
 func (in *Input) Merge(other InputDef) {
 	in.Files = append(in.Files, other.FileInputs()...)
 	in.GitFiles = append(in.GitFiles, other.GitFileInputs()...)
 	in.GolangSources = append(in.GolangSources, other.GolangSourcesInputs()...)
+	in.EnvironmentVariables = append(in.EnvironmentVariables, other.EnvironmentVariablesInputs()...)
+	in.CommandOutput = append(in.CommandOutput, other.CommandOutputInputs()...)
 }
 
 func (in *Input) Resolve(resolvers resolver.Resolver) error {
@@ -52,6 +64,18 @@ func (in *Input) Resolve(resolvers resolver.Resolver) error {
 		in.GolangSources[i] = gs
 	}
 
+	for i := range in.EnvironmentVariables {
+		if err := in.EnvironmentVariables[i].Resolve(resolvers); err != nil {
+			return FieldErrorWrap(err, "EnvironmentVariables")
+		}
+	}
+
+	for i := range in.CommandOutput {
+		if err := in.CommandOutput[i].Resolve(resolvers); err != nil {
+			return FieldErrorWrap(err, "CommandOutput")
+		}
+	}
+
 	return nil
 }
 
@@ -69,7 +93,23 @@ func InputValidate(i InputDef) error {
 		}
 	}
 
-	// TODO: add validation for gitfiles section
+	for _, e := range i.EnvironmentVariablesInputs() {
+		if err := e.Validate(); err != nil {
+			return FieldErrorWrap(err, "EnvironmentVariables")
+		}
+	}
+
+	for _, c := range i.CommandOutputInputs() {
+		if err := c.Validate(); err != nil {
+			return FieldErrorWrap(err, "CommandOutput")
+		}
+	}
+
+	for _, g := range i.GitFileInputs() {
+		if err := g.Validate(); err != nil {
+			return FieldErrorWrap(err, "GitFiles")
+		}
+	}
 
 	return nil
 }
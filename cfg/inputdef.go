@@ -4,11 +4,15 @@ type InputDef interface {
 	FileInputs() []FileInputs
 	GitFileInputs() []GitFileInputs
 	GolangSourcesInputs() []GolangSources
+	EnvironmentVariablesInputs() []EnvVarsInputs
+	CommandOutputInputs() []CommandOutputInput
 }
 
 // InputsAreEmpty returns true if no inputs are defined
 func InputsAreEmpty(in InputDef) bool {
 	return len(in.FileInputs()) == 0 &&
 		len(in.GitFileInputs()) == 0 &&
-		len(in.GolangSourcesInputs()) == 0
+		len(in.GolangSourcesInputs()) == 0 &&
+		len(in.EnvironmentVariablesInputs()) == 0 &&
+		len(in.CommandOutputInputs()) == 0
 }
@@ -6,9 +6,13 @@ import "github.com/simplesurance/baur/v1/internal/deepcopy"
 type InputInclude struct {
 	IncludeID string `toml:"include_id" comment:"identifier of the include"`
 
-	Files         []FileInputs    `comment:"Inputs specified by file glob paths"`
-	GitFiles      []GitFileInputs `comment:"Inputs specified by path, matching only Git tracked files"`
-	GolangSources []GolangSources `comment:"Inputs specified by directories containing Golang applications"`
+	Files                []FileInputs         `comment:"Inputs specified by file glob paths"`
+	GitFiles             []GitFileInputs      `comment:"Inputs specified by path, matching only Git tracked files"`
+	GolangSources        []GolangSources      `comment:"Inputs specified by directories containing Golang applications"`
+	EnvironmentVariables []EnvVarsInputs      `comment:"Inputs specified by names of environment variables"`
+	CommandOutput        []CommandOutputInput `comment:"Inputs specified by the output of commands, like the version of a compiler"`
+
+	filePath string
 }
 
 func (in *InputInclude) FileInputs() []FileInputs {
@@ -23,6 +27,14 @@ func (in *InputInclude) GolangSourcesInputs() []GolangSources {
 	return in.GolangSources
 }
 
+func (in *InputInclude) EnvironmentVariablesInputs() []EnvVarsInputs {
+	return in.EnvironmentVariables
+}
+
+func (in *InputInclude) CommandOutputInputs() []CommandOutputInput {
+	return in.CommandOutput
+}
+
 // Validate checks if the stored information is valid.
 func (in *InputInclude) Validate() error {
 	if err := validateIncludeID(in.IncludeID); err != nil {
@@ -12,6 +12,8 @@ type OutputInclude struct {
 
 	DockerImage []DockerImageOutput `comment:"Docker images that are produced by the [Task.command]"`
 	File        []FileOutput        `comment:"Files that are produces by the [Task.command]"`
+
+	filePath string
 }
 
 func (out *OutputInclude) DockerImageOutputs() []DockerImageOutput {
@@ -3,6 +3,7 @@ package cfg
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"github.com/pelletier/go-toml"
 )
@@ -22,13 +23,14 @@ type Repository struct {
 
 	Database Database
 	Discover Discover `toml:"Discover" comment:"Application discovery settings"`
+	Run      Run      `toml:"Run" comment:"Settings for baur run"`
 
 	filePath string
 }
 
 // Database contains database configuration
 type Database struct {
-	PGSQLURL string `toml:"postgresql_url" comment:"Connection string to the PostgreSQL database, see https://www.postgresql.org/docs/current/static/libpq-connect.html#LIBPQ-CONNSTRING"`
+	PGSQLURL string `toml:"postgresql_url" comment:"Connection string to the PostgreSQL database, see https://www.postgresql.org/docs/current/static/libpq-connect.html#LIBPQ-CONNSTRING\n Alternatively a local database file can be used by specifying an URL in the format file:///<ABSOLUTE-PATH>\n or a baur storage server by specifying its http:// or https:// URL"`
 }
 
 // Discover stores the [Discover] section of the repository configuration.
@@ -37,6 +39,12 @@ type Discover struct {
 	SearchDepth int      `toml:"search_depth" comment:"Descend at most SearchDepth levels to find application configs"`
 }
 
+// Run stores the [Run] section of the repository configuration.
+type Run struct {
+	Parallel      int      `toml:"parallel" comment:"Maximum number of tasks that are executed in parallel.\n It can be overwritten with the --parallel parameter of baur run.\n If unset, 1 task is run at a time."`
+	RecordEnvVars []string `toml:"record_env_vars" comment:"Names of environment variables whose values are recorded with task runs,\n e.g. to record the URL of the CI job that ran a task.\n Unset variables are ignored.\n Example: ['CI_JOB_URL', 'BUILD_URL']"`
+}
+
 // RepositoryFromFile reads the repository config from a file and returns it.
 func RepositoryFromFile(cfgPath string) (*Repository, error) {
 	config := Repository{}
@@ -66,6 +74,10 @@ func ExampleRepository() *Repository {
 			SearchDepth: 1,
 		},
 
+		Run: Run{
+			Parallel: 1,
+		},
+
 		Database: Database{
 			PGSQLURL: "postgres://postgres@localhost:5432/baur?sslmode=disable&connect_timeout=5",
 		},
@@ -97,6 +109,10 @@ func (r *Repository) Validate() error {
 		return FieldErrorWrap(err, "Discover")
 	}
 
+	if err := r.Run.Validate(); err != nil {
+		return FieldErrorWrap(err, "Run")
+	}
+
 	return nil
 }
 
@@ -115,3 +131,18 @@ func (d *Discover) Validate() error {
 
 	return nil
 }
+
+// Validate validates the Run section.
+func (r *Run) Validate() error {
+	if r.Parallel < 0 {
+		return NewFieldError("can not be negative", "parallel")
+	}
+
+	for _, name := range r.RecordEnvVars {
+		if name == "" || strings.Contains(name, "=") {
+			return NewFieldError(fmt.Sprintf("%q is not a valid environment variable name", name), "record_env_vars")
+		}
+	}
+
+	return nil
+}
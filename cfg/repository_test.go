@@ -41,3 +41,12 @@ func Test_ExampleRepository_WrittenAndReadCfgIsValid(t *testing.T) {
 		t.Error("validating conf from file failed: ", err)
 	}
 }
+
+func Test_RepositoryWithInvalidRecordEnvVarIsInvalid(t *testing.T) {
+	r := ExampleRepository()
+	r.Run.RecordEnvVars = []string{"CI_JOB_URL", "A=B"}
+
+	if err := r.Validate(); err == nil {
+		t.Error("validation succeeded for a record_env_vars entry containing '='")
+	}
+}
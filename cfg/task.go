@@ -6,11 +6,16 @@ import (
 
 // Task is a task section
 type Task struct {
-	Name     string   `toml:"name" comment:"Identifies the task, currently the name must be 'build'."`
-	Command  []string `toml:"command" comment:"Command to execute.\n The first element is the command, the following it's arguments.\n If the command element contains no path seperators,\n the path is looked up via the $PATH environment variable."`
-	Includes []string `toml:"includes" comment:"Input or Output includes that the task inherits.\n Includes are specified in the format <filepath>#<ID>.\n Paths are relative to the application directory.\n Valid variables: $ROOT."`
-	Input    Input    `toml:"Input" comment:"Specification of task inputs like source files, Makefiles, etc"`
-	Output   Output   `toml:"Output" comment:"Specification of task outputs produced by the Task.command"`
+	Name        string   `toml:"name" comment:"Identifies the task, currently the name must be 'build'."`
+	Command     []string `toml:"command" comment:"Command to execute.\n The first element is the command, the following it's arguments.\n If the command element contains no path seperators,\n the path is looked up via the $PATH environment variable."`
+	Includes    []string `toml:"includes" comment:"Input or Output includes that the task inherits.\n Includes are specified in the format <filepath>#<ID>.\n Paths are relative to the application directory.\n Valid variables: $ROOT."`
+	MutexGroups []string `toml:"mutex_groups" comment:"Names of mutex groups the task belongs to.\n Tasks that share a mutex group are never run in parallel."`
+	Timeout     string   `toml:"timeout" comment:"Maximum duration the command may run, e.g. '30m'.\n When it is exceeded, the command is terminated and the run is recorded as timed out.\n If empty, the command can run indefinitely."`
+	DependsOn   []string `toml:"depends_on" comment:"Tasks that must be run before this task.\n Tasks are specified in the format <APP-NAME>.<TASK-NAME>.\n Valid variables: $APPNAME."`
+	Input       Input    `toml:"Input" comment:"Specification of task inputs like source files, Makefiles, etc"`
+	Output      Output   `toml:"Output" comment:"Specification of task outputs produced by the Task.command"`
+
+	includeFiles []string
 }
 
 func (t *Task) GetCommand() []string {
@@ -24,6 +29,18 @@ func (t *Task) GetIncludes() *[]string {
 	return &t.Includes
 }
 
+func (t *Task) GetMutexGroups() []string {
+	return t.MutexGroups
+}
+
+func (t *Task) GetDependsOn() []string {
+	return t.DependsOn
+}
+
+func (t *Task) GetTimeout() string {
+	return t.Timeout
+}
+
 func (t *Task) GetInput() *Input {
 	return &t.Input
 }
@@ -32,6 +49,17 @@ func (t *Task) GetOutput() *Output {
 	return &t.Output
 }
 
+// IncludeFiles returns the absolute paths of the include files that the task
+// definition was merged from.
+// They are known after App.Merge() was called.
+func (t *Task) IncludeFiles() []string {
+	return t.includeFiles
+}
+
+func (t *Task) addIncludeFile(path string) {
+	t.includeFiles = appendIfMissing(t.includeFiles, path)
+}
+
 func (t *Task) Resolve(resolvers resolver.Resolver) error {
 	var err error
 
@@ -41,6 +69,12 @@ func (t *Task) Resolve(resolvers resolver.Resolver) error {
 		}
 	}
 
+	for i, elem := range t.DependsOn {
+		if t.DependsOn[i], err = resolvers.Resolve(elem); err != nil {
+			return FieldErrorWrap(err, "depends_on")
+		}
+	}
+
 	if err := t.Input.Resolve(resolvers); err != nil {
 		return FieldErrorWrap(err, "Input")
 	}
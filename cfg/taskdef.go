@@ -3,23 +3,33 @@ package cfg
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/simplesurance/baur/v1/cfg/resolver"
 )
 
 type TaskDef interface {
 	GetCommand() []string
+	GetDependsOn() []string
 	GetIncludes() *[]string
 	GetInput() *Input
+	GetMutexGroups() []string
 	GetName() string
 	GetOutput() *Output
+	GetTimeout() string
+	IncludeFiles() []string
+
+	addIncludeFile(path string)
 }
 
 // TaskMerge loads the includes of the task and merges them with the task itself.
+// The paths of the loaded include files are recorded in the task.
 func TaskMerge(task TaskDef, workingDir string, resolver resolver.Resolver, includeDB *IncludeDB) error {
 	for _, includeSpec := range *task.GetIncludes() {
 		inputInclude, err := includeDB.loadInputInclude(resolver, workingDir, includeSpec)
 		if err == nil {
+			task.addIncludeFile(inputInclude.filePath)
+
 			inputInclude = inputInclude.clone()
 			task.GetInput().Merge(inputInclude)
 
@@ -42,6 +52,8 @@ func TaskMerge(task TaskDef, workingDir string, resolver resolver.Resolver, incl
 			return err
 		}
 
+		task.addIncludeFile(outputInclude.filePath)
+
 		outputInclude = outputInclude.clone()
 		task.GetOutput().Merge(outputInclude)
 	}
@@ -67,6 +79,27 @@ func TaskValidate(t TaskDef) error {
 		return FieldErrorWrap(err, "includes")
 	}
 
+	for _, group := range t.GetMutexGroups() {
+		if strings.TrimSpace(group) == "" {
+			return NewFieldError("can not contain empty elements", "mutex_groups")
+		}
+	}
+
+	if t.GetTimeout() != "" {
+		timeout, err := time.ParseDuration(t.GetTimeout())
+		if err != nil {
+			return NewFieldError(fmt.Sprintf("invalid duration: %s", err), "timeout")
+		}
+
+		if timeout <= 0 {
+			return NewFieldError("must be positive", "timeout")
+		}
+	}
+
+	if err := validateDependsOn(t.GetDependsOn()); err != nil {
+		return FieldErrorWrap(err, "depends_on")
+	}
+
 	if t.GetInput() == nil {
 		return NewFieldError("section is empty", "Input")
 	}
@@ -85,3 +118,29 @@ func TaskValidate(t TaskDef) error {
 
 	return nil
 }
+
+func validateDependsOn(taskIDs []string) error {
+	for _, id := range taskIDs {
+		spl := strings.Split(id, ".")
+		if len(spl) != 2 || spl[0] == "" || spl[1] == "" {
+			return NewFieldError("invalid task specifier, must be in format <APP-NAME>.<TASK-NAME>", id)
+		}
+
+		if strings.Contains(id, "*") {
+			return NewFieldError("wildcards are not supported", id)
+		}
+	}
+
+	return nil
+}
+
+// appendIfMissing appends s to slice if it does not contain it yet.
+func appendIfMissing(slice []string, s string) []string {
+	for _, elem := range slice {
+		if elem == s {
+			return slice
+		}
+	}
+
+	return append(slice, s)
+}
@@ -8,11 +8,17 @@ import (
 type TaskInclude struct {
 	IncludeID string `toml:"include_id" comment:"identifier of the include"`
 
-	Name     string   `toml:"name" comment:"Identifies the task, currently the name must be 'build'."`
-	Command  []string `toml:"command" comment:"Command to execute. The first element is the command, the following it's arguments.\n If the command element contains no path seperators, it's paths is tried to be looked up via the $PATH environment variable."`
-	Includes []string `toml:"includes" comment:"Input or Output includes that the task inherits.\n Includes are specified in the format <filepath>#<ID>.\n Paths are relative to the include file location.\n Valid variables: $ROOT"`
-	Input    Input    `toml:"Input" comment:"Specification of task inputs like source files, Makefiles, etc"`
-	Output   Output   `toml:"Output" comment:"Specification of task outputs produced by the Task.command"`
+	Name        string   `toml:"name" comment:"Identifies the task, currently the name must be 'build'."`
+	Command     []string `toml:"command" comment:"Command to execute. The first element is the command, the following it's arguments.\n If the command element contains no path seperators, it's paths is tried to be looked up via the $PATH environment variable."`
+	Includes    []string `toml:"includes" comment:"Input or Output includes that the task inherits.\n Includes are specified in the format <filepath>#<ID>.\n Paths are relative to the include file location.\n Valid variables: $ROOT"`
+	MutexGroups []string `toml:"mutex_groups" comment:"Names of mutex groups the task belongs to.\n Tasks that share a mutex group are never run in parallel."`
+	Timeout     string   `toml:"timeout" comment:"Maximum duration the command may run, e.g. '30m'.\n When it is exceeded, the command is terminated and the run is recorded as timed out.\n If empty, the command can run indefinitely."`
+	DependsOn   []string `toml:"depends_on" comment:"Tasks that must be run before this task.\n Tasks are specified in the format <APP-NAME>.<TASK-NAME>.\n Valid variables: $APPNAME."`
+	Input       Input    `toml:"Input" comment:"Specification of task inputs like source files, Makefiles, etc"`
+	Output      Output   `toml:"Output" comment:"Specification of task outputs produced by the Task.command"`
+
+	filePath     string
+	includeFiles []string
 }
 
 func (t *TaskInclude) GetCommand() []string {
@@ -27,6 +33,18 @@ func (t *TaskInclude) GetIncludes() *[]string {
 	return &t.Includes
 }
 
+func (t *TaskInclude) GetMutexGroups() []string {
+	return t.MutexGroups
+}
+
+func (t *TaskInclude) GetDependsOn() []string {
+	return t.DependsOn
+}
+
+func (t *TaskInclude) GetTimeout() string {
+	return t.Timeout
+}
+
 func (t *TaskInclude) GetInput() *Input {
 	return &t.Input
 }
@@ -35,6 +53,14 @@ func (t *TaskInclude) GetOutput() *Output {
 	return &t.Output
 }
 
+func (t *TaskInclude) IncludeFiles() []string {
+	return t.includeFiles
+}
+
+func (t *TaskInclude) addIncludeFile(path string) {
+	t.includeFiles = appendIfMissing(t.includeFiles, path)
+}
+
 func (t *TaskInclude) Validate() error {
 	if err := validateIncludeID(t.IncludeID); err != nil {
 		if t.IncludeID != "" {
@@ -56,11 +82,27 @@ func (t *TaskInclude) toTask() *Task {
 	var result Task
 
 	result.Name = t.Name
+	result.Timeout = t.Timeout
 	result.Command = make([]string, len(t.Command))
 	copy(result.Command, t.Command)
 
+	if len(t.MutexGroups) > 0 {
+		result.MutexGroups = make([]string, len(t.MutexGroups))
+		copy(result.MutexGroups, t.MutexGroups)
+	}
+
+	if len(t.DependsOn) > 0 {
+		result.DependsOn = make([]string, len(t.DependsOn))
+		copy(result.DependsOn, t.DependsOn)
+	}
+
 	deepcopy.MustCopy(t.Input, &result.Input)
 	deepcopy.MustCopy(t.Output, &result.Output)
 
+	result.addIncludeFile(t.filePath)
+	for _, path := range t.includeFiles {
+		result.addIncludeFile(path)
+	}
+
 	return &result
 }
@@ -12,3 +12,32 @@ func TestAppsWithoutAnyTasksAreValid(t *testing.T) {
 	err := app.Validate()
 	assert.NoError(t, err)
 }
+
+func TestDependsOnValidation(t *testing.T) {
+	testcases := []struct {
+		dependsOn   []string
+		expectError bool
+	}{
+		{dependsOn: []string{"app.build"}},
+		{dependsOn: []string{"app"}, expectError: true},
+		{dependsOn: []string{".build"}, expectError: true},
+		{dependsOn: []string{"app.build.x"}, expectError: true},
+		{dependsOn: []string{"app.*"}, expectError: true},
+	}
+
+	for _, tc := range testcases {
+		task := Task{
+			Name:      "check",
+			Command:   []string{"make"},
+			DependsOn: tc.dependsOn,
+		}
+
+		err := TaskValidate(&task)
+		if tc.expectError {
+			assert.Error(t, err, "depends_on: %q", tc.dependsOn)
+			continue
+		}
+
+		assert.NoError(t, err, "depends_on: %q", tc.dependsOn)
+	}
+}
@@ -0,0 +1,72 @@
+package baur
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/simplesurance/baur/v1/internal/exec"
+)
+
+// commandOutputCache runs commands and stores their STDOUT output by the
+// directory and arguments they were run with.
+// It is safe for concurrent use, when the output of a command is requested
+// concurrently, it is only run once.
+type commandOutputCache struct {
+	mu      sync.Mutex
+	entries map[string]*commandOutputCacheEntry
+}
+
+type commandOutputCacheEntry struct {
+	done   chan struct{}
+	output string
+	err    error
+}
+
+func newCommandOutputCache() *commandOutputCache {
+	return &commandOutputCache{
+		entries: map[string]*commandOutputCacheEntry{},
+	}
+}
+
+// Output returns the STDOUT output of command when it is run in dir.
+// If the command was not run before, it is run. Errors are cached like
+// outputs.
+func (c *commandOutputCache) Output(ctx context.Context, dir string, command []string) (string, error) {
+	key := dir + "\x00" + strings.Join(command, "\x00")
+
+	c.mu.Lock()
+
+	entry, exist := c.entries[key]
+	if exist {
+		c.mu.Unlock()
+		<-entry.done
+
+		return entry.output, entry.err
+	}
+
+	entry = &commandOutputCacheEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+
+	c.mu.Unlock()
+
+	entry.output, entry.err = runOutputCommand(ctx, dir, command)
+
+	close(entry.done)
+
+	return entry.output, entry.err
+}
+
+func runOutputCommand(ctx context.Context, dir string, command []string) (string, error) {
+	res, err := exec.CommandContext(ctx, command[0], command[1:]...).
+		Directory(dir).
+		SeparateStderr().
+		ExpectSuccess().
+		Run()
+	if err != nil {
+		return "", fmt.Errorf("running '%s' failed: %w", strings.Join(command, " "), err)
+	}
+
+	return res.StrOutput(), nil
+}
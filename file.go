@@ -9,13 +9,26 @@ import (
 type File struct {
 	AbsPath string
 	digest  *digest.Digest
+
+	// digestPath is the path that is hashed together with the content of
+	// the file, if it is empty AbsPath is hashed.
+	digestPath string
+
+	// digestCache is optional, when it is set Digest() looks the digest
+	// up in the cache before calculating it.
+	digestCache *fileDigestCache
 }
 
 // CalcDigest calculates the digest of the file, saves it and returns it.
 func (f *File) CalcDigest() (*digest.Digest, error) {
 	sha := sha384.New()
 
-	err := sha.AddBytes([]byte(f.AbsPath))
+	path := f.AbsPath
+	if f.digestPath != "" {
+		path = f.digestPath
+	}
+
+	err := sha.AddBytes([]byte(path))
 	if err != nil {
 		return nil, err
 	}
@@ -33,12 +46,25 @@ func (f *File) CalcDigest() (*digest.Digest, error) {
 // Digest returns the previous calculated digest.
 // If the digest wasn't calculated yet, CalcDigest() is called and it's return
 // values are returned.
+// If the File has a digest cache, the digest is only calculated if the cache
+// does not contain it.
 func (f *File) Digest() (*digest.Digest, error) {
 	if f.digest != nil {
 		return f.digest, nil
 	}
 
-	return f.CalcDigest()
+	if f.digestCache == nil {
+		return f.CalcDigest()
+	}
+
+	d, err := f.digestCache.Digest(f.AbsPath, f.CalcDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	f.digest = d
+
+	return d, nil
 }
 
 func (f *File) Path() string {
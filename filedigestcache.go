@@ -0,0 +1,61 @@
+package baur
+
+import (
+	"sync"
+
+	"github.com/simplesurance/baur/v1/internal/digest"
+	"github.com/simplesurance/baur/v1/internal/digestcache"
+)
+
+// fileDigestCache stores the digests of files by their absolute paths.
+// It is safe for concurrent use, when the digest of a file is requested
+// concurrently, it is only calculated once.
+// If persistent is set, digests that are not in the memory are looked up in
+// it before they are calculated.
+type fileDigestCache struct {
+	mu      sync.Mutex
+	entries map[string]*fileDigestCacheEntry
+
+	persistent *digestcache.Cache
+}
+
+type fileDigestCacheEntry struct {
+	done   chan struct{}
+	digest *digest.Digest
+	err    error
+}
+
+func newFileDigestCache() *fileDigestCache {
+	return &fileDigestCache{
+		entries: map[string]*fileDigestCacheEntry{},
+	}
+}
+
+// Digest returns the cached digest for path. If it is not cached, calc is
+// called to calculate it. Errors are cached like digests.
+func (c *fileDigestCache) Digest(path string, calc func() (*digest.Digest, error)) (*digest.Digest, error) {
+	c.mu.Lock()
+
+	entry, exist := c.entries[path]
+	if exist {
+		c.mu.Unlock()
+		<-entry.done
+
+		return entry.digest, entry.err
+	}
+
+	entry = &fileDigestCacheEntry{done: make(chan struct{})}
+	c.entries[path] = entry
+
+	c.mu.Unlock()
+
+	if c.persistent != nil {
+		entry.digest, entry.err = c.persistent.Digest(path, calc)
+	} else {
+		entry.digest, entry.err = calc()
+	}
+
+	close(entry.done)
+
+	return entry.digest, entry.err
+}
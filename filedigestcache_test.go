@@ -0,0 +1,61 @@
+package baur
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/internal/digest"
+)
+
+func TestFileDigestCacheCalculatesDigestOnce(t *testing.T) {
+	var calls int32
+	var wg sync.WaitGroup
+
+	cache := newFileDigestCache()
+	d := &digest.Digest{Algorithm: digest.SHA384, Sum: []byte{1}}
+
+	calc := func() (*digest.Digest, error) {
+		atomic.AddInt32(&calls, 1)
+		return d, nil
+	}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := cache.Digest("/file", calc)
+			assert.NoError(t, err)
+			assert.Equal(t, d, res)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestFileDigestCacheStoresErrors(t *testing.T) {
+	var calls int
+
+	cache := newFileDigestCache()
+	calcErr := errors.New("failed")
+
+	calc := func() (*digest.Digest, error) {
+		calls++
+		return nil, calcErr
+	}
+
+	_, err := cache.Digest("/file", calc)
+	require.Equal(t, calcErr, err)
+
+	_, err = cache.Digest("/file", calc)
+	require.Equal(t, calcErr, err)
+
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,56 @@
+package baur
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/simplesurance/baur/v1/internal/digest"
+	"github.com/simplesurance/baur/v1/internal/digest/sha384"
+)
+
+// InputCommandOutput represents a command and the output it printed to
+// STDOUT.
+type InputCommandOutput struct {
+	Command []string
+	output  string
+	digest  *digest.Digest
+}
+
+// NewInputCommandOutput returns a new InputCommandOutput.
+func NewInputCommandOutput(command []string, output string) *InputCommandOutput {
+	return &InputCommandOutput{
+		Command: command,
+		output:  output,
+	}
+}
+
+// Digest returns the previous calculated digest.
+// If the digest wasn't calculated yet, calcDigest() is called and it's return
+// values are returned.
+func (c *InputCommandOutput) Digest() (*digest.Digest, error) {
+	if c.digest != nil {
+		return c.digest, nil
+	}
+
+	return c.calcDigest()
+}
+
+// String returns cmd:<COMMAND>.
+func (c *InputCommandOutput) String() string {
+	return fmt.Sprintf("cmd:%s", strings.Join(c.Command, " "))
+}
+
+// calcDigest calculates the digest of the command and it's output, saves it
+// and returns it.
+func (c *InputCommandOutput) calcDigest() (*digest.Digest, error) {
+	sha := sha384.New()
+
+	err := sha.AddBytes([]byte(strings.Join(c.Command, " ") + "\n" + c.output))
+	if err != nil {
+		return nil, err
+	}
+
+	c.digest = sha.Digest()
+
+	return c.digest, nil
+}
@@ -0,0 +1,88 @@
+package baur
+
+import (
+	"sort"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// InputDiffState describes how an input differs between two sets of inputs.
+type InputDiffState string
+
+const (
+	// InputDiffAdded is the state of inputs that only exist in the second set.
+	InputDiffAdded InputDiffState = "added"
+	// InputDiffRemoved is the state of inputs that only exist in the first set.
+	InputDiffRemoved InputDiffState = "removed"
+	// InputDiffChanged is the state of inputs that exist in both sets with
+	// different digests.
+	InputDiffChanged InputDiffState = "changed"
+)
+
+// InputDiff describes an input that differs between two sets of inputs.
+type InputDiff struct {
+	State InputDiffState
+	URI   string
+	// Digest1 is the digest of the input in the first set, it is empty
+	// if the input was added.
+	Digest1 string
+	// Digest2 is the digest of the input in the second set, it is empty
+	// if the input was removed.
+	Digest2 string
+}
+
+// DiffInputs compares the inputs in a with the inputs in b.
+// Inputs are identified by their URI. The differences are returned sorted by
+// URI, inputs that exist with the same digest in both sets are omitted.
+func DiffInputs(a, b []*storage.Input) []*InputDiff {
+	var result []*InputDiff
+
+	digestsB := make(map[string]string, len(b))
+	for _, in := range b {
+		digestsB[in.URI] = in.Digest
+	}
+
+	seen := make(map[string]struct{}, len(a))
+
+	for _, in := range a {
+		seen[in.URI] = struct{}{}
+
+		digest, exist := digestsB[in.URI]
+		if !exist {
+			result = append(result, &InputDiff{
+				State:   InputDiffRemoved,
+				URI:     in.URI,
+				Digest1: in.Digest,
+			})
+
+			continue
+		}
+
+		if digest != in.Digest {
+			result = append(result, &InputDiff{
+				State:   InputDiffChanged,
+				URI:     in.URI,
+				Digest1: in.Digest,
+				Digest2: digest,
+			})
+		}
+	}
+
+	for _, in := range b {
+		if _, exist := seen[in.URI]; exist {
+			continue
+		}
+
+		result = append(result, &InputDiff{
+			State:   InputDiffAdded,
+			URI:     in.URI,
+			Digest2: in.Digest,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].URI < result[j].URI
+	})
+
+	return result
+}
@@ -0,0 +1,41 @@
+package baur
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+func TestDiffInputs(t *testing.T) {
+	a := []*storage.Input{
+		{URI: "main.go", Digest: "1"},
+		{URI: "go.mod", Digest: "2"},
+		{URI: "removed.go", Digest: "3"},
+		{URI: "string:abc", Digest: "4"},
+	}
+
+	b := []*storage.Input{
+		{URI: "added.go", Digest: "5"},
+		{URI: "go.mod", Digest: "2"},
+		{URI: "main.go", Digest: "6"},
+		{URI: "string:abc", Digest: "4"},
+	}
+
+	diff := DiffInputs(a, b)
+
+	assert.Equal(t, []*InputDiff{
+		{State: InputDiffAdded, URI: "added.go", Digest2: "5"},
+		{State: InputDiffChanged, URI: "main.go", Digest1: "1", Digest2: "6"},
+		{State: InputDiffRemoved, URI: "removed.go", Digest1: "3"},
+	}, diff)
+}
+
+func TestDiffInputsEqual(t *testing.T) {
+	inputs := []*storage.Input{
+		{URI: "main.go", Digest: "1"},
+	}
+
+	assert.Empty(t, DiffInputs(inputs, inputs))
+}
@@ -0,0 +1,55 @@
+package baur
+
+import (
+	"fmt"
+
+	"github.com/simplesurance/baur/v1/internal/digest"
+	"github.com/simplesurance/baur/v1/internal/digest/sha384"
+)
+
+// InputEnvVar represents an environment variable and it's value.
+// The value is only part of the digest, it is not stored.
+type InputEnvVar struct {
+	Name   string
+	value  string
+	digest *digest.Digest
+}
+
+// NewInputEnvVar returns a new InputEnvVar.
+func NewInputEnvVar(name, value string) *InputEnvVar {
+	return &InputEnvVar{
+		Name:  name,
+		value: value,
+	}
+}
+
+// Digest returns the previous calculated digest.
+// If the digest wasn't calculated yet, calcDigest() is called and it's return
+// values are returned.
+func (e *InputEnvVar) Digest() (*digest.Digest, error) {
+	if e.digest != nil {
+		return e.digest, nil
+	}
+
+	return e.calcDigest()
+}
+
+// String returns env:<NAME>.
+func (e *InputEnvVar) String() string {
+	return fmt.Sprintf("env:%s", e.Name)
+}
+
+// calcDigest calculates the digest of the name and value of the variable,
+// saves it and returns it.
+func (e *InputEnvVar) calcDigest() (*digest.Digest, error) {
+	sha := sha384.New()
+
+	err := sha.AddBytes([]byte(e.Name + "=" + e.value))
+	if err != nil {
+		return nil, err
+	}
+
+	e.digest = sha.Digest()
+
+	return e.digest, nil
+}
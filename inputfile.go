@@ -9,15 +9,20 @@ type Inputfile struct {
 	File
 	repoRootPath string
 	relPath      string
+	configFile   bool
 }
 
-// NewFile returns a new file
+// NewFile returns a new file.
+// It's digest is calculated from the repository relative path with forward
+// slashes as separator and the content, it does not depend on the location
+// of the repository.
 func NewFile(repoRootPath, relPath string) *Inputfile {
 	return &Inputfile{
 		repoRootPath: repoRootPath,
 		relPath:      relPath,
 		File: File{
-			AbsPath: filepath.Join(repoRootPath, relPath),
+			AbsPath:    filepath.Join(repoRootPath, relPath),
+			digestPath: filepath.ToSlash(relPath),
 		},
 	}
 }
@@ -32,6 +37,12 @@ func (f *Inputfile) RepoRelPath() string {
 	return f.relPath
 }
 
+// IsConfigFile returns true if the file is an app config or include file of
+// the task.
+func (f *Inputfile) IsConfigFile() bool {
+	return f.configFile
+}
+
 // String returns it's string representation
 func (f *Inputfile) String() string {
 	return f.RepoRelPath()
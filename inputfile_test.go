@@ -0,0 +1,43 @@
+package baur
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputfileDigestDoesNotDependOnRepositoryPath(t *testing.T) {
+	relPath := filepath.Join("app", "main.go")
+
+	digestInRepo := func(repoDir string) string {
+		require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "app"), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, relPath), []byte("package main"), 0644))
+
+		d, err := NewFile(repoDir, relPath).CalcDigest()
+		require.NoError(t, err)
+
+		return d.String()
+	}
+
+	assert.Equal(t, digestInRepo(t.TempDir()), digestInRepo(t.TempDir()))
+}
+
+func TestInputfileDigestDependsOnRelativePath(t *testing.T) {
+	repoDir := t.TempDir()
+
+	for _, name := range []string{"a", "b"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, name), []byte("content"), 0644))
+	}
+
+	d1, err := NewFile(repoDir, "a").CalcDigest()
+	require.NoError(t, err)
+
+	d2, err := NewFile(repoDir, "b").CalcDigest()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, d1.String(), d2.String())
+}
@@ -3,28 +3,59 @@ package baur
 import (
 	"context"
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/simplesurance/baur/v1/cfg"
+	"github.com/simplesurance/baur/v1/internal/digestcache"
+	"github.com/simplesurance/baur/v1/internal/fs"
 	"github.com/simplesurance/baur/v1/internal/log"
 	"github.com/simplesurance/baur/v1/internal/resolve/gitpath"
 	"github.com/simplesurance/baur/v1/internal/resolve/glob"
 	"github.com/simplesurance/baur/v1/internal/resolve/gosource"
 )
 
+// InputResolver resolves the inputs of tasks.
+// It is safe for concurrent use. The digests of the resolved files are
+// cached, files that are inputs of multiple tasks are only hashed once per
+// InputResolver. The same applies to the outputs of commands.
 type InputResolver struct {
 	gitGlobPathResolver *gitpath.Resolver
 	globPathResolver    *glob.Resolver
 	goSourceResolver    *gosource.Resolver
+
+	digestCache        *fileDigestCache
+	commandOutputCache *commandOutputCache
+}
+
+// InputResolverOpt is an option for NewInputResolver.
+type InputResolverOpt func(*InputResolver)
+
+// WithPersistentDigestCache configures the InputResolver to look up the
+// digests of files in cache before calculating them.
+func WithPersistentDigestCache(cache *digestcache.Cache) InputResolverOpt {
+	return func(r *InputResolver) {
+		r.digestCache.persistent = cache
+	}
 }
 
-func NewInputResolver() *InputResolver {
-	return &InputResolver{
+func NewInputResolver(opts ...InputResolverOpt) *InputResolver {
+	r := InputResolver{
 		gitGlobPathResolver: &gitpath.Resolver{},
 		globPathResolver:    &glob.Resolver{},
 		goSourceResolver:    gosource.NewResolver(log.Debugf),
+		digestCache:         newFileDigestCache(),
+		commandOutputCache:  newCommandOutputCache(),
+	}
+
+	for _, opt := range opts {
+		opt(&r)
 	}
+
+	return &r
 }
 
 // Resolves the input definition of the task to concrete Files.
@@ -51,16 +82,127 @@ func (i *InputResolver) Resolve(ctx context.Context, repositoryDir string, task
 	allInputsPaths = append(allInputsPaths, globPaths...)
 	allInputsPaths = append(allInputsPaths, goSourcePaths...)
 
-	// Add the .app.toml file of the app to the inputs
-	// TODO: add the files that were included in the .app.toml and it's includes
-	allInputsPaths = append(allInputsPaths, filepath.Join(task.Directory, AppCfgFile))
+	// the .app.toml file of the app and the include files that the task
+	// uses are added to the inputs, changing them can change the task
+	cfgPaths := make([]string, 0, len(task.IncludeFiles)+1)
+	cfgPaths = append(cfgPaths, filepath.Join(task.Directory, AppCfgFile))
+	cfgPaths = append(cfgPaths, task.IncludeFiles...)
 
-	uniqInputs, err := i.pathsToUniqInputs(repositoryDir, allInputsPaths)
+	uniqInputs, err := i.pathsToUniqInputs(repositoryDir, cfgPaths, allInputsPaths)
 	if err != nil {
 		return nil, err
 	}
 
-	return uniqInputs, nil
+	envVarInputs, err := resolveEnvVarInputs(os.Environ(), task.UnresolvedInputs.EnvironmentVariables)
+	if err != nil {
+		return nil, fmt.Errorf("resolving environment variable inputs failed: %w", err)
+	}
+
+	cmdOutputInputs, err := i.resolveCommandOutputInputs(ctx, repositoryDir, task.Directory, task.UnresolvedInputs.CommandOutput)
+	if err != nil {
+		return nil, fmt.Errorf("resolving command output inputs failed: %w", err)
+	}
+
+	result := make([]Input, 0, len(uniqInputs)+len(envVarInputs)+len(cmdOutputInputs))
+	result = append(result, uniqInputs...)
+	result = append(result, envVarInputs...)
+	result = append(result, cmdOutputInputs...)
+
+	return result, nil
+}
+
+// resolveCommandOutputInputs runs the commands of inputs and returns an
+// InputCommandOutput for each. Commands are run in repositoryDir, or in appDir
+// if InAppDir is set. Commands that are defined multiple times are only added
+// once.
+func (i *InputResolver) resolveCommandOutputInputs(ctx context.Context, repositoryDir, appDir string, inputs []cfg.CommandOutputInput) ([]Input, error) {
+	result := make([]Input, 0, len(inputs))
+	dedupMap := make(map[string]struct{}, len(inputs))
+
+	for _, in := range inputs {
+		key := strings.Join(in.Command, "\x00")
+		if _, exist := dedupMap[key]; exist {
+			continue
+		}
+
+		dedupMap[key] = struct{}{}
+
+		dir := repositoryDir
+		if in.InAppDir {
+			dir = appDir
+		}
+
+		output, err := i.commandOutputCache.Output(ctx, dir, in.Command)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, NewInputCommandOutput(in.Command, output))
+	}
+
+	return result, nil
+}
+
+// resolveEnvVarInputs returns an InputEnvVar for each variable in environ
+// that matches a name pattern of inputs. environ is in the same format that
+// os.Environ() returns.
+// The returned inputs are sorted by name.
+func resolveEnvVarInputs(environ []string, inputs []cfg.EnvVarsInputs) ([]Input, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	env := make(map[string]string, len(environ))
+	envNames := make([]string, 0, len(environ))
+
+	for _, kv := range environ {
+		spl := strings.SplitN(kv, "=", 2)
+		if len(spl) != 2 || spl[0] == "" {
+			continue
+		}
+
+		env[spl[0]] = spl[1]
+		envNames = append(envNames, spl[0])
+	}
+
+	matches := map[string]struct{}{}
+
+	for _, in := range inputs {
+		for _, pattern := range in.Names {
+			var matched bool
+
+			for _, name := range envNames {
+				ok, err := path.Match(pattern, name)
+				if err != nil {
+					return nil, fmt.Errorf("%q: %w", pattern, err)
+				}
+
+				if !ok {
+					continue
+				}
+
+				matched = true
+				matches[name] = struct{}{}
+			}
+
+			if !matched && !in.Optional {
+				return nil, fmt.Errorf("'%s' matched 0 environment variables", pattern)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(matches))
+	for name := range matches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]Input, 0, len(names))
+	for _, name := range names {
+		result = append(result, NewInputEnvVar(name, env[name]))
+	}
+
+	return result, nil
 }
 
 func (i *InputResolver) resolveGitGlobPaths(repositoryRootDir, appDir string, inputs []cfg.GitFileInputs) ([]string, error) {
@@ -80,6 +222,11 @@ func (i *InputResolver) resolveGitGlobPaths(repositoryRootDir, appDir string, in
 			return nil, fmt.Errorf("'%s' matched 0 files", strings.Join(in.Paths, ", "))
 		}
 
+		gitPaths, err = excludePaths(appDir, gitPaths, in.Exclude)
+		if err != nil {
+			return nil, err
+		}
+
 		result = append(result, gitPaths...)
 
 	}
@@ -91,6 +238,8 @@ func (i *InputResolver) resolveGlobPaths(appDir string, inputs []cfg.FileInputs)
 	var result []string
 
 	for _, in := range inputs {
+		var inputPaths []string
+
 		for _, path := range in.Paths {
 			var absGlobPath string
 
@@ -109,13 +258,71 @@ func (i *InputResolver) resolveGlobPaths(appDir string, inputs []cfg.FileInputs)
 				return nil, fmt.Errorf("'%s' matched 0 files", path)
 			}
 
-			result = append(result, resolvedPaths...)
+			inputPaths = append(inputPaths, resolvedPaths...)
+		}
+
+		inputPaths, err := excludePaths(appDir, inputPaths, in.Exclude)
+		if err != nil {
+			return nil, err
 		}
+
+		result = append(result, inputPaths...)
 	}
 
 	return result, nil
 }
 
+// excludePaths returns the elements of paths that do not match any of the
+// exclude patterns. The patterns support the same syntax as fs.FileGlob(),
+// relative patterns are relative to appDir.
+func excludePaths(appDir string, paths, exclude []string) ([]string, error) {
+	if len(exclude) == 0 {
+		return paths, nil
+	}
+
+	absPatterns := make([]string, 0, len(exclude))
+	for _, pattern := range exclude {
+		if filepath.IsAbs(pattern) {
+			absPatterns = append(absPatterns, pattern)
+		} else {
+			absPatterns = append(absPatterns, filepath.Join(appDir, pattern))
+		}
+	}
+
+	result := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		excluded, err := matchesAny(absPatterns, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if excluded {
+			log.Debugf("excluded input %q", path)
+			continue
+		}
+
+		result = append(result, path)
+	}
+
+	return result, nil
+}
+
+func matchesAny(patterns []string, path string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := fs.MatchGlob(pattern, path)
+		if err != nil {
+			return false, fmt.Errorf("exclude pattern %q: %w", pattern, err)
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (i *InputResolver) resolveGoSrcInputs(ctx context.Context, appDir string, inputs []cfg.GolangSources) ([]string, error) {
 	var result []string
 
@@ -132,8 +339,13 @@ func (i *InputResolver) resolveGoSrcInputs(ctx context.Context, appDir string, i
 
 }
 
-func (i *InputResolver) pathsToUniqInputs(repositoryRoot string, pathSlice ...[]string) ([]Input, error) {
-	var pathsCount int
+// pathsToUniqInputs returns an Inputfile for every path in cfgPaths and
+// pathSlice. Paths that are contained multiple times are only added once.
+// The files in cfgPaths are marked as config files, they are processed first,
+// a config file that is also matched by an input definition is therefore
+// marked too.
+func (i *InputResolver) pathsToUniqInputs(repositoryRoot string, cfgPaths []string, pathSlice ...[]string) ([]Input, error) {
+	pathsCount := len(cfgPaths)
 
 	for _, paths := range pathSlice {
 		pathsCount += len(paths)
@@ -142,7 +354,7 @@ func (i *InputResolver) pathsToUniqInputs(repositoryRoot string, pathSlice ...[]
 	res := make([]Input, 0, pathsCount)
 	dedupMap := make(map[string]struct{}, pathsCount)
 
-	for _, paths := range pathSlice {
+	for idx, paths := range append([][]string{cfgPaths}, pathSlice...) {
 		for _, path := range paths {
 			if _, exist := dedupMap[path]; exist {
 				log.Debugf("removed duplicate input %q", path)
@@ -156,7 +368,11 @@ func (i *InputResolver) pathsToUniqInputs(repositoryRoot string, pathSlice ...[]
 				return nil, err
 			}
 
-			res = append(res, NewFile(repositoryRoot, relPath))
+			file := NewFile(repositoryRoot, relPath)
+			file.digestCache = i.digestCache
+			file.configFile = idx == 0
+
+			res = append(res, file)
 		}
 	}
 
@@ -2,6 +2,8 @@ package baur
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -222,3 +224,260 @@ func TestFilesOptional(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveEnvVarInputs(t *testing.T) {
+	environ := []string{
+		"GOFLAGS=-mod=vendor",
+		"CGO_ENABLED=0",
+		"CGO_CFLAGS=-O2",
+		"API_TOKEN=secret",
+		"HOME=/root",
+	}
+
+	inputs, err := resolveEnvVarInputs(environ, []cfg.EnvVarsInputs{
+		{Names: []string{"GOFLAGS", "CGO_*"}},
+		{Names: []string{"API_TOKEN"}},
+		{Names: []string{"NODE_ENV"}, Optional: true},
+	})
+	require.NoError(t, err)
+
+	var strs []string
+	for _, in := range inputs {
+		strs = append(strs, in.String())
+	}
+
+	assert.Equal(t,
+		[]string{
+			"env:API_TOKEN",
+			"env:CGO_CFLAGS",
+			"env:CGO_ENABLED",
+			"env:GOFLAGS",
+		},
+		strs,
+	)
+
+	_, err = resolveEnvVarInputs(environ, []cfg.EnvVarsInputs{
+		{Names: []string{"NODE_ENV"}},
+	})
+	assert.Error(t, err, "resolving a non-optional unset variable did not fail")
+}
+
+func TestEnvVarDigestDependsOnNameAndValue(t *testing.T) {
+	d1, err := NewInputEnvVar("A", "1").Digest()
+	require.NoError(t, err)
+
+	d2, err := NewInputEnvVar("B", "1").Digest()
+	require.NoError(t, err)
+
+	d3, err := NewInputEnvVar("A", "2").Digest()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, d1.String(), d2.String())
+	assert.NotEqual(t, d1.String(), d3.String())
+}
+
+func TestConfigFilesAreInputs(t *testing.T) {
+	tempDir := t.TempDir()
+
+	fstest.WriteToFile(t, []byte("name = \"calc\""), filepath.Join(tempDir, AppCfgFile))
+	fstest.WriteToFile(t, []byte("[[Input]]"), filepath.Join(tempDir, "includes", "go.toml"))
+	fstest.WriteToFile(t, []byte("package main"), filepath.Join(tempDir, "main.go"))
+
+	task := Task{
+		Directory: tempDir,
+		UnresolvedInputs: &cfg.Input{
+			Files: []cfg.FileInputs{{Paths: []string{"*.go", "includes/*.toml"}}},
+		},
+		IncludeFiles: []string{filepath.Join(tempDir, "includes", "go.toml")},
+	}
+
+	result, err := NewInputResolver().Resolve(context.Background(), tempDir, &task)
+	require.NoError(t, err)
+
+	configFiles := map[string]bool{}
+	for _, in := range result {
+		file, ok := in.(*Inputfile)
+		require.True(t, ok)
+
+		configFiles[file.RepoRelPath()] = file.IsConfigFile()
+	}
+
+	assert.Equal(t,
+		map[string]bool{
+			AppCfgFile:                           true,
+			filepath.Join("includes", "go.toml"): true,
+			"main.go":                            false,
+		},
+		configFiles,
+	)
+}
+
+func TestCommandOutputInputsAreRunOncePerResolver(t *testing.T) {
+	testcases := []struct {
+		name         string
+		inAppDir     bool
+		expectedRuns int
+	}{
+		{name: "inRepositoryDir", inAppDir: false, expectedRuns: 1},
+		{name: "inAppDir", inAppDir: true, expectedRuns: 2},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			repoDir := t.TempDir()
+			counterFile := filepath.Join(repoDir, "counter")
+
+			command := []string{"sh", "-c", "pwd >> " + counterFile + "; echo 1.2.3"}
+
+			newTask := func(name, appDir string) *Task {
+				return &Task{
+					Name:      name,
+					Directory: appDir,
+					UnresolvedInputs: &cfg.Input{
+						CommandOutput: []cfg.CommandOutputInput{
+							{Command: command, InAppDir: tc.inAppDir},
+							{Command: command, InAppDir: tc.inAppDir},
+						},
+					},
+				}
+			}
+
+			appDir1 := filepath.Join(repoDir, "app1")
+			appDir2 := filepath.Join(repoDir, "app2")
+			require.NoError(t, os.Mkdir(appDir1, 0755))
+			require.NoError(t, os.Mkdir(appDir2, 0755))
+
+			tasks := []*Task{
+				newTask("build", appDir1),
+				newTask("check", appDir1),
+				newTask("build", appDir2),
+			}
+
+			r := NewInputResolver()
+
+			for _, task := range tasks {
+				result, err := r.Resolve(context.Background(), repoDir, task)
+				require.NoError(t, err)
+
+				var cmdInputs []*InputCommandOutput
+				for _, in := range result {
+					if c, ok := in.(*InputCommandOutput); ok {
+						cmdInputs = append(cmdInputs, c)
+					}
+				}
+
+				require.Len(t, cmdInputs, 1)
+				assert.Equal(t, "cmd:"+strings.Join(command, " "), cmdInputs[0].String())
+				assert.Equal(t, "1.2.3", cmdInputs[0].output)
+			}
+
+			content, err := ioutil.ReadFile(counterFile)
+			require.NoError(t, err)
+
+			runDirs := strings.Fields(string(content))
+			require.Len(t, runDirs, tc.expectedRuns)
+
+			if tc.inAppDir {
+				assert.ElementsMatch(t, []string{appDir1, appDir2}, runDirs)
+			} else {
+				assert.Equal(t, []string{repoDir}, runDirs)
+			}
+		})
+	}
+}
+
+func TestFailingCommandOutputInputReturnsError(t *testing.T) {
+	tempDir := t.TempDir()
+
+	task := Task{
+		Directory: tempDir,
+		UnresolvedInputs: &cfg.Input{
+			CommandOutput: []cfg.CommandOutputInput{
+				{Command: []string{"sh", "-c", "echo broken toolchain >&2; exit 3"}},
+			},
+		},
+	}
+
+	_, err := NewInputResolver().Resolve(context.Background(), tempDir, &task)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exited with code 3")
+	assert.Contains(t, err.Error(), "broken toolchain")
+}
+
+func TestCommandOutputDigestDependsOnCommandAndOutput(t *testing.T) {
+	d1, err := NewInputCommandOutput([]string{"go", "version"}, "go1.15").Digest()
+	require.NoError(t, err)
+
+	d2, err := NewInputCommandOutput([]string{"go", "version"}, "go1.16").Digest()
+	require.NoError(t, err)
+
+	d3, err := NewInputCommandOutput([]string{"node", "--version"}, "go1.15").Digest()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, d1.String(), d2.String())
+	assert.NotEqual(t, d1.String(), d3.String())
+}
+
+func TestExcludedFilesAreNotInputs(t *testing.T) {
+	files := []string{
+		"main.go",
+		"main_test.go",
+		"README.md",
+		"pkg/lib.go",
+		"pkg/lib_test.go",
+		"testdata/in.go",
+	}
+
+	exclude := []string{"**/*_test.go", "*.md", "testdata/**"}
+
+	testcases := []struct {
+		name string
+		task Task
+	}{
+		{
+			name: "files",
+			task: Task{
+				UnresolvedInputs: &cfg.Input{
+					Files: []cfg.FileInputs{{Paths: []string{"**"}, Exclude: exclude}},
+				},
+			},
+		},
+		{
+			name: "gitfiles",
+			task: Task{
+				UnresolvedInputs: &cfg.Input{
+					GitFiles: []cfg.GitFileInputs{{Paths: []string{"*"}, Exclude: exclude}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			for _, f := range files {
+				fstest.WriteToFile(t, []byte(f), filepath.Join(tempDir, f))
+			}
+
+			if tc.name == "gitfiles" {
+				gittest.CreateRepository(t, tempDir)
+				gittest.CommitFilesToGit(t, tempDir)
+			}
+
+			tc.task.Directory = tempDir
+
+			result, err := NewInputResolver().Resolve(context.Background(), tempDir, &tc.task)
+			require.NoError(t, err)
+
+			var inputs []string
+			for _, in := range result {
+				if file := in.(*Inputfile); !file.IsConfigFile() {
+					inputs = append(inputs, in.String())
+				}
+			}
+
+			assert.ElementsMatch(t, []string{"main.go", filepath.Join("pkg", "lib.go")}, inputs)
+		})
+	}
+}
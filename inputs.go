@@ -7,6 +7,16 @@ import (
 	"github.com/simplesurance/baur/v1/internal/digest/sha384"
 )
 
+// InputDigestVersion is the version of the format of input digests that
+// baur calculates. It is recorded with every task run, digests of different
+// versions are not comparable.
+//
+// Versions:
+//   - 1: digests of files are calculated from their absolute paths and content
+//   - 2: digests of files are calculated from their repository relative paths
+//     and content
+const InputDigestVersion = 2
+
 // Inputs are resolved Inputs of a task.
 type Inputs struct {
 	inputs []Input
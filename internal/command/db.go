@@ -0,0 +1,14 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "export and import recorded task runs",
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,165 @@
+package command
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v1/internal/command/flag"
+	"github.com/simplesurance/baur/v1/internal/command/term"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// dbExportProgressInterval is the number of exported task runs after that
+// the progress is reported.
+const dbExportProgressInterval = 1000
+
+const dbExportLongHelp = `
+Export recorded task runs with their inputs, outputs and uploads.
+
+The runs are written in a versioned JSON-lines format, one run per line,
+ordered by their start time. The export can be imported into another
+database with 'baur db import'.
+
+Arguments:
+	If <APP-NAME> or <APP-NAME>.<TASK-NAME> is passed, only runs of the
+	application or task are exported. '*' matches all Apps or Tasks.
+`
+
+const dbExportExample = `
+baur db export -o runs.jsonl					export all task runs to runs.jsonl
+baur db export --after=2020.01.01-00:00 calc.build > runs.jsonl	export the runs of the calc.build
+								task since 2020
+`
+
+func init() {
+	dbCmd.AddCommand(&newDbExportCmd().Command)
+}
+
+type dbExportCmd struct {
+	cobra.Command
+
+	after       flag.DateTimeFlagValue
+	before      flag.DateTimeFlagValue
+	outFile     string
+	databaseURL string
+}
+
+func newDbExportCmd() *dbExportCmd {
+	cmd := dbExportCmd{
+		Command: cobra.Command{
+			Use:     "export [<APP-NAME>[.<TASK-NAME>]]",
+			Short:   "export recorded task runs in JSON-lines format",
+			Long:    strings.TrimSpace(dbExportLongHelp),
+			Example: strings.TrimSpace(dbExportExample),
+			Args:    cobra.MaximumNArgs(1),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().VarP(&cmd.after, "after", "a",
+		fmt.Sprintf("Only export runs that were started after this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
+
+	cmd.Flags().VarP(&cmd.before, "before", "b",
+		fmt.Sprintf("Only export runs that were started before this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
+
+	cmd.Flags().StringVarP(&cmd.outFile, "output", "o", "",
+		"write the export to the file instead of stdout")
+
+	cmd.Flags().StringVar(&cmd.databaseURL, "database-url", "",
+		"URL of the database that is exported,\n"+
+			"defaults to the database of the repository")
+
+	return &cmd
+}
+
+func (c *dbExportCmd) run(cmd *cobra.Command, args []string) {
+	var app, task string
+	var outFile *os.File
+	var out io.Writer = os.Stdout
+
+	if len(args) == 1 {
+		app, task = parseSpec(args[0])
+	}
+
+	storageClt := mustNewCompatibleStorageForURL(c.databaseURL)
+	defer storageClt.Close()
+
+	if c.outFile != "" {
+		var err error
+
+		outFile, err = os.Create(c.outFile)
+		exitOnErr(err)
+		defer outFile.Close()
+
+		out = outFile
+	}
+
+	bufOut := bufio.NewWriter(out)
+
+	cnt, err := storage.Export(ctx, storageClt, bufOut, c.filters(app, task), func(exported int) {
+		if exported%dbExportProgressInterval == 0 {
+			stderr.Printf("exported %d task runs\n", exported)
+		}
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			stderr.Printf("no matching task runs exist\n")
+			exitFunc(1)
+		}
+
+		exitOnErr(err, "exporting task runs failed")
+	}
+
+	exitOnErr(bufOut.Flush())
+
+	if outFile != nil {
+		exitOnErr(outFile.Close())
+	}
+
+	stderr.Printf("exported %s task runs\n", term.Highlight(cnt))
+}
+
+func (c *dbExportCmd) filters(app, task string) []*storage.Filter {
+	var filters []*storage.Filter
+
+	if app != "" && app != "*" {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldApplicationName,
+			Operator: storage.OpEQ,
+			Value:    app,
+		})
+	}
+
+	if task != "" && task != "*" {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldTaskName,
+			Operator: storage.OpEQ,
+			Value:    task,
+		})
+	}
+
+	if c.after != (flag.DateTimeFlagValue{}) {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldStartTime,
+			Operator: storage.OpGT,
+			Value:    c.after.Time,
+		})
+	}
+
+	if c.before != (flag.DateTimeFlagValue{}) {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldStartTime,
+			Operator: storage.OpLT,
+			Value:    c.before.Time,
+		})
+	}
+
+	return filters
+}
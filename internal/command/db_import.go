@@ -0,0 +1,89 @@
+package command
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v1/internal/command/term"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+const dbImportLongHelp = `
+Import task runs that were exported with 'baur db export'.
+
+The runs are read from the passed file or from stdin if no file or '-' is
+passed.
+Runs that already exist in the database are skipped. Runs are identified
+by their application name, task name, total input digest and start time.
+The database must have been created with 'baur init db' before.
+`
+
+const dbImportExample = `
+baur db import runs.jsonl						import runs.jsonl into the
+									database of the repository
+baur db export | baur db import --database-url file:///tmp/baur.db	copy all task runs to a local
+									database file
+`
+
+func init() {
+	dbCmd.AddCommand(&newDbImportCmd().Command)
+}
+
+type dbImportCmd struct {
+	cobra.Command
+
+	databaseURL string
+}
+
+func newDbImportCmd() *dbImportCmd {
+	cmd := dbImportCmd{
+		Command: cobra.Command{
+			Use:     "import [FILE]",
+			Short:   "import task runs that were exported with baur db export",
+			Long:    strings.TrimSpace(dbImportLongHelp),
+			Example: strings.TrimSpace(dbImportExample),
+			Args:    cobra.MaximumNArgs(1),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().StringVar(&cmd.databaseURL, "database-url", "",
+		"URL of the database that the runs are imported into,\n"+
+			"defaults to the database of the repository")
+
+	return &cmd
+}
+
+func (c *dbImportCmd) run(cmd *cobra.Command, args []string) {
+	var in io.Reader = os.Stdin
+
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		exitOnErr(err)
+		defer f.Close()
+
+		in = f
+	}
+
+	storageClt := mustNewCompatibleStorageForURL(c.databaseURL)
+	defer storageClt.Close()
+
+	result, err := storage.Import(ctx, storageClt, bufio.NewReader(in), func(p *storage.ImportProgress) {
+		stderr.Printf("read %d task runs, imported %d, skipped %d\n", p.Read, p.Imported, p.Skipped)
+	})
+	if err != nil {
+		if result != nil && result.Imported > 0 {
+			stderr.Printf("%d task runs were imported before the error occurred\n", result.Imported)
+		}
+
+		exitOnErr(err, "importing task runs failed")
+	}
+
+	stdout.Printf("imported %s task runs, skipped %s existing task runs\n",
+		term.Highlight(result.Imported), term.Highlight(result.Skipped))
+}
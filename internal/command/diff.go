@@ -0,0 +1,197 @@
+package command
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v1"
+	"github.com/simplesurance/baur/v1/internal/command/flag"
+	"github.com/simplesurance/baur/v1/internal/command/term"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+const diffLongHelp = `
+Compare the inputs of two task runs or of a task run and a task.
+
+Each argument is either the ID of a recorded task run or a task.
+For task runs the recorded inputs are compared, for tasks the inputs are
+resolved from the current state of the workspace.
+Inputs that were added, removed or whose digest changed are listed.
+`
+
+const diffExamples = `
+baur diff 511 512		compare the inputs of task run 511 and 512
+baur diff 512 calc.build	compare the inputs of task run 512 with the
+				current inputs of the calc.build task
+`
+
+func init() {
+	rootCmd.AddCommand(&newDiffCmd().Command)
+}
+
+type diffCmd struct {
+	cobra.Command
+
+	csv      bool
+	json     bool
+	inputStr string
+	format   string
+
+	repo       *baur.Repository
+	storageClt storage.Storer
+}
+
+func newDiffCmd() *diffCmd {
+	cmd := diffCmd{
+		Command: cobra.Command{
+			Use:     "diff <APP-NAME.TASK-NAME>|<TASK-RUN-ID> <APP-NAME.TASK-NAME>|<TASK-RUN-ID>",
+			Short:   "compare the inputs of task runs and tasks",
+			Long:    strings.TrimSpace(diffLongHelp),
+			Example: strings.TrimSpace(diffExamples),
+			Args:    cobra.ExactArgs(2),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().BoolVar(&cmd.csv, "csv", false,
+		"Show output in RFC4180 CSV format,\n"+
+			"same as --format csv")
+	cmd.Flags().BoolVar(&cmd.json, "json", false,
+		"Show output in JSON format,\n"+
+			"same as --format json")
+	cmd.Flags().StringVar(&cmd.inputStr, "input-str", "",
+		"include a string as input of the tasks")
+
+	// --json is superseded by --format json, it is kept for
+	// backwards-compatibility
+	_ = cmd.Flags().MarkHidden("json")
+
+	return &cmd
+}
+
+func (c *diffCmd) run(cmd *cobra.Command, args []string) {
+	c.format = c.mustOutputFormat()
+	c.repo = mustFindRepository()
+
+	inputs1, digestVer1 := c.mustArgToInputs(args[0])
+	inputs2, digestVer2 := c.mustArgToInputs(args[1])
+
+	if c.storageClt != nil {
+		c.storageClt.Close()
+	}
+
+	if digestVer1 != digestVer2 {
+		stderr.Printf("the inputs of %s and %s can not be compared, their digests were calculated with different versions (%d, %d)\n",
+			term.Highlight(args[0]), term.Highlight(args[1]), digestVer1, digestVer2)
+		exitFunc(1)
+	}
+
+	diffs := baur.DiffInputs(inputs1, inputs2)
+
+	if len(diffs) == 0 && c.format == flag.FormatTable {
+		stdout.Printf("the inputs of %s and %s are equal\n", term.Highlight(args[0]), term.Highlight(args[1]))
+		return
+	}
+
+	var headers []string
+	if c.format == flag.FormatTable {
+		headers = []string{"State", "Input", "Digest in " + args[0], "Digest in " + args[1]}
+	}
+
+	formatter := newFormatter(c.format, headers, []string{"state", "input", "digest1", "digest2"})
+
+	for _, d := range diffs {
+		mustWriteRow(formatter, c.stateStr(d.State), d.URI, d.Digest1, d.Digest2)
+	}
+
+	exitOnErr(formatter.Flush())
+}
+
+// mustOutputFormat returns the output format, --json is handled like
+// --format json.
+func (c *diffCmd) mustOutputFormat() string {
+	if !c.json {
+		return mustOutputFormat(c.csv)
+	}
+
+	if c.csv {
+		stderr.Printf("--csv and --json can not be passed at the same time\n")
+		exitFunc(1)
+	}
+
+	if formatFlag.Value != "" && formatFlag.Value != flag.FormatJSON {
+		stderr.Printf("--json and --format %s can not be passed at the same time\n", formatFlag.Value)
+		exitFunc(1)
+	}
+
+	return flag.FormatJSON
+}
+
+func (c *diffCmd) stateStr(state baur.InputDiffState) string {
+	if c.format != flag.FormatTable {
+		return string(state)
+	}
+
+	switch state {
+	case baur.InputDiffAdded:
+		return term.GreenHighlight(state)
+	case baur.InputDiffRemoved:
+		return term.RedHighlight(state)
+	default:
+		return term.YellowHighlight(state)
+	}
+}
+
+// mustArgToInputs returns the recorded inputs of the task run if arg is a
+// task run ID, otherwise the inputs of the task that arg refers to are
+// resolved.
+// Additionally the input digest version of the inputs is returned.
+func (c *diffCmd) mustArgToInputs(arg string) ([]*storage.Input, int) {
+	runID, err := strconv.Atoi(arg)
+	if err != nil {
+		return c.mustResolveTaskInputs(arg), baur.InputDigestVersion
+	}
+
+	if c.storageClt == nil {
+		c.storageClt = mustNewCompatibleStorage(c.repo)
+	}
+
+	run, err := c.storageClt.TaskRun(ctx, runID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			stderr.Printf("task run with id %d does not exist\n", runID)
+			exitFunc(1)
+		}
+
+		exitOnErr(err)
+	}
+
+	inputs, err := c.storageClt.Inputs(ctx, runID)
+	if err != nil && !errors.Is(err, storage.ErrNotExist) {
+		exitOnErrf(err, "retrieving inputs of task run %d failed", runID)
+	}
+
+	return inputs, run.InputDigestVersion
+}
+
+func (c *diffCmd) mustResolveTaskInputs(arg string) []*storage.Input {
+	task := mustArgToTask(c.repo, arg)
+
+	inputResolver, saveDigestCache := newInputResolver(c.repo)
+
+	inputFiles, err := inputResolver.Resolve(ctx, c.repo.Path, task)
+	exitOnErrf(err, "%s: resolving inputs failed", task)
+
+	inputs := baur.NewInputs(baur.InputAddStrIfNotEmpty(inputFiles, c.inputStr))
+
+	result, err := baur.InputsToStorageInputs(inputs)
+	exitOnErrf(err, "%s", task)
+
+	saveDigestCache()
+
+	return result
+}
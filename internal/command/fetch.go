@@ -0,0 +1,156 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v1"
+	"github.com/simplesurance/baur/v1/internal/command/term"
+	"github.com/simplesurance/baur/v1/internal/log"
+	"github.com/simplesurance/baur/v1/internal/upload/docker"
+	"github.com/simplesurance/baur/v1/internal/upload/filecopy"
+	"github.com/simplesurance/baur/v1/internal/upload/s3"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+const fetchLongHelp = `
+Restore the outputs of a recorded task run.
+
+The outputs are restored from the locations they were uploaded to.
+File outputs are downloaded from S3 or copied from the filecopy destination
+to their path in the application directory.
+Docker images are pulled from the registry and their image ID is written to
+the configured image ID file.
+The digests of restored outputs are verified against the recorded digests.
+
+If a task is passed, the outputs of the run with the same inputs as the
+current state of the task are restored.
+If a task-run ID is passed, the outputs of this run are restored.
+`
+
+const fetchExamples = `
+baur fetch calc.build	restore the outputs of the calc.build run with the current inputs
+baur fetch 512		restore the outputs of task run 512
+`
+
+func init() {
+	rootCmd.AddCommand(&newFetchCmd().Command)
+}
+
+type fetchCmd struct {
+	cobra.Command
+
+	inputStr       string
+	lookupInputStr string
+}
+
+func newFetchCmd() *fetchCmd {
+	cmd := fetchCmd{
+		Command: cobra.Command{
+			Use:     "fetch <APP-NAME.TASK-NAME>|<TASK-RUN-ID>",
+			Short:   "restore the outputs of a recorded task run",
+			Long:    strings.TrimSpace(fetchLongHelp),
+			Example: strings.TrimSpace(fetchExamples),
+			Args:    cobra.ExactArgs(1),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().StringVar(&cmd.inputStr, "input-str", "",
+		"include a string as an input")
+	cmd.Flags().StringVar(&cmd.lookupInputStr, "lookup-input-str", "",
+		"if a run can not be found, try to find a run with this value as input-string")
+
+	return &cmd
+}
+
+func (c *fetchCmd) run(cmd *cobra.Command, args []string) {
+	var task *baur.Task
+	var run *storage.TaskRunWithID
+
+	repo := mustFindRepository()
+	storageClt := mustNewCompatibleStorage(repo)
+
+	if runID, err := strconv.Atoi(args[0]); err == nil {
+		run, err = storageClt.TaskRun(ctx, runID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotExist) {
+				stderr.Printf("task run with id %d does not exist\n", runID)
+				exitFunc(1)
+			}
+
+			exitOnErr(err)
+		}
+
+		task = mustArgToTask(repo, fmt.Sprintf("%s.%s", run.ApplicationName, run.TaskName))
+	} else {
+		task = mustArgToTask(repo, args[0])
+
+		inputResolver, saveDigestCache := newInputResolver(repo)
+		statusEvaluator := baur.NewTaskStatusEvaluator(repo.Path, storageClt, inputResolver, c.inputStr, c.lookupInputStr)
+		status, _, taskRun, err := statusEvaluator.Status(ctx, task)
+		exitOnErrf(err, "%s: evaluating task status failed", task)
+
+		saveDigestCache()
+
+		if status != baur.TaskStatusRunExist {
+			stderr.Printf("%s: no run with the current inputs exist, task has status %s\n",
+				task, term.ColoredTaskStatus(status))
+			exitFunc(1)
+		}
+
+		run = taskRun
+	}
+
+	if run.Result != storage.ResultSuccess {
+		stderr.Printf("task run %d has result %s, only outputs of successful runs can be restored\n",
+			run.ID, term.RedHighlight(run.Result))
+		exitFunc(1)
+	}
+
+	restorer := mustNewRestorer()
+
+	err := restoreRunOutputs(restorer, storageClt, task, run.ID)
+	exitOnErrf(err, "%s", task)
+}
+
+func mustNewRestorer() *baur.Restorer {
+	dockerClient, err := docker.NewClient(log.StdLogger.Debugf)
+	exitOnErr(err)
+
+	s3Client, err := s3.NewClient(log.StdLogger)
+	exitOnErr(err)
+
+	return baur.NewRestorer(dockerClient, s3Client, filecopy.New(log.Debugf))
+}
+
+// restoreRunOutputs restores all recorded outputs of the task run with the
+// given ID.
+func restoreRunOutputs(restorer *baur.Restorer, storageClt storage.Storer, task *baur.Task, runID int) error {
+	outputs, err := storageClt.Outputs(ctx, runID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			stdout.TaskPrintf(task, "run %d has no outputs\n", runID)
+			return nil
+		}
+
+		return err
+	}
+
+	for _, output := range outputs {
+		result, err := restorer.Restore(task, output)
+		if err != nil {
+			return fmt.Errorf("restoring %s output %q of run %d failed: %w", output.Type, output.Name, runID, err)
+		}
+
+		stdout.TaskPrintf(task, "restored %s output %s from %s\n",
+			output.Type, term.Highlight(result.Path), result.Upload.URI)
+	}
+
+	return nil
+}
@@ -14,20 +14,42 @@ const FieldSep = ","
 // The passed values are case-insensitive.
 type Fields struct {
 	supportedFields map[string]struct{}
-	Fields          []string
+	// supportedOrdered contains the supported fields in the order they
+	// are listed in the usage description.
+	supportedOrdered []string
+	Fields           []string
 }
 
-// NewFields returns a new flag that supports the passed fields.
+// NewFields returns a new flag that supports the passed fields, all of them
+// are enabled by default.
 func NewFields(fields []string) *Fields {
+	return NewFieldsWithDefaults(fields, fields)
+}
+
+// NewFieldsWithDefaults returns a new flag that supports the passed fields,
+// by default only the fields in defaults are enabled.
+// defaults must be a subset of supported.
+func NewFieldsWithDefaults(supported, defaults []string) *Fields {
 	res := Fields{
-		supportedFields: map[string]struct{}{},
-		Fields:          make([]string, 0, len(fields)),
+		supportedFields:  map[string]struct{}{},
+		supportedOrdered: make([]string, 0, len(supported)),
+		Fields:           make([]string, 0, len(defaults)),
 	}
 
-	for _, f := range fields {
+	for _, f := range supported {
 		lf := strings.ToLower(f)
 
 		res.supportedFields[lf] = struct{}{}
+		res.supportedOrdered = append(res.supportedOrdered, lf)
+	}
+
+	for _, f := range defaults {
+		lf := strings.ToLower(f)
+
+		if _, exist := res.supportedFields[lf]; !exist {
+			panic(fmt.Sprintf("default field %q is not in the list of supported fields", f))
+		}
+
 		res.Fields = append(res.Fields, lf)
 	}
 
@@ -41,13 +63,7 @@ func (f *Fields) String() string {
 
 // ValidValues returns the values that the flag accepts
 func (f *Fields) ValidValues() string {
-	vals := make([]string, 0, len(f.supportedFields))
-
-	for k := range f.supportedFields {
-		vals = append(vals, k)
-	}
-
-	return strings.Join(vals, FieldSep+" ")
+	return strings.Join(f.supportedOrdered, FieldSep+" ")
 }
 
 // Set parses a list of fields, fields contained in the string are set to
@@ -84,9 +100,9 @@ func (f *Fields) Type() string {
 // Usage returns a usage description, important parts are passed through
 // highlightFn
 func (f *Fields) Usage(highlightFn func(a ...interface{}) string) string {
-	fields := make([]string, 0, len(f.Fields))
+	fields := make([]string, 0, len(f.supportedOrdered))
 
-	for _, f := range f.Fields {
+	for _, f := range f.supportedOrdered {
 		fields = append(fields, highlightFn(f))
 	}
 
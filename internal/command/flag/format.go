@@ -0,0 +1,55 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported output formats.
+const (
+	FormatTable     = "table"
+	FormatCSV       = "csv"
+	FormatJSON      = "json"
+	FormatJSONLines = "jsonl"
+)
+
+var formats = []string{FormatTable, FormatCSV, FormatJSON, FormatJSONLines}
+
+// Format is a commandline flag to select the output format.
+// If it is not set, Value is empty and FormatTable should be used.
+type Format struct {
+	Value string
+}
+
+// String returns the default value in the usage output
+func (f *Format) String() string {
+	if f.Value == "" {
+		return FormatTable
+	}
+
+	return f.Value
+}
+
+// Set parses the passed string and sets the Format
+func (f *Format) Set(val string) error {
+	val = strings.ToLower(val)
+
+	for _, format := range formats {
+		if val == format {
+			f.Value = val
+			return nil
+		}
+	}
+
+	return fmt.Errorf("format must be one of %s", strings.Join(formats, ", "))
+}
+
+// Type returns the format description
+func (f *Format) Type() string {
+	return strings.Join(formats, "|")
+}
+
+// IsJSON returns true if a JSON or JSON-lines format is selected.
+func (f *Format) IsJSON() bool {
+	return f.Value == FormatJSON || f.Value == FormatJSONLines
+}
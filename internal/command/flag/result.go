@@ -0,0 +1,77 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+var results = []storage.Result{
+	storage.ResultSuccess,
+	storage.ResultFailure,
+	storage.ResultTimeout,
+	storage.ResultCancelled,
+}
+
+// Results is a commandline flag that accepts a comma-separated list of task
+// run results.
+type Results struct {
+	Values []storage.Result
+}
+
+// String returns the comma-separated list of results.
+func (r *Results) String() string {
+	strs := make([]string, 0, len(r.Values))
+
+	for _, res := range r.Values {
+		strs = append(strs, string(res))
+	}
+
+	return strings.Join(strs, ",")
+}
+
+// Set parses the comma-separated list of results.
+func (r *Results) Set(val string) error {
+	var values []storage.Result
+
+	for _, s := range strings.Split(val, ",") {
+		res, err := parseResult(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+
+		values = append(values, res)
+	}
+
+	r.Values = values
+
+	return nil
+}
+
+func parseResult(s string) (storage.Result, error) {
+	s = strings.ToLower(s)
+
+	for _, res := range results {
+		if s == string(res) {
+			return res, nil
+		}
+	}
+
+	return "", fmt.Errorf("%q is not a valid result, must be one of %s", s, resultsStr(","))
+}
+
+// Type returns the supported results.
+func (r *Results) Type() string {
+	return resultsStr("|")
+}
+
+func resultsStr(sep string) string {
+	strs := make([]string, 0, len(results))
+
+	for _, res := range results {
+		strs = append(strs, string(res))
+	}
+
+	return strings.Join(strs, sep)
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	encjson "encoding/json"
+	"strings"
+
+	"github.com/simplesurance/baur/v1/internal/command/flag"
+	"github.com/simplesurance/baur/v1/internal/format"
+	"github.com/simplesurance/baur/v1/internal/format/csv"
+	"github.com/simplesurance/baur/v1/internal/format/json"
+	"github.com/simplesurance/baur/v1/internal/format/table"
+)
+
+// formatFlag is the value of the global --format flag.
+var formatFlag flag.Format
+
+// mustOutputFormat returns the output format that was selected via the
+// --format flag. csvFlag is the value of the --csv flag of the command, if
+// it is true flag.FormatCSV is returned.
+func mustOutputFormat(csvFlag bool) string {
+	if csvFlag {
+		if formatFlag.Value != "" && formatFlag.Value != flag.FormatCSV {
+			stderr.Printf("--csv and --format %s can not be passed at the same time\n", formatFlag.Value)
+			exitFunc(1)
+		}
+
+		return flag.FormatCSV
+	}
+
+	if formatFlag.Value == "" {
+		return flag.FormatTable
+	}
+
+	return formatFlag.Value
+}
+
+// isJSONFormat returns true if outFormat is a JSON or JSON-lines format.
+func isJSONFormat(outFormat string) bool {
+	return outFormat == flag.FormatJSON || outFormat == flag.FormatJSONLines
+}
+
+// newFormatter returns a formatter for outFormat that writes to stdout.
+// headers are written as first row by the table and CSV formatters, if they
+// are not empty. keys are the field names of the JSON formatters.
+func newFormatter(outFormat string, headers, keys []string) format.Formatter {
+	switch outFormat {
+	case flag.FormatCSV:
+		return csv.New(headers, stdout)
+	case flag.FormatJSON:
+		return json.New(keys, stdout)
+	case flag.FormatJSONLines:
+		return json.NewLines(keys, stdout)
+	default:
+		return table.New(headers, stdout)
+	}
+}
+
+// fieldsToKeys converts the names of --fields flag values to JSON field
+// names.
+func fieldsToKeys(fields []string) []string {
+	keys := make([]string, 0, len(fields))
+
+	for _, f := range fields {
+		keys = append(keys, strings.ReplaceAll(f, "-", "_"))
+	}
+
+	return keys
+}
+
+// mustWriteJSONDocument writes v as JSON document to stdout.
+// If outFormat is flag.FormatJSONLines, it is written in a single line,
+// otherwise indented.
+func mustWriteJSONDocument(outFormat string, v interface{}) {
+	enc := encjson.NewEncoder(stdout)
+	enc.SetEscapeHTML(false)
+
+	if outFormat != flag.FormatJSONLines {
+		enc.SetIndent("", "  ")
+	}
+
+	exitOnErr(enc.Encode(v))
+}
@@ -5,14 +5,18 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/fatih/color"
 
 	"github.com/simplesurance/baur/v1"
+	"github.com/simplesurance/baur/v1/internal/command/term"
 	"github.com/simplesurance/baur/v1/internal/format"
 	"github.com/simplesurance/baur/v1/internal/log"
 	"github.com/simplesurance/baur/v1/internal/vcs"
 	"github.com/simplesurance/baur/v1/storage"
+	"github.com/simplesurance/baur/v1/storage/filedb"
+	"github.com/simplesurance/baur/v1/storage/httpstorage"
 	"github.com/simplesurance/baur/v1/storage/postgres"
 )
 
@@ -67,9 +71,12 @@ func mustArgToApp(repo *baur.Repository, arg string) *baur.App {
 	return apps[0]
 }
 
-// newStorageClient creates a new postgresql storage client.
+// newStorageClient creates a new storage client.
 // If the environment variable BAUR_PSQL_URI is set, this uri is used instead
-// of the configuration specified in the baur.Repository object
+// of the configuration specified in the baur.Repository object.
+// If the uri has the scheme file://, a filedb client for the database file
+// is returned, for http:// and https:// a client for a baur storage server,
+// otherwise a postgresql client.
 func newStorageClient(psqlURI string) (storage.Storer, error) {
 	uri := psqlURI
 
@@ -82,6 +89,28 @@ func newStorageClient(psqlURI string) (storage.Storer, error) {
 		log.Debugf("environment variable $%s not set", envVarPSQLURL)
 	}
 
+	if strings.HasPrefix(uri, filedb.URLScheme+"://") {
+		path := strings.TrimPrefix(uri, filedb.URLScheme+"://")
+		if !filepath.IsAbs(path) {
+			return nil, fmt.Errorf("database file path in %q is not absolute, the format is: %s:///<ABSOLUTE-PATH>", uri, filedb.URLScheme)
+		}
+
+		log.Debugf("using database file %s", path)
+
+		return filedb.New(path), nil
+	}
+
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		log.Debugf("using baur storage server %s", uri)
+
+		token := os.Getenv(envVarStorageToken)
+		if token == "" {
+			return nil, fmt.Errorf("the %s environment variable must be set to access the storage server", envVarStorageToken)
+		}
+
+		return httpstorage.NewClient(uri, token)
+	}
+
 	var logger postgres.Logger
 	if verboseFlag {
 		logger = log.StdLogger
@@ -102,28 +131,60 @@ func mustHavePSQLURI(r *baur.Repository) {
 	}
 
 	if len(os.Getenv(envVarPSQLURL)) == 0 {
-		log.Fatalf("PostgreSQL connection information is missing.\n"+
-			"- set postgres_url in your repository config or\n"+
+		log.Fatalf("Database connection information is missing.\n"+
+			"- set postgresql_url in your repository config or\n"+
 			"- set the $%s environment variable", envVarPSQLURL)
 	}
 }
 
-// mustNewCompatibleStorage initializes a new postgresql storage client.
+// mustNewCompatibleStorage initializes a new storage client.
 // The function ensures that the storage is compatible.
 func mustNewCompatibleStorage(r *baur.Repository) storage.Storer {
 	mustHavePSQLURI(r)
 
 	clt, err := newStorageClient(r.PSQLURL)
-	exitOnErr(err, "creating postgresql storage client failed")
+	exitOnErr(err, "creating storage client failed")
+
+	mustBeCompatible(clt)
+
+	return clt
+}
 
-	if err := clt.IsCompatible(ctx); err != nil {
-		clt.Close()
-		exitOnErr(err)
+// mustNewCompatibleStorageForURL returns a storage client for dbURL.
+// If dbURL is empty, the database of the repository is used.
+func mustNewCompatibleStorageForURL(dbURL string) storage.Storer {
+	if dbURL == "" {
+		return mustNewCompatibleStorage(mustFindRepository())
 	}
 
+	clt, err := newStorageClient(dbURL)
+	exitOnErr(err, "creating storage client failed")
+
+	mustBeCompatible(clt)
+
 	return clt
 }
 
+// mustBeCompatible ensures that the schema of the storage is compatible, if
+// it is not the storage is closed and the program terminates.
+// If the schema is outdated, the user is told how to upgrade it.
+func mustBeCompatible(clt storage.Storer) {
+	err := clt.IsCompatible(ctx)
+	if err == nil {
+		return
+	}
+
+	clt.Close()
+
+	if errors.Is(err, storage.ErrSchemaOutdated) {
+		stderr.Printf("%s %s\nrun '%s' to upgrade the database schema\n",
+			errorPrefix, err, term.Highlight(cmdUpgradeDb))
+		exitFunc(1)
+	}
+
+	exitOnErr(err)
+}
+
 func mustGetRepoState(dir string) vcs.StateFetcher {
 	s, err := vcs.GetState(dir, log.Debugf)
 	exitOnErr(err, "failed to evaluate if baur repository is in a VCS repository")
@@ -14,18 +14,21 @@ import (
 
 const initDbExample = `
 baur init db postgres://postgres@localhost:5432/baur?sslmode=disable
+baur init db file:///var/lib/baur/baur.db
 `
 
 var initDbLongHelp = fmt.Sprintf(`
 Creates the baur tables in a PostgreSQL database.
+If a file:///<ABSOLUTE-PATH> URL is passed, a local database file is
+created instead.
 
-The Postgres URL is read from the repository configuration file.
+The database URL is read from the repository configuration file.
 Alternatively the URL can be passed as argument or
 by setting the '%s' environment variable.`,
 	term.Highlight(envVarPSQLURL))
 
 var initDbCmd = &cobra.Command{
-	Use:     "db [POSTGRES-URL]",
+	Use:     "db [DATABASE-URL]",
 	Short:   "create baur tables in a PostgreSQL database",
 	Example: strings.TrimSpace(initDbExample),
 	Long:    strings.TrimSpace(initDbLongHelp),
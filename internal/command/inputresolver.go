@@ -0,0 +1,39 @@
+package command
+
+import (
+	"github.com/simplesurance/baur/v1"
+	"github.com/simplesurance/baur/v1/internal/digestcache"
+	"github.com/simplesurance/baur/v1/internal/log"
+)
+
+var noDigestCacheFlag bool
+
+// newInputResolver returns an InputResolver for the repository.
+// Unless --no-digest-cache was passed, the digests of input files are cached
+// across baur invocations. The returned function saves new digests in the
+// cache, it must be called after the inputs were resolved and hashed.
+func newInputResolver(repo *baur.Repository) (*baur.InputResolver, func()) {
+	if noDigestCacheFlag {
+		return baur.NewInputResolver(), func() {}
+	}
+
+	path, err := digestcache.DefaultPath(repo.Path)
+	if err != nil {
+		log.Debugf("digest cache is disabled, determining its path failed: %s", err)
+		return baur.NewInputResolver(), func() {}
+	}
+
+	cache, err := digestcache.Load(path)
+	if err != nil {
+		stderr.Printf("digest cache is disabled: %s\n", err)
+		return baur.NewInputResolver(), func() {}
+	}
+
+	log.Debugf("using digest cache %s", path)
+
+	return baur.NewInputResolver(baur.WithPersistentDigestCache(cache)), func() {
+		if err := cache.Save(); err != nil {
+			stderr.Printf("saving digest cache failed: %s\n", err)
+		}
+	}
+}
@@ -9,8 +9,6 @@ import (
 	"github.com/simplesurance/baur/v1/internal/command/flag"
 	"github.com/simplesurance/baur/v1/internal/command/term"
 	"github.com/simplesurance/baur/v1/internal/format"
-	"github.com/simplesurance/baur/v1/internal/format/csv"
-	"github.com/simplesurance/baur/v1/internal/format/table"
 )
 
 const (
@@ -50,7 +48,8 @@ func newLsAppsCmd() *lsAppsCmd {
 	cmd.Run = cmd.run
 
 	cmd.Flags().BoolVar(&cmd.csv, "csv", false,
-		"List applications in RFC4180 CSV format")
+		"List applications in RFC4180 CSV format,\n"+
+			"same as --format csv")
 
 	cmd.Flags().BoolVarP(&cmd.quiet, "quiet", "q", false,
 		"Suppress printing a header and progress dots")
@@ -90,15 +89,13 @@ func (c *lsAppsCmd) run(cmd *cobra.Command, args []string) {
 	repo := mustFindRepository()
 	apps := mustArgToApps(repo, args)
 
-	if !c.quiet && !c.csv {
+	outFormat := mustOutputFormat(c.csv)
+
+	if !c.quiet && outFormat == flag.FormatTable {
 		headers = c.createHeader()
 	}
 
-	if c.csv {
-		formatter = csv.New(headers, stdout)
-	} else {
-		formatter = table.New(headers, stdout)
-	}
+	formatter = newFormatter(outFormat, headers, fieldsToKeys(c.fields.Fields))
 
 	baur.SortAppsByName(apps)
 
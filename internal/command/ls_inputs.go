@@ -6,10 +6,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/simplesurance/baur/v1"
+	"github.com/simplesurance/baur/v1/internal/command/flag"
 	"github.com/simplesurance/baur/v1/internal/command/term"
-	"github.com/simplesurance/baur/v1/internal/format"
-	"github.com/simplesurance/baur/v1/internal/format/csv"
-	"github.com/simplesurance/baur/v1/internal/format/table"
 )
 
 func init() {
@@ -22,6 +20,7 @@ type lsInputsCmd struct {
 	csv        bool
 	quiet      bool
 	showDigest bool
+	noConfig   bool
 	inputStr   string
 }
 
@@ -37,7 +36,8 @@ func newLsInputsCmd() *lsInputsCmd {
 	cmd.Run = cmd.run
 
 	cmd.Flags().BoolVar(&cmd.csv, "csv", false,
-		"Show output in RFC4180 CSV format")
+		"Show output in RFC4180 CSV format,\n"+
+			"same as --format csv")
 
 	cmd.Flags().BoolVarP(&cmd.quiet, "quiet", "q", false,
 		"Only show filepaths")
@@ -45,6 +45,9 @@ func newLsInputsCmd() *lsInputsCmd {
 	cmd.Flags().BoolVar(&cmd.showDigest, "digests", false,
 		"show digests")
 
+	cmd.Flags().BoolVar(&cmd.noConfig, "no-configs", false,
+		"do not show if inputs are config or include files of the task")
+
 	cmd.Flags().StringVar(&cmd.inputStr, "input-str", "",
 		"include a string as input")
 
@@ -52,12 +55,12 @@ func newLsInputsCmd() *lsInputsCmd {
 }
 
 func (c *lsInputsCmd) run(cmd *cobra.Command, args []string) {
-	var formatter format.Formatter
 	var headers []string
 
 	rep := mustFindRepository()
 	task := mustArgToTask(rep, args[0])
-	writeHeaders := !c.quiet && !c.csv
+	outFormat := mustOutputFormat(c.csv)
+	writeHeaders := !c.quiet && outFormat == flag.FormatTable
 
 	if !task.HasInputs() {
 		stderr.TaskPrintf(task, "has no inputs configured")
@@ -70,15 +73,27 @@ func (c *lsInputsCmd) run(cmd *cobra.Command, args []string) {
 		if c.showDigest {
 			headers = append(headers, "Digest")
 		}
+
+		if !c.noConfig {
+			headers = append(headers, "Config")
+		}
 	}
 
-	if c.csv {
-		formatter = csv.New(headers, stdout)
-	} else {
-		formatter = table.New(headers, stdout)
+	keys := []string{"input"}
+	if !c.quiet {
+		if c.showDigest {
+			keys = append(keys, "digest")
+		}
+
+		if !c.noConfig {
+			keys = append(keys, "config")
+		}
 	}
 
-	inputResolver := baur.NewInputResolver()
+	formatter := newFormatter(outFormat, headers, keys)
+
+	inputResolver, saveDigestCache := newInputResolver(rep)
+	defer saveDigestCache()
 
 	inputFiles, err := inputResolver.Resolve(ctx, rep.Path, task)
 	exitOnErr(err)
@@ -91,24 +106,47 @@ func (c *lsInputsCmd) run(cmd *cobra.Command, args []string) {
 	})
 
 	for _, input := range inputsSlice {
-		if !c.showDigest || c.quiet {
-			mustWriteRow(formatter, input)
+		if c.quiet {
+			mustWriteRow(formatter, input.String())
 			continue
 		}
 
-		digest, err := input.Digest()
-		exitOnErrf(err, "%s: calculating digest failed", input)
+		row := []interface{}{input.String()}
+
+		if c.showDigest {
+			digest, err := input.Digest()
+			exitOnErrf(err, "%s: calculating digest failed", input)
 
-		mustWriteRow(formatter, input, digest.String())
+			row = append(row, digest.String())
+		}
+
+		if !c.noConfig {
+			row = append(row, c.configStr(input, outFormat))
+		}
+
+		mustWriteRow(formatter, row...)
 	}
 
 	err = formatter.Flush()
 	exitOnErr(err)
 
-	if c.showDigest && !c.quiet && !c.csv {
+	if c.showDigest && !c.quiet && outFormat == flag.FormatTable {
 		totalDigest, err := inputs.Digest()
 		exitOnErr(err, "calculating total input digest failed")
 
 		stdout.Printf("\nTotal Input Digest: %s\n", term.Highlight(totalDigest.String()))
 	}
 }
+
+// configStr returns whether input is an app config or include file of the
+// task. For JSON formats a bool is returned, otherwise "yes" or "no".
+func (c *lsInputsCmd) configStr(input baur.Input, format string) interface{} {
+	file, ok := input.(*baur.Inputfile)
+	isConfig := ok && file.IsConfigFile()
+
+	if isJSONFormat(format) {
+		return isConfig
+	}
+
+	return yesNo(isConfig)
+}
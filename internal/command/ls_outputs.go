@@ -1,15 +1,13 @@
 package command
 
 import (
-	"os"
 	"strconv"
 
 	"github.com/spf13/cobra"
 
+	"github.com/simplesurance/baur/v1/internal/command/flag"
 	"github.com/simplesurance/baur/v1/internal/command/term"
 	"github.com/simplesurance/baur/v1/internal/format"
-	"github.com/simplesurance/baur/v1/internal/format/csv"
-	"github.com/simplesurance/baur/v1/internal/format/table"
 	"github.com/simplesurance/baur/v1/internal/log"
 	"github.com/simplesurance/baur/v1/storage"
 )
@@ -37,7 +35,8 @@ func newLsOutputsCmd() *lsOutputsCmd {
 	cmd.Run = cmd.run
 
 	cmd.Flags().BoolVar(&cmd.csv, "csv", false,
-		"Show output in RFC4180 CSV format")
+		"Show output in RFC4180 CSV format,\n"+
+			"same as --format csv")
 
 	cmd.Flags().BoolVarP(&cmd.quiet, "quiet", "q", false,
 		"Only show URIs")
@@ -71,26 +70,12 @@ func (c *lsOutputsCmd) run(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	formatter := getLsOutputsFormatter(c.quiet, c.csv)
+	outFormat := mustOutputFormat(c.csv)
+	formatter := getLsOutputsFormatter(c.quiet, outFormat)
 
 	for _, o := range outputs {
 		for _, upload := range o.Uploads {
-			if c.quiet {
-				mustWriteRow(formatter, upload.URI)
-				continue
-			}
-
-			mustWriteRow(formatter,
-				upload.URI,
-				o.Digest,
-				term.FormatSize(o.SizeBytes, term.FormatBaseWithoutUnitName(c.csv)),
-				term.FormatDuration(
-					upload.UploadStopTimestamp.Sub(upload.UploadStartTimestamp),
-					term.FormatBaseWithoutUnitName(c.csv),
-				),
-				o.Type,
-				upload.Method,
-			)
+			c.printUpload(formatter, outFormat, o, upload)
 		}
 	}
 
@@ -98,18 +83,56 @@ func (c *lsOutputsCmd) run(cmd *cobra.Command, args []string) {
 	exitOnErr(err)
 }
 
-func getLsOutputsFormatter(isQuiet, isCsv bool) format.Formatter {
-	var headers []string
+func (c *lsOutputsCmd) printUpload(formatter format.Formatter, outFormat string, o *storage.Output, upload *storage.Upload) {
+	if c.quiet {
+		mustWriteRow(formatter, upload.URI)
+		return
+	}
 
-	if isCsv {
-		return csv.New(headers, os.Stdout)
+	if isJSONFormat(outFormat) {
+		mustWriteRow(formatter,
+			upload.URI,
+			o.Digest,
+			o.SizeBytes,
+			upload.UploadStopTimestamp.Sub(upload.UploadStartTimestamp).Seconds(),
+			o.Type,
+			upload.Method,
+		)
+		return
 	}
 
+	mustWriteRow(formatter,
+		upload.URI,
+		o.Digest,
+		term.FormatSize(o.SizeBytes, term.FormatBaseWithoutUnitName(outFormat == flag.FormatCSV)),
+		term.FormatDuration(
+			upload.UploadStopTimestamp.Sub(upload.UploadStartTimestamp),
+			term.FormatBaseWithoutUnitName(outFormat == flag.FormatCSV),
+		),
+		o.Type,
+		upload.Method,
+	)
+}
+
+func getLsOutputsFormatter(isQuiet bool, outFormat string) format.Formatter {
 	if isQuiet {
-		return table.New(headers, os.Stdout)
+		return newFormatter(outFormat, nil, []string{"uri"})
+	}
+
+	keys := []string{
+		"uri",
+		"digest",
+		"size_bytes",
+		"upload_duration",
+		"type",
+		"method",
+	}
+
+	if outFormat != flag.FormatTable {
+		return newFormatter(outFormat, nil, keys)
 	}
 
-	headers = []string{
+	headers := []string{
 		"URI",
 		"Digest",
 		"Size",
@@ -118,5 +141,5 @@ func getLsOutputsFormatter(isQuiet, isCsv bool) format.Formatter {
 		"Method",
 	}
 
-	return table.New(headers, os.Stdout)
+	return newFormatter(outFormat, headers, keys)
 }
@@ -2,16 +2,16 @@ package command
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/simplesurance/baur/v1/internal/command/flag"
 	"github.com/simplesurance/baur/v1/internal/command/term"
 	"github.com/simplesurance/baur/v1/internal/format"
-	"github.com/simplesurance/baur/v1/internal/format/csv"
-	"github.com/simplesurance/baur/v1/internal/format/table"
 	"github.com/simplesurance/baur/v1/internal/log"
 	"github.com/simplesurance/baur/v1/storage"
 )
@@ -29,7 +29,45 @@ baur ls runs -s duration-desc calc               list task runs of the calc
 						 application, sorted by
 						 run duration
 baur ls runs --csv --after=2018.09.27-11:30 '*'  list all task runs in csv format that
-						 were started after 2018.09.27 11:30`
+						 were started after 2018.09.27 11:30
+baur ls runs -f id,host,user,env-vars '*'        list the IDs of all task runs with the host,
+						 user and environment variables they
+						 were recorded with
+baur ls runs --result failure,timeout \
+	--git-commit 3f2a --limit 10 calc.build  list the 10 most recent failed or timed out
+						 runs of calc.build for commits starting
+						 with 3f2a`
+
+const (
+	lsRunsIDHeader          = "Id"
+	lsRunsIDParam           = "id"
+	lsRunsAppHeader         = "App"
+	lsRunsAppParam          = "app"
+	lsRunsTaskHeader        = "Task"
+	lsRunsTaskParam         = "task"
+	lsRunsResultHeader      = "Result"
+	lsRunsResultParam       = "result"
+	lsRunsStartTimeHeader   = "Start Time"
+	lsRunsStartTimeParam    = "start-time"
+	lsRunsDurationHeader    = "Duration"
+	lsRunsDurationParam     = "duration"
+	lsRunsInputDigestHeader = "Input Digest"
+	lsRunsInputDigestParam  = "input-digest"
+	lsRunsLogHeader         = "Log"
+	lsRunsLogParam          = "log"
+	lsRunsGitCommitHeader   = "Git Commit"
+	lsRunsGitCommitParam    = "git-commit"
+	lsRunsHostHeader        = "Host"
+	lsRunsHostParam         = "host"
+	lsRunsUserHeader        = "User"
+	lsRunsUserParam         = "user"
+	lsRunsBaurVersionHeader = "Baur Version"
+	lsRunsBaurVersionParam  = "baur-version"
+	lsRunsCommandHeader     = "Command"
+	lsRunsCommandParam      = "command"
+	lsRunsEnvVarsHeader     = "Environment Variables"
+	lsRunsEnvVarsParam      = "env-vars"
+)
 
 func init() {
 	lsCmd.AddCommand(&newLsRunsCmd().Command)
@@ -43,6 +81,17 @@ type lsRunsCmd struct {
 	before flag.DateTimeFlagValue
 	sort   *flag.Sort
 	quiet  bool
+	fields *flag.Fields
+	format string
+
+	results     flag.Results
+	gitCommit   string
+	dirty       bool
+	inputDigest string
+	longerThan  time.Duration
+	shorterThan time.Duration
+	limit       int
+	offset      int
 
 	app  string
 	task string
@@ -62,12 +111,42 @@ func newLsRunsCmd() *lsRunsCmd {
 			"time":     storage.FieldStartTime,
 			"duration": storage.FieldDuration,
 		}),
+
+		fields: flag.NewFieldsWithDefaults(
+			[]string{
+				lsRunsIDParam,
+				lsRunsAppParam,
+				lsRunsTaskParam,
+				lsRunsResultParam,
+				lsRunsStartTimeParam,
+				lsRunsDurationParam,
+				lsRunsInputDigestParam,
+				lsRunsLogParam,
+				lsRunsGitCommitParam,
+				lsRunsHostParam,
+				lsRunsUserParam,
+				lsRunsBaurVersionParam,
+				lsRunsCommandParam,
+				lsRunsEnvVarsParam,
+			},
+			[]string{
+				lsRunsIDParam,
+				lsRunsAppParam,
+				lsRunsTaskParam,
+				lsRunsResultParam,
+				lsRunsStartTimeParam,
+				lsRunsDurationParam,
+				lsRunsInputDigestParam,
+				lsRunsLogParam,
+			},
+		),
 	}
 
 	cmd.Run = cmd.run
 
 	cmd.Flags().BoolVar(&cmd.csv, "csv", false,
-		"List runs in RFC4180 CSV format")
+		"List runs in RFC4180 CSV format,\n"+
+			"same as --format csv")
 
 	cmd.Flags().BoolVarP(&cmd.quiet, "quiet", "q", false,
 		"Only print task run IDs")
@@ -75,12 +154,40 @@ func newLsRunsCmd() *lsRunsCmd {
 	cmd.Flags().VarP(cmd.sort, "sort", "s",
 		cmd.sort.Usage(term.Highlight))
 
+	cmd.Flags().VarP(cmd.fields, "fields", "f",
+		cmd.fields.Usage(term.Highlight))
+
 	cmd.Flags().VarP(&cmd.after, "after", "a",
 		fmt.Sprintf("Only show runs that were started after this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
 
 	cmd.Flags().VarP(&cmd.before, "before", "b",
 		fmt.Sprintf("Only show runs that were started before this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
 
+	cmd.Flags().VarP(&cmd.results, "result", "r",
+		"Only show runs with one of the comma-separated results")
+
+	cmd.Flags().StringVar(&cmd.gitCommit, "git-commit", "",
+		"Only show runs of Git commits that start with the passed ID")
+
+	cmd.Flags().BoolVar(&cmd.dirty, "dirty", false,
+		"Only show runs of dirty (--dirty) or clean (--dirty=false)\n"+
+			"Git worktrees")
+
+	cmd.Flags().StringVar(&cmd.inputDigest, "input-digest", "",
+		"Only show runs with the passed total input digest")
+
+	cmd.Flags().DurationVar(&cmd.longerThan, "longer-than", 0,
+		"Only show runs that took longer than the duration")
+
+	cmd.Flags().DurationVar(&cmd.shorterThan, "shorter-than", 0,
+		"Only show runs that took less time than the duration")
+
+	cmd.Flags().IntVar(&cmd.limit, "limit", 0,
+		"Show at most this number of runs, 0 means no limit")
+
+	cmd.Flags().IntVar(&cmd.offset, "offset", 0,
+		"Skip this number of runs at the beginning of the list")
+
 	return &cmd
 }
 
@@ -112,21 +219,28 @@ func (c *lsRunsCmd) run(cmd *cobra.Command, args []string) {
 
 	c.app, c.task = parseSpec(args[0])
 
+	pagination := storage.Pagination{Offset: c.offset, Limit: c.limit}
+	exitOnErr(pagination.Validate(), "invalid --offset or --limit")
+
 	repo := mustFindRepository()
 	psql := mustNewCompatibleStorage(repo)
 
-	var formatter format.Formatter
-	if c.csv {
-		formatter = csv.New(nil, stdout)
-	} else {
-		formatter = table.New(nil, stdout)
+	var headers []string
+	keys := fieldsToKeys(c.fields.Fields)
+
+	c.format = mustOutputFormat(c.csv)
+
+	if c.format == flag.FormatTable && !c.quiet {
+		headers = c.createHeader()
 	}
 
-	if !c.csv && !c.quiet {
-		printHeader(formatter)
+	if c.quiet {
+		keys = []string{lsRunsIDParam}
 	}
 
-	filters := c.getFilters()
+	formatter := newFormatter(c.format, headers, keys)
+
+	filters := c.getFilters(cmd)
 	if c.sort.Value != (storage.Sorter{}) {
 		sorters = append(sorters, &c.sort.Value)
 	}
@@ -137,6 +251,7 @@ func (c *lsRunsCmd) run(cmd *cobra.Command, args []string) {
 		ctx,
 		filters,
 		sorters,
+		&pagination,
 		func(taskRun *storage.TaskRunWithID) error {
 			c.printTaskRun(formatter, taskRun)
 			return nil
@@ -154,39 +269,156 @@ func (c *lsRunsCmd) run(cmd *cobra.Command, args []string) {
 	exitOnErr(formatter.Flush())
 }
 
-func printHeader(formatter format.Formatter) {
-	mustWriteRow(
-		formatter,
-		"Id",
-		"App",
-		"Task",
-		"Result",
-		"Start Time",
-		"Duration",
-		"Input Digest",
-	)
+func (c *lsRunsCmd) createHeader() []string {
+	var headers []string
+
+	for _, f := range c.fields.Fields {
+		switch f {
+		case lsRunsIDParam:
+			headers = append(headers, lsRunsIDHeader)
+		case lsRunsAppParam:
+			headers = append(headers, lsRunsAppHeader)
+		case lsRunsTaskParam:
+			headers = append(headers, lsRunsTaskHeader)
+		case lsRunsResultParam:
+			headers = append(headers, lsRunsResultHeader)
+		case lsRunsStartTimeParam:
+			headers = append(headers, lsRunsStartTimeHeader)
+		case lsRunsDurationParam:
+			headers = append(headers, lsRunsDurationHeader)
+		case lsRunsInputDigestParam:
+			headers = append(headers, lsRunsInputDigestHeader)
+		case lsRunsLogParam:
+			headers = append(headers, lsRunsLogHeader)
+		case lsRunsGitCommitParam:
+			headers = append(headers, lsRunsGitCommitHeader)
+		case lsRunsHostParam:
+			headers = append(headers, lsRunsHostHeader)
+		case lsRunsUserParam:
+			headers = append(headers, lsRunsUserHeader)
+		case lsRunsBaurVersionParam:
+			headers = append(headers, lsRunsBaurVersionHeader)
+		case lsRunsCommandParam:
+			headers = append(headers, lsRunsCommandHeader)
+		case lsRunsEnvVarsParam:
+			headers = append(headers, lsRunsEnvVarsHeader)
+
+		default:
+			panic(fmt.Sprintf("unsupported value '%v' in fields parameter", f))
+		}
+	}
+
+	return headers
 }
 
 func (c *lsRunsCmd) printTaskRun(formatter format.Formatter, taskRun *storage.TaskRunWithID) {
+	var row []interface{}
+
 	if c.quiet {
 		mustWriteRow(formatter, taskRun.ID)
+		return
 	}
 
-	mustWriteRow(formatter,
-		strconv.Itoa(taskRun.ID),
-		taskRun.ApplicationName,
-		taskRun.TaskName,
-		taskRun.Result,
-		taskRun.StartTimestamp.Format(flag.DateTimeFormatTz),
-		term.FormatDuration(
-			taskRun.StopTimestamp.Sub(taskRun.StartTimestamp),
-			term.FormatBaseWithoutUnitName(c.csv),
-		),
-		taskRun.TotalInputDigest,
-	)
+	if isJSONFormat(c.format) {
+		mustWriteRow(formatter, c.jsonRow(taskRun)...)
+		return
+	}
+
+	for _, f := range c.fields.Fields {
+		switch f {
+		case lsRunsIDParam:
+			row = append(row, strconv.Itoa(taskRun.ID))
+		case lsRunsAppParam:
+			row = append(row, taskRun.ApplicationName)
+		case lsRunsTaskParam:
+			row = append(row, taskRun.TaskName)
+		case lsRunsResultParam:
+			row = append(row, taskRun.Result)
+		case lsRunsStartTimeParam:
+			row = append(row, taskRun.StartTimestamp.Format(flag.DateTimeFormatTz))
+		case lsRunsDurationParam:
+			row = append(row, term.FormatDuration(
+				taskRun.StopTimestamp.Sub(taskRun.StartTimestamp),
+				term.FormatBaseWithoutUnitName(c.format == flag.FormatCSV),
+			))
+		case lsRunsInputDigestParam:
+			row = append(row, taskRun.TotalInputDigest)
+		case lsRunsLogParam:
+			row = append(row, yesNo(taskRun.HasLog))
+		case lsRunsGitCommitParam:
+			row = append(row, vcsStr(&taskRun.TaskRun))
+		case lsRunsHostParam:
+			row = append(row, taskRun.Hostname)
+		case lsRunsUserParam:
+			row = append(row, taskRun.Username)
+		case lsRunsBaurVersionParam:
+			row = append(row, taskRun.BaurVersion)
+		case lsRunsCommandParam:
+			row = append(row, strings.Join(taskRun.Command, " "))
+		case lsRunsEnvVarsParam:
+			row = append(row, envVarsStr(taskRun.EnvVars))
+		}
+	}
+
+	mustWriteRow(formatter, row...)
+}
+
+// jsonRow returns the values of the selected fields with their types, for
+// the JSON formatters.
+func (c *lsRunsCmd) jsonRow(taskRun *storage.TaskRunWithID) []interface{} {
+	row := make([]interface{}, 0, len(c.fields.Fields))
+
+	for _, f := range c.fields.Fields {
+		switch f {
+		case lsRunsIDParam:
+			row = append(row, taskRun.ID)
+		case lsRunsAppParam:
+			row = append(row, taskRun.ApplicationName)
+		case lsRunsTaskParam:
+			row = append(row, taskRun.TaskName)
+		case lsRunsResultParam:
+			row = append(row, taskRun.Result)
+		case lsRunsStartTimeParam:
+			row = append(row, taskRun.StartTimestamp)
+		case lsRunsDurationParam:
+			row = append(row, taskRun.StopTimestamp.Sub(taskRun.StartTimestamp).Seconds())
+		case lsRunsInputDigestParam:
+			row = append(row, taskRun.TotalInputDigest)
+		case lsRunsLogParam:
+			row = append(row, taskRun.HasLog)
+		case lsRunsGitCommitParam:
+			row = append(row, vcsStr(&taskRun.TaskRun))
+		case lsRunsHostParam:
+			row = append(row, taskRun.Hostname)
+		case lsRunsUserParam:
+			row = append(row, taskRun.Username)
+		case lsRunsBaurVersionParam:
+			row = append(row, taskRun.BaurVersion)
+		case lsRunsCommandParam:
+			row = append(row, taskRun.Command)
+		case lsRunsEnvVarsParam:
+			row = append(row, taskRun.EnvVars)
+		}
+	}
+
+	return row
 }
 
-func (c *lsRunsCmd) getFilters() []*storage.Filter {
+// envVarsStr returns the environment variables as space separated list of
+// NAME=VALUE pairs, sorted by name.
+func envVarsStr(envVars map[string]string) string {
+	pairs := make([]string, 0, len(envVars))
+
+	for name, val := range envVars {
+		pairs = append(pairs, name+"="+val)
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, " ")
+}
+
+func (c *lsRunsCmd) getFilters(cmd *cobra.Command) []*storage.Filter {
 	var filters []*storage.Filter
 
 	if c.app != "" && c.app != "*" {
@@ -221,5 +453,55 @@ func (c *lsRunsCmd) getFilters() []*storage.Filter {
 		})
 	}
 
+	if len(c.results.Values) > 0 {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldResult,
+			Operator: storage.OpIN,
+			Value:    c.results.Values,
+		})
+	}
+
+	if c.gitCommit != "" {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldVCSRevision,
+			Operator: storage.OpPrefix,
+			Value:    c.gitCommit,
+		})
+	}
+
+	// --dirty=false must filter for clean worktrees, the filter is
+	// therefore only omitted if the flag was not passed
+	if cmd.Flags().Changed("dirty") {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldVCSIsDirty,
+			Operator: storage.OpEQ,
+			Value:    c.dirty,
+		})
+	}
+
+	if c.inputDigest != "" {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldTotalInputDigest,
+			Operator: storage.OpEQ,
+			Value:    c.inputDigest,
+		})
+	}
+
+	if c.longerThan > 0 {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldDuration,
+			Operator: storage.OpGT,
+			Value:    c.longerThan,
+		})
+	}
+
+	if c.shorterThan > 0 {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldDuration,
+			Operator: storage.OpLT,
+			Value:    c.shorterThan,
+		})
+	}
+
 	return filters
 }
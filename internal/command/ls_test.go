@@ -0,0 +1,127 @@
+package command
+
+import (
+	"encoding/csv"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/internal/testutils/repotest"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+func setFormatFlag(t *testing.T, val string) {
+	t.Helper()
+
+	prev := formatFlag
+	t.Cleanup(func() { formatFlag = prev })
+
+	require.NoError(t, formatFlag.Set(val))
+}
+
+func TestLsRunsFormatCSVPrintsDurationWithoutUnit(t *testing.T) {
+	initTest(t)
+	setFormatFlag(t, "csv")
+	stdoutBuf, _ := interceptCmdOutput()
+
+	lsRunsCmd := newLsRunsCmd()
+	require.NoError(t, lsRunsCmd.fields.Set(lsRunsDurationParam))
+	lsRunsCmd.format = mustOutputFormat(lsRunsCmd.csv)
+
+	start := time.Now()
+	formatter := newFormatter(lsRunsCmd.format, nil, nil)
+	lsRunsCmd.printTaskRun(formatter, &storage.TaskRunWithID{
+		TaskRun: storage.TaskRun{
+			StartTimestamp: start,
+			StopTimestamp:  start.Add(90 * time.Second),
+		},
+	})
+	require.NoError(t, formatter.Flush())
+
+	rows, err := csv.NewReader(stdoutBuf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"90.000"}}, rows)
+}
+
+func TestLsOutputsFormatCSVPrintsSizeAndDurationWithoutUnit(t *testing.T) {
+	initTest(t)
+	setFormatFlag(t, "csv")
+	stdoutBuf, _ := interceptCmdOutput()
+
+	lsOutputsCmd := newLsOutputsCmd()
+	outFormat := mustOutputFormat(lsOutputsCmd.csv)
+
+	start := time.Now()
+	formatter := newFormatter(outFormat, nil, nil)
+	lsOutputsCmd.printUpload(formatter, outFormat,
+		&storage.Output{
+			Digest:    "sha384:123",
+			Type:      storage.ArtifactTypeFile,
+			SizeBytes: 2048,
+		},
+		&storage.Upload{
+			URI:                  "file:///tmp/out",
+			UploadStartTimestamp: start,
+			UploadStopTimestamp:  start.Add(1500 * time.Millisecond),
+			Method:               storage.UploadMethodFileCopy,
+		},
+	)
+	require.NoError(t, formatter.Flush())
+
+	rows, err := csv.NewReader(stdoutBuf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t,
+		[][]string{{"file:///tmp/out", "sha384:123", "2048", "1.500", "file", "filecopy"}},
+		rows,
+	)
+}
+
+func TestLsInputsMarksConfigFiles(t *testing.T) {
+	prevNoDigestCache := noDigestCacheFlag
+	noDigestCacheFlag = true
+	t.Cleanup(func() { noDigestCacheFlag = prevNoDigestCache })
+
+	testcases := []struct {
+		name     string
+		noConfig bool
+		expected [][]string
+	}{
+		{
+			name: "default",
+			expected: [][]string{
+				{filepath.Join("simpleApp", ".app.toml"), "yes"},
+				{filepath.Join("simpleApp", "check.sh"), "no"},
+			},
+		},
+		{
+			name:     "noConfigs",
+			noConfig: true,
+			expected: [][]string{
+				{filepath.Join("simpleApp", ".app.toml")},
+				{filepath.Join("simpleApp", "check.sh")},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			initTest(t)
+			r := repotest.CreateBaurRepository(t)
+			app := r.CreateSimpleApp(t)
+
+			stdoutBuf, _ := interceptCmdOutput()
+
+			lsInputsCmd := newLsInputsCmd()
+			lsInputsCmd.csv = true
+			lsInputsCmd.noConfig = tc.noConfig
+			lsInputsCmd.Command.Run(&lsInputsCmd.Command, []string{app.Name + ".check"})
+
+			rows, err := csv.NewReader(stdoutBuf).ReadAll()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, rows)
+		})
+	}
+}
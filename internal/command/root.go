@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime/pprof"
+	"syscall"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -41,7 +43,7 @@ func initSb(_ *cobra.Command, _ []string) {
 		exec.DefaultDebugfFn = log.StdLogger.Debugf
 	}
 
-	if noColorFlag {
+	if noColorFlag || formatFlag.IsJSON() {
 		color.NoColor = true
 	}
 
@@ -57,6 +59,27 @@ func initSb(_ *cobra.Command, _ []string) {
 	}
 }
 
+// cancelOnSignal returns a context that is cancelled when SIGINT or SIGTERM is
+// received. When a second signal is received the process exits immediately.
+func cancelOnSignal(parent context.Context) context.Context {
+	ctx, cancel := context.WithCancel(parent)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigChan
+		stderr.Printf("received %s, terminating...\n", sig)
+		cancel()
+
+		sig = <-sigChan
+		stderr.Printf("received %s, exiting immediately\n", sig)
+		exitFunc(1)
+	}()
+
+	return ctx
+}
+
 // Execute parses commandline flags and execute their actions
 func Execute() {
 	if err := version.LoadPackageVars(); err != nil {
@@ -68,8 +91,15 @@ func Execute() {
 	rootCmd.PersistentFlags().BoolVar(&cpuProfilingFlag, "cpu-prof", false,
 		fmt.Sprintf("enable cpu profiling, result is written to %q", defCPUProfFile))
 	rootCmd.PersistentFlags().BoolVar(&noColorFlag, "no-color", false, "disable color output")
+	rootCmd.PersistentFlags().Var(&formatFlag, "format",
+		"output format of commands that list or show records")
+	rootCmd.PersistentFlags().BoolVar(&noDigestCacheFlag, "no-digest-cache", false,
+		"calculate the digests of all input files,\n"+
+			"instead of reusing digests of unchanged files from previous runs")
+
+	ctx = cancelOnSignal(ctx)
 
-	if err := rootCmd.Execute(); err != nil {
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		log.Fatalln(err)
 	}
 
@@ -2,9 +2,11 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -17,6 +19,7 @@ import (
 	"github.com/simplesurance/baur/v1/internal/upload/filecopy"
 	"github.com/simplesurance/baur/v1/internal/upload/s3"
 	"github.com/simplesurance/baur/v1/internal/vcs"
+	"github.com/simplesurance/baur/v1/internal/version"
 	"github.com/simplesurance/baur/v1/storage"
 )
 
@@ -24,11 +27,37 @@ const runExample = `
 baur run auth				run all tasks of the auth application, upload the produced outputs
 baur run calc.check			run the check task of the calc application and upload the produced outputs
 baur run --force			run and upload all tasks of applications, independent of their status
+baur run --parallel 4			run up to 4 tasks in parallel
+baur run -p 4 --show-task-output	run up to 4 tasks in parallel and show their output
+baur run --no-deps shop.build		run the build task of the shop application, without the tasks it depends on
+baur run --keep-going			run all pending tasks, also when some of them fail
+baur run --rerun-failed			run the tasks whose last run with the same inputs failed
+baur run --restore-outputs		run pending tasks, restore the outputs of tasks that were run before
 `
 
 var runLongHelp = fmt.Sprintf(`
 Execute tasks of applications.
 By default all tasks of all applications with status %s are run.
+Tasks are run after the tasks they depend on (depends_on).
+Dependencies of the passed tasks are run too if they have status %s,
+this can be disabled by passing --no-deps.
+
+Runs of tasks that fail are recorded with result failure.
+When the command of a task runs longer than the timeout of the task, it is
+terminated and the run is recorded with result timeout.
+When baur receives SIGINT or SIGTERM, running commands are terminated and
+their runs are recorded with result cancelled.
+By default no further tasks are started after a task failed,
+when --keep-going is passed the remaining tasks are run.
+baur exits with a non-zero code if a task failed.
+
+The output of a task is shown when it's execution fails. When
+--show-task-output is passed, the output of all tasks is shown while they
+run, each line is prefixed with the ID of the task.
+
+When --restore-outputs is passed, the outputs of tasks with status %s
+are restored from the run with the same inputs before pending tasks are run,
+like it is done by 'baur fetch'.
 
 The following Environment Variables are supported:
     %s
@@ -45,6 +74,8 @@ The following Environment Variables are supported:
     %s
 `,
 	term.ColoredTaskStatus(baur.TaskStatusExecutionPending),
+	term.ColoredTaskStatus(baur.TaskStatusExecutionPending),
+	term.ColoredTaskStatus(baur.TaskStatusRunExist),
 
 	term.Highlight(envVarPSQLURL),
 
@@ -57,6 +88,10 @@ The following Environment Variables are supported:
 	term.Highlight("DOCKER_CERT_PATH"),
 	term.Highlight("DOCKER_TLS_VERIFY"))
 
+// recordCancelledRunTimeout is the max. duration for storing the record of a
+// task run that was cancelled.
+const recordCancelledRunTimeout = 30 * time.Second
+
 func init() {
 	rootCmd.AddCommand(&newRunCmd().Command)
 }
@@ -69,15 +104,26 @@ type runCmd struct {
 	force          bool
 	inputStr       string
 	lookupInputStr string
+	parallel       uint
+	noDeps         bool
+	keepGoing      bool
+	rerunFailed    bool
+	restoreOutputs bool
+	showOutput     bool
 
 	// other fields
 	storage      storage.Storer
 	repoRootPath string
 	dockerClient *docker.Client
 	uploader     *baur.Uploader
+	restorer     *baur.Restorer
 	vcsState     vcs.StateFetcher
+	provenance   *baur.RunProvenance
 
 	uploadRoutinePool *routines.Pool
+
+	failedTasks     []*baur.Task
+	failedTasksLock sync.Mutex // protects failedTasks
 }
 
 func newRunCmd() *runCmd {
@@ -100,6 +146,22 @@ func newRunCmd() *runCmd {
 		"include a string as an input")
 	cmd.Flags().StringVar(&cmd.lookupInputStr, "lookup-input-str", "",
 		"if a run can not be found, try to find a run with this value as input-string")
+	cmd.Flags().UintVarP(&cmd.parallel, "parallel", "p", 1,
+		"maximum number of tasks that are run in parallel,\n"+
+			"defaults to the parallel setting in the repository config")
+	cmd.Flags().BoolVar(&cmd.noDeps, "no-deps", false,
+		"do not run the tasks that the specified tasks depend on")
+	cmd.Flags().BoolVarP(&cmd.keepGoing, "keep-going", "k", false,
+		"continue running the remaining tasks when a task fails")
+	cmd.Flags().BoolVar(&cmd.rerunFailed, "rerun-failed", false,
+		"only run tasks with status pending whose most recent run\n"+
+			"with the same inputs failed")
+	cmd.Flags().BoolVar(&cmd.restoreOutputs, "restore-outputs", false,
+		"restore the outputs of tasks that are not run because\n"+
+			"a run with the same inputs exist")
+	cmd.Flags().BoolVarP(&cmd.showOutput, "show-task-output", "o", false,
+		"show the output of tasks while they run,\n"+
+			"each line is prefixed with the task ID")
 
 	return &cmd
 }
@@ -109,11 +171,25 @@ func (c *runCmd) run(cmd *cobra.Command, args []string) {
 
 	startTime := time.Now()
 
+	if c.force && c.rerunFailed {
+		stderr.Printf("--force and --rerun-failed can not be passed at the same time\n")
+		exitFunc(1)
+	}
+
 	repo := mustFindRepository()
 	c.repoRootPath = repo.Path
 
 	c.storage = mustNewCompatibleStorage(repo)
 
+	if !cmd.Flags().Changed("parallel") && repo.Cfg.Run.Parallel > 0 {
+		c.parallel = uint(repo.Cfg.Run.Parallel)
+	}
+
+	if c.parallel == 0 {
+		stderr.Printf("--parallel must be greater than 0\n")
+		exitFunc(1)
+	}
+
 	c.uploadRoutinePool = routines.NewPool(1) // run 1 upload in parallel with builds
 
 	c.dockerClient, err = docker.NewClient(log.StdLogger.Debugf)
@@ -121,10 +197,15 @@ func (c *runCmd) run(cmd *cobra.Command, args []string) {
 
 	s3Client, err := s3.NewClient(log.StdLogger)
 	exitOnErr(err)
-	c.uploader = baur.NewUploader(c.dockerClient, s3Client, filecopy.New(log.Debugf))
+	filecopyClient := filecopy.New(log.Debugf)
+	c.uploader = baur.NewUploader(c.dockerClient, s3Client, filecopyClient)
+	c.restorer = baur.NewRestorer(c.dockerClient, s3Client, filecopyClient)
 
 	c.vcsState = mustGetRepoState(repo.Path)
 
+	c.provenance, err = baur.NewRunProvenance(version.CurSemVer.String(), repo.Cfg.Run.RecordEnvVars)
+	exitOnErr(err)
+
 	if c.skipUpload {
 		stdout.Printf("--skip-upload was passed, outputs won't be uploaded and task runs not recorded\n\n")
 	}
@@ -132,16 +213,38 @@ func (c *runCmd) run(cmd *cobra.Command, args []string) {
 	loader, err := baur.NewLoader(repo.Cfg, c.vcsState.CommitID, log.StdLogger)
 	exitOnErr(err)
 
-	tasks, err := loader.LoadTasks(args...)
+	requestedTasks, err := loader.LoadTasks(args...)
+	exitOnErr(err)
+
+	var tasks []*baur.Task
+	if c.noDeps {
+		tasks, err = baur.SortTasksByDependencies(requestedTasks)
+	} else {
+		tasks, err = loader.LoadDependencies(requestedTasks)
+	}
 	exitOnErr(err)
 
-	baur.SortTasksByID(tasks)
+	inputResolver, saveDigestCache := newInputResolver(repo)
 
-	pendingTasks, err := c.filterPendingTasks(tasks)
+	pendingTasks, existingRuns, err := c.filterPendingTasks(inputResolver, tasks, requestedTasks)
 	exitOnErr(err)
 
+	saveDigestCache()
+
 	stdout.PrintSep()
 
+	if c.restoreOutputs && len(existingRuns) > 0 {
+		stdout.Printf("Restoring outputs of %d task(s) with status %s\n\n",
+			len(existingRuns), term.ColoredTaskStatus(baur.TaskStatusRunExist))
+
+		for _, r := range existingRuns {
+			err := restoreRunOutputs(c.restorer, c.storage, r.task, r.runID)
+			exitOnErrf(err, "%s", r.task.ID())
+		}
+
+		stdout.PrintSep()
+	}
+
 	if c.force {
 		stdout.Printf("Running %d/%d task(s) with status %s, %s\n\n",
 			len(pendingTasks), len(tasks), term.ColoredTaskStatus(baur.TaskStatusExecutionPending), term.ColoredTaskStatus(baur.TaskStatusRunExist))
@@ -159,6 +262,18 @@ func (c *runCmd) run(cmd *cobra.Command, args []string) {
 			time.Since(startTime),
 		),
 	)
+
+	if len(c.failedTasks) > 0 {
+		baur.SortTasksByID(c.failedTasks)
+
+		stderr.Printf("%d task(s) %s: %s\n",
+			len(c.failedTasks),
+			term.RedHighlight("failed"),
+			strings.Join(tasksToIDs(c.failedTasks), ", "),
+		)
+
+		exitFunc(1)
+	}
 }
 
 type pendingTask struct {
@@ -166,6 +281,13 @@ type pendingTask struct {
 	inputs *baur.Inputs
 }
 
+// existingRun is a task that is not run because a run with the same inputs
+// exist.
+type existingRun struct {
+	task  *baur.Task
+	runID int
+}
+
 func (c *runCmd) uploadAndRecord(
 	ctx context.Context,
 	task *baur.Task,
@@ -203,62 +325,143 @@ func (c *runCmd) uploadAndRecord(
 		exitOnErrf(err, "%s: %s", task.ID(), output)
 	}
 
-	id, err := baur.StoreRun(ctx, c.storage, c.vcsState, task, inputs, runResult, uploadResults)
+	id, err := baur.StoreRun(ctx, c.storage, c.vcsState, task, inputs, runResult, uploadResults, c.provenance)
 	exitOnErrf(err, "%s", task.ID())
 
 	stdout.TaskPrintf(task, "run stored in database with ID %s\n", term.Highlight(id))
 }
 
+// runUploadStore runs the tasks concurrently, uploads their outputs and
+// records the runs.
+// taskToRun must be sorted by baur.SortTasksByDependencies().
 func (c *runCmd) runUploadStore(taskToRun []*pendingTask) {
 	taskRunner := baur.NewTaskRunner()
+	if c.showOutput {
+		taskRunner.LogFn = func(format string, v ...interface{}) {
+			stdout.Println(fmt.Sprintf(format, v...))
+		}
+	}
 
-	for _, t := range taskToRun {
-		// TODO: record the result as failed if run exitCode is != 0
-		// except when a flag like --errors-are-fatal is passed
-		runResult, err := taskRunner.Run(t.task)
-		exitOnErrf(err, "%s", t.task.ID())
+	scheduler := taskScheduler{
+		parallel:   c.parallel,
+		keepGoing:  c.keepGoing,
+		taskRunner: taskRunner,
+		runFn: func(t *pendingTask) bool {
+			return c.runUploadStoreTask(taskRunner, t)
+		},
+		skipFn: func(t *pendingTask, reason string) {
+			stdout.TaskPrintf(t.task, "%s, %s\n", term.YellowHighlight("skipped"), reason)
+		},
+	}
+
+	scheduler.run(ctx, taskToRun)
+}
 
-		if runResult.Result.ExitCode != 0 {
-			statusStr := term.RedHighlight("failed")
+func (c *runCmd) addFailedTask(task *baur.Task) {
+	c.failedTasksLock.Lock()
+	defer c.failedTasksLock.Unlock()
 
-			log.Fatalf("%s: execution %s (%s), command exited with code %d, output:\n%s\n",
-				t.task,
-				statusStr,
+	c.failedTasks = append(c.failedTasks, task)
+}
+
+// runUploadStoreTask runs the task, uploads it's outputs and records the run.
+// Uploading and recording is done asynchronously in the uploadRoutinePool.
+// If the command of the task exits with a non-zero code, times out or is
+// cancelled, the run is recorded with the corresponding result.
+// The method returns true if the task was run successfully.
+func (c *runCmd) runUploadStoreTask(taskRunner *baur.TaskRunner, t *pendingTask) bool {
+	runResult, err := taskRunner.Run(ctx, t.task)
+	exitOnErrf(err, "%s", t.task.ID())
+
+	if runResult.Status != baur.RunStatusSuccess {
+		c.addFailedTask(t.task)
+
+		if c.showOutput {
+			stderr.TaskPrintf(t.task, "execution %s (%s), command exited with code %d\n",
+				term.RedHighlight(runResult.Status),
+				term.FormatDuration(
+					runResult.StopTime.Sub(runResult.StartTime),
+				),
+				runResult.ExitCode)
+		} else {
+			stderr.TaskPrintf(t.task, "execution %s (%s), command exited with code %d, output:\n%s\n",
+				term.RedHighlight(runResult.Status),
 				term.FormatDuration(
 					runResult.StopTime.Sub(runResult.StartTime),
 				),
 				runResult.ExitCode,
-				runResult.StrOutput())
+				prefixLines(t.task.ID()+": ", runResult.StrOutput()))
 		}
 
-		statusStr := term.GreenHighlight("successful")
+		if !c.skipUpload {
+			c.uploadRoutinePool.Queue(func() {
+				recordCtx := ctx
+				if runResult.Status == baur.RunStatusCancelled {
+					// ctx is cancelled, use a separate context
+					// to still be able to record the run
+					var cancel context.CancelFunc
+					recordCtx, cancel = context.WithTimeout(context.Background(), recordCancelledRunTimeout)
+					defer cancel()
+				}
+
+				c.uploadAndRecord(recordCtx, t.task, t.inputs, nil, runResult)
+			})
+		}
 
-		stdout.TaskPrintf(t.task, "execution %s (%s)\n",
-			statusStr,
-			term.FormatDuration(
-				runResult.StopTime.Sub(runResult.StartTime),
-			),
-		)
+		return false
+	}
 
-		outputs, err := baur.OutputsFromTask(c.dockerClient, t.task)
-		exitOnErrf(err, "%s", t.task.ID())
+	statusStr := term.GreenHighlight("successful")
 
-		if !outputsExist(t.task, outputs) {
-			exitFunc(1)
-		}
+	stdout.TaskPrintf(t.task, "execution %s (%s)\n",
+		statusStr,
+		term.FormatDuration(
+			runResult.StopTime.Sub(runResult.StartTime),
+		),
+	)
 
-		if c.skipUpload {
-			continue
-		}
+	outputs, err := baur.OutputsFromTask(c.dockerClient, t.task)
+	exitOnErrf(err, "%s", t.task.ID())
 
-		// copy the iteration variable, to prevent that it's value
-		// changes in the closure to 't' of the next iteration before
-		// the closure is executed
-		taskCopy := t
-		c.uploadRoutinePool.Queue(func() {
-			c.uploadAndRecord(ctx, taskCopy.task, taskCopy.inputs, outputs, runResult)
-		})
+	if !outputsExist(t.task, outputs) {
+		c.addFailedTask(t.task)
+		return false
+	}
+
+	if c.skipUpload {
+		return true
+	}
+
+	c.uploadRoutinePool.Queue(func() {
+		c.uploadAndRecord(ctx, t.task, t.inputs, outputs, runResult)
+	})
+
+	return true
+}
+
+func tasksToIDs(tasks []*baur.Task) []string {
+	result := make([]string, 0, len(tasks))
+
+	for _, t := range tasks {
+		result = append(result, t.ID())
+	}
+
+	return result
+}
+
+// prefixLines prepends prefix to every line in str.
+// It is used to make the output of tasks that run in parallel distinguishable.
+func prefixLines(prefix, str string) string {
+	if str == "" {
+		return str
 	}
+
+	lines := strings.Split(str, "\n")
+	for i, l := range lines {
+		lines[i] = prefix + l
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 func outputsExist(task *baur.Task, outputs []baur.Output) bool {
@@ -304,30 +507,68 @@ func maxTaskIDLen(tasks []*baur.Task) int {
 	return maxLen
 }
 
-func (c *runCmd) filterPendingTasks(tasks []*baur.Task) ([]*pendingTask, error) {
+// filterPendingTasks returns the tasks that must be run and the runs of tasks
+// that are not run because they have status TaskStatusRunExist.
+// Tasks that are not part of requestedTasks were only loaded because
+// requested tasks depend on them, they are only run if their status is
+// pending, independent of the force flag.
+func (c *runCmd) filterPendingTasks(inputResolver *baur.InputResolver, tasks, requestedTasks []*baur.Task) ([]*pendingTask, []*existingRun, error) {
 	var result []*pendingTask
+	var existingRuns []*existingRun
 	const sep = " => "
 
+	requested := make(map[string]struct{}, len(requestedTasks))
+	for _, task := range requestedTasks {
+		requested[task.ID()] = struct{}{}
+	}
+
 	taskIDColLen := maxTaskIDLen(tasks) + len(sep)
-	statusEvaluator := baur.NewTaskStatusEvaluator(c.repoRootPath, c.storage, baur.NewInputResolver(), c.inputStr, c.lookupInputStr)
+	statusEvaluator := baur.NewTaskStatusEvaluator(c.repoRootPath, c.storage, inputResolver, c.inputStr, c.lookupInputStr)
 
 	stdout.Printf("Evaluating status of tasks:\n\n")
 
-	for _, task := range tasks {
-		status, inputs, run, err := statusEvaluator.Status(ctx, task)
-		if err != nil {
-			return nil, fmt.Errorf("%s: evaluating task status failed: %w", task, err)
+	statuses, err := statusEvaluator.StatusBatch(ctx, tasks)
+	if err != nil {
+		return nil, nil, fmt.Errorf("evaluating task status failed: %w", err)
+	}
+
+	for _, taskStatus := range statuses {
+		task := taskStatus.Task
+		status := taskStatus.Status
+		inputs := taskStatus.Inputs
+		run := taskStatus.Run
+
+		_, isRequested := requested[task.ID()]
+
+		var depStr string
+		if !isRequested {
+			depStr = " (dependency)"
 		}
 
 		if status == baur.TaskStatusRunExist {
-			stdout.Printf("%-*s%s%s (%s)\n",
-				taskIDColLen, task, sep, term.ColoredTaskStatus(status), term.GreenHighlight(run.ID))
+			stdout.Printf("%-*s%s%s (%s)%s\n",
+				taskIDColLen, task, sep, term.ColoredTaskStatus(status), term.GreenHighlight(run.ID), depStr)
 
-			if !c.force {
+			if !c.force || !isRequested {
+				existingRuns = append(existingRuns, &existingRun{task: task, runID: run.ID})
 				continue
 			}
+		} else if c.rerunFailed && isRequested {
+			failedRun, err := statusEvaluator.LatestFailedRun(ctx, task, inputs)
+			if err != nil {
+				if !errors.Is(err, storage.ErrNotExist) {
+					return nil, nil, fmt.Errorf("%s: %w", task, err)
+				}
+
+				stdout.Printf("%-*s%s%s, no failed run exists\n", taskIDColLen, task, sep, term.ColoredTaskStatus(status))
+
+				continue
+			}
+
+			stdout.Printf("%-*s%s%s (%s: %s)\n",
+				taskIDColLen, task, sep, term.ColoredTaskStatus(status), term.RedHighlight(failedRun.Result), term.Highlight(failedRun.ID))
 		} else {
-			stdout.Printf("%-*s%s%s\n", taskIDColLen, task, sep, term.ColoredTaskStatus(status))
+			stdout.Printf("%-*s%s%s%s\n", taskIDColLen, task, sep, term.ColoredTaskStatus(status), depStr)
 		}
 
 		result = append(result, &pendingTask{
@@ -336,5 +577,5 @@ func (c *runCmd) filterPendingTasks(tasks []*baur.Task) ([]*pendingTask, error)
 		})
 	}
 
-	return result, nil
+	return result, existingRuns, nil
 }
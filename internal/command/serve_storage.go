@@ -0,0 +1,132 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v1/internal/command/term"
+	"github.com/simplesurance/baur/v1/internal/log"
+	"github.com/simplesurance/baur/v1/storage/httpstorage"
+)
+
+// envVarStorageToken contains the name of the environment variable that
+// contains the token that authenticates clients of the storage server.
+const envVarStorageToken = "BAUR_STORAGE_TOKEN"
+
+// serveStorageShutdownTimeout is the max. duration that the storage server
+// waits for running requests to finish on termination.
+const serveStorageShutdownTimeout = 30 * time.Second
+
+var serveStorageLongHelp = fmt.Sprintf(`
+Serve the baur database via an HTTP API.
+
+The server forwards the requests to the database that is configured in the
+repository configuration, set via the '%s' environment variable
+or passed via --database-url.
+Clients use the server by setting the database URL to the
+http:// or https:// URL of the server.
+
+Clients must authenticate with a token. The token is read from the '%s'
+environment variable, on the server and on the clients.
+If --tls-cert and --tls-key are passed, the server serves HTTPS.
+`,
+	term.Highlight(envVarPSQLURL),
+	term.Highlight(envVarStorageToken),
+)
+
+const serveStorageExample = `
+baur serve-storage --listen :8443 --tls-cert cert.pem --tls-key key.pem
+`
+
+func init() {
+	rootCmd.AddCommand(&newServeStorageCmd().Command)
+}
+
+type serveStorageCmd struct {
+	cobra.Command
+
+	listenAddr  string
+	databaseURL string
+	tlsCert     string
+	tlsKey      string
+}
+
+func newServeStorageCmd() *serveStorageCmd {
+	cmd := serveStorageCmd{
+		Command: cobra.Command{
+			Use:     "serve-storage",
+			Short:   "serve the baur database via an HTTP API",
+			Long:    strings.TrimSpace(serveStorageLongHelp),
+			Example: strings.TrimSpace(serveStorageExample),
+			Args:    cobra.NoArgs,
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().StringVarP(&cmd.listenAddr, "listen", "l", ":8080",
+		"address on that the server listens")
+	cmd.Flags().StringVar(&cmd.databaseURL, "database-url", "",
+		"URL of the database that is served,\n"+
+			"defaults to the database of the repository")
+	cmd.Flags().StringVar(&cmd.tlsCert, "tls-cert", "",
+		"path to a TLS certificate file")
+	cmd.Flags().StringVar(&cmd.tlsKey, "tls-key", "",
+		"path to a TLS key file")
+
+	return &cmd
+}
+
+func (c *serveStorageCmd) run(cmd *cobra.Command, args []string) {
+	if (c.tlsCert == "") != (c.tlsKey == "") {
+		stderr.Printf("--tls-cert and --tls-key must be passed together\n")
+		exitFunc(1)
+	}
+
+	token := os.Getenv(envVarStorageToken)
+	if token == "" {
+		stderr.Printf("the %s environment variable must be set\n", envVarStorageToken)
+		exitFunc(1)
+	}
+
+	storageClt := mustNewCompatibleStorageForURL(c.databaseURL)
+	defer storageClt.Close()
+
+	handler, err := httpstorage.NewServer(storageClt, token, log.StdLogger)
+	exitOnErr(err)
+
+	srv := http.Server{
+		Addr:    c.listenAddr,
+		Handler: handler,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serveStorageShutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			stderr.Printf("shutting down server failed: %s\n", err)
+		}
+	}()
+
+	stdout.Printf("serving storage on %s\n", term.Highlight(c.listenAddr))
+
+	if c.tlsCert != "" {
+		err = srv.ListenAndServeTLS(c.tlsCert, c.tlsKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		exitOnErr(err)
+	}
+}
@@ -3,12 +3,14 @@ package command
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/simplesurance/baur/v1"
+	"github.com/simplesurance/baur/v1/internal/command/flag"
 	"github.com/simplesurance/baur/v1/internal/command/term"
 	"github.com/simplesurance/baur/v1/internal/format"
 	"github.com/simplesurance/baur/v1/internal/format/table"
@@ -24,6 +26,7 @@ If the name or path of an application directory is passed,
 application information are shown.
 If a numeric task-run ID is passed, information about the
 recorded task run are shown.
+If --log is passed, the output of the task run's command is printed.
 `
 
 const showExamples = `
@@ -31,6 +34,7 @@ baur show calc		show information about the calc application
 baur show calc.build	show information about the build task of the calc application
 baur show ui/shop	show information about the app in the ui/shop directory
 baur show 512		show information about build 512
+baur show --log 512	show the command output of build 512
 `
 
 func init() {
@@ -39,6 +43,10 @@ func init() {
 
 type showCmd struct {
 	cobra.Command
+
+	log bool
+
+	format string
 }
 
 func newShowCmd() *showCmd {
@@ -54,18 +62,37 @@ func newShowCmd() *showCmd {
 
 	cmd.Run = cmd.run
 
+	cmd.Flags().BoolVar(&cmd.log, "log", false,
+		"show the command output of a task run")
+
 	return &cmd
 }
 
 func (c *showCmd) run(cmd *cobra.Command, args []string) {
 	arg := args[0]
 
+	c.format = mustOutputFormat(false)
+	if c.format == flag.FormatCSV {
+		stderr.Printf("the %s format is not supported by this command\n", flag.FormatCSV)
+		exitFunc(1)
+	}
+
 	buildID, err := strconv.Atoi(arg)
 	if err == nil {
+		if c.log {
+			c.showBuildLog(buildID)
+			return
+		}
+
 		c.showBuild(buildID)
 		return
 	}
 
+	if c.log {
+		stderr.Printf("--log can only be passed with a task run ID\n")
+		exitFunc(1)
+	}
+
 	if isDir, _ := fs.IsDir(arg); isDir {
 		c.showApp(arg)
 		return
@@ -126,6 +153,11 @@ func (c *showCmd) showApp(appName string) {
 	tasks := app.Tasks()
 	baur.SortTasksByID(tasks)
 
+	if isJSONFormat(c.format) {
+		mustWriteJSONDocument(c.format, newShowAppJSON(app, tasks))
+		return
+	}
+
 	mustWriteRow(formatter, "Application Name:", term.Highlight(app.Name), "", "")
 	mustWriteRow(formatter, "Path:", term.Highlight(app.RelPath), "")
 
@@ -149,6 +181,11 @@ func (c *showCmd) showTask(taskName string) {
 
 	task := mustArgToTask(repo, taskName)
 
+	if isJSONFormat(c.format) {
+		mustWriteJSONDocument(c.format, newShowTaskJSON(task))
+		return
+	}
+
 	c.printTask(formatter, task)
 
 	err := formatter.Flush()
@@ -161,6 +198,12 @@ func (c *showCmd) printTask(formatter format.Formatter, task *baur.Task) {
 	mustWriteRow(formatter, "", "Command:", term.Highlight(
 		c.strCmd(task.Command),
 	), "", "")
+	mustWriteStringSliceRows(formatter, "Mutex Groups:", 1, task.MutexGroups)
+	mustWriteStringSliceRows(formatter, "Depends On:", 1, task.DependsOn)
+
+	if task.Timeout > 0 {
+		mustWriteRow(formatter, "", "Timeout:", term.Highlight(task.Timeout), "", "")
+	}
 
 	if task.HasInputs() {
 		mustWriteRow(formatter, "", "", "", "")
@@ -170,6 +213,7 @@ func (c *showCmd) printTask(formatter format.Formatter, task *baur.Task) {
 			mustWriteRow(formatter, "", "", "Type:", term.Highlight("File"))
 			mustWriteRow(formatter, "", "", "Optional:", term.Highlight(f.Optional))
 			mustWriteStringSliceRows(formatter, "Paths:", 2, f.Paths)
+			mustWriteStringSliceRows(formatter, "Exclude:", 2, f.Exclude)
 
 			if i+1 < len(task.UnresolvedInputs.Files) {
 				mustWriteRow(formatter, "", "", "", "")
@@ -184,6 +228,7 @@ func (c *showCmd) printTask(formatter format.Formatter, task *baur.Task) {
 			mustWriteRow(formatter, "", "", "Type:", term.Highlight("GitFile"))
 			mustWriteRow(formatter, "", "", "Optional:", term.Highlight(g.Optional))
 			mustWriteStringSliceRows(formatter, "Paths:", 2, g.Paths)
+			mustWriteStringSliceRows(formatter, "Exclude:", 2, g.Exclude)
 
 			if i+1 < len(task.UnresolvedInputs.GitFiles) {
 				mustWriteRow(formatter, "", "", "", "")
@@ -207,6 +252,19 @@ func (c *showCmd) printTask(formatter format.Formatter, task *baur.Task) {
 				mustWriteRow(formatter, "", "", "", "")
 			}
 		}
+
+		for _, e := range task.UnresolvedInputs.EnvironmentVariables {
+			mustWriteRow(formatter, "", "", "", "")
+			mustWriteRow(formatter, "", "", "Type:", term.Highlight("EnvironmentVariables"))
+			mustWriteRow(formatter, "", "", "Optional:", term.Highlight(e.Optional))
+			mustWriteStringSliceRows(formatter, "Names:", 2, e.Names)
+		}
+
+		for _, cmd := range task.UnresolvedInputs.CommandOutput {
+			mustWriteRow(formatter, "", "", "", "")
+			mustWriteRow(formatter, "", "", "Type:", term.Highlight("CommandOutput"))
+			mustWriteRow(formatter, "", "", "Command:", term.Highlight(c.strCmd(cmd.Command)))
+		}
 	}
 
 	if task.HasOutputs() {
@@ -260,7 +318,7 @@ func vcsStr(v *storage.TaskRun) string {
 	return v.VCSRevision
 }
 
-func (*showCmd) showBuild(taskRunID int) {
+func (c *showCmd) showBuild(taskRunID int) {
 	repo := mustFindRepository()
 	storageClt := mustNewCompatibleStorage(repo)
 
@@ -278,6 +336,11 @@ func (*showCmd) showBuild(taskRunID int) {
 		exitOnErr(err)
 	}
 
+	if isJSONFormat(c.format) {
+		mustWriteJSONDocument(c.format, newShowRunJSON(taskRun, outputs))
+		return
+	}
+
 	formatter := table.New(nil, stdout)
 
 	mustWriteRow(formatter, "Run-ID:", term.Highlight(taskRun.ID))
@@ -290,6 +353,8 @@ func (*showCmd) showBuild(taskRunID int) {
 		mustWriteRow(formatter, "Result", term.RedHighlight(taskRun.Result))
 	}
 
+	mustWriteRow(formatter, "Exit Code:", term.Highlight(taskRun.ExitCode))
+
 	mustWriteRow(formatter, "Started At:", term.Highlight(taskRun.StartTimestamp))
 	mustWriteRow(
 		formatter,
@@ -304,7 +369,29 @@ func (*showCmd) showBuild(taskRunID int) {
 	mustWriteRow(formatter, "Git Commit:", term.Highlight(vcsStr(&taskRun.TaskRun)))
 
 	mustWriteRow(formatter, "Total Input Digest:", term.Highlight(taskRun.TotalInputDigest))
+	mustWriteRow(formatter, "Input Digest Version:", term.Highlight(taskRun.InputDigestVersion))
 	mustWriteRow(formatter, "Output Count:", term.Highlight(len(outputs)))
+	mustWriteRow(formatter, "Log Available:", term.Highlight(yesNo(taskRun.HasLog)))
+
+	mustWriteRow(formatter, "Host:", term.Highlight(taskRun.Hostname))
+	mustWriteRow(formatter, "User:", term.Highlight(taskRun.Username))
+	mustWriteRow(formatter, "Baur Version:", term.Highlight(taskRun.BaurVersion))
+	mustWriteRow(formatter, "Command:", term.Highlight(c.strCmd(taskRun.Command)))
+
+	if len(taskRun.EnvVars) > 0 {
+		mustWriteRow(formatter)
+		mustWriteRow(formatter, term.Underline("Environment Variables:"))
+
+		names := make([]string, 0, len(taskRun.EnvVars))
+		for name := range taskRun.EnvVars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			mustWriteRow(formatter, "", name+":", term.Highlight(taskRun.EnvVars[name]))
+		}
+	}
 
 	if len(outputs) > 0 {
 		mustWriteRow(formatter)
@@ -355,3 +442,40 @@ func (*showCmd) showBuild(taskRunID int) {
 	err = formatter.Flush()
 	exitOnErr(err)
 }
+
+func (*showCmd) showBuildLog(taskRunID int) {
+	repo := mustFindRepository()
+	storageClt := mustNewCompatibleStorage(repo)
+
+	runLog, err := storageClt.TaskRunLog(ctx, taskRunID)
+	if err != nil {
+		if err == storage.ErrNotExist {
+			log.Fatalf("no log exists for task run with id %d\n", taskRunID)
+		}
+
+		exitOnErr(err)
+	}
+
+	output, err := runLog.Output()
+	exitOnErrf(err, "decompressing log failed")
+
+	if runLog.Truncated {
+		stderr.Printf("log was truncated, only the last %s are available\n",
+			term.FormatSize(uint64(len(output))))
+	}
+
+	_, err = stdout.Write(output)
+	exitOnErr(err)
+
+	if len(output) > 0 && output[len(output)-1] != '\n' {
+		stdout.Println()
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
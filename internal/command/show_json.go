@@ -0,0 +1,241 @@
+package command
+
+import (
+	"sort"
+	"time"
+
+	"github.com/simplesurance/baur/v1"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// The types in this file are the JSON representations of the information
+// that baur show prints.
+
+type showAppJSON struct {
+	Name  string          `json:"name"`
+	Path  string          `json:"path"`
+	Tasks []*showTaskJSON `json:"tasks"`
+}
+
+type showTaskJSON struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Command     []string          `json:"command"`
+	MutexGroups []string          `json:"mutex_groups,omitempty"`
+	DependsOn   []string          `json:"depends_on,omitempty"`
+	Timeout     string            `json:"timeout,omitempty"`
+	Inputs      []*showInputJSON  `json:"inputs,omitempty"`
+	Outputs     []*showOutputJSON `json:"outputs,omitempty"`
+}
+
+type showInputJSON struct {
+	Type        string   `json:"type"`
+	Optional    bool     `json:"optional"`
+	Paths       []string `json:"paths,omitempty"`
+	Exclude     []string `json:"exclude,omitempty"`
+	Names       []string `json:"names,omitempty"`
+	Queries     []string `json:"queries,omitempty"`
+	Environment []string `json:"environment,omitempty"`
+	BuildFlags  []string `json:"build_flags,omitempty"`
+	Tests       bool     `json:"tests,omitempty"`
+	Command     []string `json:"command,omitempty"`
+}
+
+type showOutputJSON struct {
+	Type                string `json:"type"`
+	Path                string `json:"path,omitempty"`
+	IDFile              string `json:"idfile,omitempty"`
+	Registry            string `json:"registry,omitempty"`
+	Repository          string `json:"repository,omitempty"`
+	Tag                 string `json:"tag,omitempty"`
+	FileCopyDestination string `json:"filecopy_destination,omitempty"`
+	S3Bucket            string `json:"s3_bucket,omitempty"`
+	S3Key               string `json:"s3_key,omitempty"`
+}
+
+type showRunJSON struct {
+	ID                 int                  `json:"id"`
+	Application        string               `json:"application"`
+	Task               string               `json:"task"`
+	Result             storage.Result       `json:"result"`
+	ExitCode           int                  `json:"exit_code"`
+	StartTime          time.Time            `json:"start_time"`
+	Duration           float64              `json:"duration"`
+	GitCommit          string               `json:"git_commit"`
+	TotalInputDigest   string               `json:"total_input_digest"`
+	InputDigestVersion int                  `json:"input_digest_version"`
+	LogAvailable       bool                 `json:"log_available"`
+	Host               string               `json:"host"`
+	User               string               `json:"user"`
+	BaurVersion        string               `json:"baur_version"`
+	Command            []string             `json:"command"`
+	EnvVars            map[string]string    `json:"env_vars,omitempty"`
+	Outputs            []*showRunOutputJSON `json:"outputs"`
+}
+
+type showRunOutputJSON struct {
+	Name      string               `json:"name"`
+	Type      storage.ArtifactType `json:"type"`
+	Digest    string               `json:"digest"`
+	SizeBytes uint64               `json:"size_bytes"`
+	Uploads   []*showRunUploadJSON `json:"uploads"`
+}
+
+type showRunUploadJSON struct {
+	URI            string               `json:"uri"`
+	Method         storage.UploadMethod `json:"method"`
+	UploadDuration float64              `json:"upload_duration"`
+}
+
+func newShowAppJSON(app *baur.App, tasks []*baur.Task) *showAppJSON {
+	result := showAppJSON{
+		Name:  app.Name,
+		Path:  app.RelPath,
+		Tasks: make([]*showTaskJSON, 0, len(tasks)),
+	}
+
+	for _, task := range tasks {
+		result.Tasks = append(result.Tasks, newShowTaskJSON(task))
+	}
+
+	return &result
+}
+
+func newShowTaskJSON(task *baur.Task) *showTaskJSON {
+	result := showTaskJSON{
+		ID:          task.ID(),
+		Name:        task.Name,
+		Command:     task.Command,
+		MutexGroups: task.MutexGroups,
+		DependsOn:   task.DependsOn,
+	}
+
+	if task.Timeout > 0 {
+		result.Timeout = task.Timeout.String()
+	}
+
+	if task.HasInputs() {
+		for _, f := range task.UnresolvedInputs.Files {
+			result.Inputs = append(result.Inputs, &showInputJSON{
+				Type:     "File",
+				Optional: f.Optional,
+				Paths:    f.Paths,
+				Exclude:  f.Exclude,
+			})
+		}
+
+		for _, g := range task.UnresolvedInputs.GitFiles {
+			result.Inputs = append(result.Inputs, &showInputJSON{
+				Type:     "GitFile",
+				Optional: g.Optional,
+				Paths:    g.Paths,
+				Exclude:  g.Exclude,
+			})
+		}
+
+		for _, gs := range task.UnresolvedInputs.GolangSources {
+			result.Inputs = append(result.Inputs, &showInputJSON{
+				Type:        "GolangSources",
+				Queries:     gs.Queries,
+				Environment: gs.Environment,
+				BuildFlags:  gs.BuildFlags,
+				Tests:       gs.Tests,
+			})
+		}
+
+		for _, e := range task.UnresolvedInputs.EnvironmentVariables {
+			result.Inputs = append(result.Inputs, &showInputJSON{
+				Type:     "EnvironmentVariables",
+				Optional: e.Optional,
+				Names:    e.Names,
+			})
+		}
+
+		for _, c := range task.UnresolvedInputs.CommandOutput {
+			result.Inputs = append(result.Inputs, &showInputJSON{
+				Type:    "CommandOutput",
+				Command: c.Command,
+			})
+		}
+	}
+
+	if task.HasOutputs() {
+		for _, di := range task.Outputs.DockerImage {
+			result.Outputs = append(result.Outputs, &showOutputJSON{
+				Type:       "Docker Image",
+				IDFile:     di.IDFile,
+				Registry:   di.RegistryUpload.Registry,
+				Repository: di.RegistryUpload.Repository,
+				Tag:        di.RegistryUpload.Tag,
+			})
+		}
+
+		for _, file := range task.Outputs.File {
+			out := showOutputJSON{
+				Type: "File",
+				Path: file.Path,
+			}
+
+			if !file.FileCopy.IsEmpty() {
+				out.FileCopyDestination = file.FileCopy.Path
+			}
+
+			if !file.S3Upload.IsEmpty() {
+				out.S3Bucket = file.S3Upload.Bucket
+				out.S3Key = file.S3Upload.Key
+			}
+
+			result.Outputs = append(result.Outputs, &out)
+		}
+	}
+
+	return &result
+}
+
+func newShowRunJSON(taskRun *storage.TaskRunWithID, outputs []*storage.Output) *showRunJSON {
+	result := showRunJSON{
+		ID:                 taskRun.ID,
+		Application:        taskRun.ApplicationName,
+		Task:               taskRun.TaskName,
+		Result:             taskRun.Result,
+		ExitCode:           taskRun.ExitCode,
+		StartTime:          taskRun.StartTimestamp,
+		Duration:           taskRun.StopTimestamp.Sub(taskRun.StartTimestamp).Seconds(),
+		GitCommit:          vcsStr(&taskRun.TaskRun),
+		TotalInputDigest:   taskRun.TotalInputDigest,
+		InputDigestVersion: taskRun.InputDigestVersion,
+		LogAvailable:       taskRun.HasLog,
+		Host:               taskRun.Hostname,
+		User:               taskRun.Username,
+		BaurVersion:        taskRun.BaurVersion,
+		Command:            taskRun.Command,
+		EnvVars:            taskRun.EnvVars,
+		Outputs:            make([]*showRunOutputJSON, 0, len(outputs)),
+	}
+
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].Name < outputs[j].Name
+	})
+
+	for _, o := range outputs {
+		out := showRunOutputJSON{
+			Name:      o.Name,
+			Type:      o.Type,
+			Digest:    o.Digest,
+			SizeBytes: o.SizeBytes,
+			Uploads:   make([]*showRunUploadJSON, 0, len(o.Uploads)),
+		}
+
+		for _, u := range o.Uploads {
+			out.Uploads = append(out.Uploads, &showRunUploadJSON{
+				URI:            u.URI,
+				Method:         u.Method,
+				UploadDuration: u.UploadStopTimestamp.Sub(u.UploadStartTimestamp).Seconds(),
+			})
+		}
+
+		result.Outputs = append(result.Outputs, &out)
+	}
+
+	return &result
+}
@@ -0,0 +1,340 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v1/internal/command/flag"
+	"github.com/simplesurance/baur/v1/internal/command/term"
+	"github.com/simplesurance/baur/v1/internal/format/table"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// statsDefaultWindow is the time window that statistics are calculated for
+// when --after is not passed.
+const statsDefaultWindow = 30 * 24 * time.Hour
+
+const statsLongHelp = `
+Show statistics about recorded task runs.
+
+For every task the number of runs, the ratio of successful runs and the
+50th percentile, 95th percentile and maximum of the run durations are shown.
+Additionally the tasks with the longest run durations, the tasks that were
+rebuilt most frequently and the trend of the accumulated output sizes are
+listed.
+
+Only task runs that were started in the time window described by --after and
+--before are considered. If --after is not passed, the window starts 30 days
+ago.
+
+Arguments:
+	If <APP-NAME> or <APP-NAME>.<TASK-NAME> is passed, only runs of the
+	application or task are considered. '*' matches all Apps or Tasks.
+`
+
+const statsExample = `
+baur stats					show statistics about the task runs
+						of the last 30 days
+baur stats --after=2020.01.01-00:00 calc	show statistics about the runs of the
+						calc tasks since 2020
+baur stats --top 10 --interval 168h '*.build'	list the 10 slowest and most frequently
+						rebuilt build tasks and the weekly
+						output size trend
+`
+
+type statsCmd struct {
+	cobra.Command
+
+	after    flag.DateTimeFlagValue
+	before   flag.DateTimeFlagValue
+	top      int
+	interval time.Duration
+
+	format string
+}
+
+func init() {
+	rootCmd.AddCommand(&newStatsCmd().Command)
+}
+
+func newStatsCmd() *statsCmd {
+	cmd := statsCmd{
+		Command: cobra.Command{
+			Use:     "stats [<APP-NAME>[.<TASK-NAME>]]",
+			Short:   "show statistics about recorded task runs",
+			Long:    strings.TrimSpace(statsLongHelp),
+			Example: strings.TrimSpace(statsExample),
+			Args:    cobra.MaximumNArgs(1),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().VarP(&cmd.after, "after", "a",
+		fmt.Sprintf("Only consider runs that were started after this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
+
+	cmd.Flags().VarP(&cmd.before, "before", "b",
+		fmt.Sprintf("Only consider runs that were started before this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
+
+	cmd.Flags().IntVar(&cmd.top, "top", 5,
+		"Number of tasks that are listed as slowest and most frequently rebuilt tasks")
+
+	cmd.Flags().DurationVar(&cmd.interval, "interval", 24*time.Hour,
+		"Length of the intervals that output sizes are accumulated for")
+
+	return &cmd
+}
+
+func (c *statsCmd) run(cmd *cobra.Command, args []string) {
+	var app, task string
+
+	if c.top < 0 {
+		stderr.Printf("--top must not be negative\n")
+		exitFunc(1)
+	}
+
+	if c.interval <= 0 {
+		stderr.Printf("--interval must be positive\n")
+		exitFunc(1)
+	}
+
+	c.format = mustOutputFormat(false)
+	if c.format == flag.FormatCSV {
+		stderr.Printf("the %s format is not supported by this command\n", flag.FormatCSV)
+		exitFunc(1)
+	}
+
+	if len(args) == 1 {
+		app, task = parseSpec(args[0])
+	}
+
+	if c.after == (flag.DateTimeFlagValue{}) {
+		c.after.Time = time.Now().Add(-statsDefaultWindow)
+	}
+
+	repo := mustFindRepository()
+	storageClt := mustNewCompatibleStorage(repo)
+	defer storageClt.Close()
+
+	filters := c.filters(app, task)
+
+	taskStats, err := storageClt.TaskStats(ctx, filters)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			stderr.Printf("no matching task runs exist\n")
+			exitFunc(1)
+		}
+
+		exitOnErr(err, "querying task statistics failed")
+	}
+
+	outputSizes, err := storageClt.OutputSizeTrend(ctx, filters, c.interval)
+	exitOnErr(err, "querying output size trend failed")
+
+	slowest := topTaskStats(taskStats, c.top, func(a, b *storage.TaskStats) bool {
+		return a.DurationP95 > b.DurationP95
+	})
+
+	mostRebuilt := topTaskStats(taskStats, c.top, func(a, b *storage.TaskStats) bool {
+		return a.Runs > b.Runs
+	})
+
+	if isJSONFormat(c.format) {
+		var before *time.Time
+		if c.before != (flag.DateTimeFlagValue{}) {
+			before = &c.before.Time
+		}
+
+		mustWriteJSONDocument(c.format, &statsJSON{
+			After:            c.after.Time,
+			Before:           before,
+			Tasks:            newStatsTasksJSON(taskStats),
+			SlowestTasks:     newStatsTasksJSON(slowest),
+			MostRebuiltTasks: newStatsTasksJSON(mostRebuilt),
+			OutputSizeTrend:  newStatsOutputSizesJSON(outputSizes),
+		})
+		return
+	}
+
+	c.printWindow()
+
+	stdout.Printf("\n%s\n", term.Underline("Tasks:"))
+	c.printTaskStats(taskStats)
+
+	stdout.Printf("\n%s\n", term.Underline("Slowest Tasks (by 95th percentile duration):"))
+	c.printTaskStats(slowest)
+
+	stdout.Printf("\n%s\n", term.Underline("Most Frequently Rebuilt Tasks:"))
+	c.printTaskStats(mostRebuilt)
+
+	stdout.Printf("\n%s\n", term.Underline(fmt.Sprintf("Output Size Trend (per %s):", c.interval)))
+	c.printOutputSizes(outputSizes)
+}
+
+func (c *statsCmd) filters(app, task string) []*storage.Filter {
+	var filters []*storage.Filter
+
+	if app != "" && app != "*" {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldApplicationName,
+			Operator: storage.OpEQ,
+			Value:    app,
+		})
+	}
+
+	if task != "" && task != "*" {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldTaskName,
+			Operator: storage.OpEQ,
+			Value:    task,
+		})
+	}
+
+	filters = append(filters, &storage.Filter{
+		Field:    storage.FieldStartTime,
+		Operator: storage.OpGT,
+		Value:    c.after.Time,
+	})
+
+	if c.before != (flag.DateTimeFlagValue{}) {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldStartTime,
+			Operator: storage.OpLT,
+			Value:    c.before.Time,
+		})
+	}
+
+	return filters
+}
+
+// topTaskStats returns the first n elements of a copy of stats, that is
+// sorted by less.
+func topTaskStats(stats []*storage.TaskStats, n int, less func(a, b *storage.TaskStats) bool) []*storage.TaskStats {
+	result := make([]*storage.TaskStats, len(stats))
+	copy(result, stats)
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return less(result[i], result[j])
+	})
+
+	if n < len(result) {
+		result = result[:n]
+	}
+
+	return result
+}
+
+func (c *statsCmd) printWindow() {
+	before := "now"
+	if c.before != (flag.DateTimeFlagValue{}) {
+		before = c.before.Format(flag.DateTimeFormatTz)
+	}
+
+	stdout.Printf("Task runs started between %s and %s\n",
+		term.Highlight(c.after.Format(flag.DateTimeFormatTz)),
+		term.Highlight(before),
+	)
+}
+
+func (c *statsCmd) printTaskStats(stats []*storage.TaskStats) {
+	formatter := table.New(
+		[]string{"App", "Task", "Runs", "Success Rate", "Duration P50", "Duration P95", "Duration Max"},
+		stdout,
+	)
+
+	for _, s := range stats {
+		mustWriteRow(formatter,
+			s.ApplicationName,
+			s.TaskName,
+			s.Runs,
+			fmt.Sprintf("%.1f%%", s.SuccessRate()*100),
+			term.FormatDuration(s.DurationP50),
+			term.FormatDuration(s.DurationP95),
+			term.FormatDuration(s.DurationMax),
+		)
+	}
+
+	exitOnErr(formatter.Flush())
+}
+
+func (c *statsCmd) printOutputSizes(buckets []*storage.OutputSizeBucket) {
+	formatter := table.New(
+		[]string{"Interval Start", "Runs", "Output Size", "Avg. Output Size per Run"},
+		stdout,
+	)
+
+	for _, b := range buckets {
+		mustWriteRow(formatter,
+			b.Start.Format(flag.DateTimeFormatTz),
+			b.Runs,
+			term.FormatSize(b.OutputSizeBytes),
+			term.FormatSize(b.OutputSizeBytes/uint64(b.Runs)),
+		)
+	}
+
+	exitOnErr(formatter.Flush())
+}
+
+type statsJSON struct {
+	After            time.Time              `json:"after"`
+	Before           *time.Time             `json:"before,omitempty"`
+	Tasks            []*statsTaskJSON       `json:"tasks"`
+	SlowestTasks     []*statsTaskJSON       `json:"slowest_tasks"`
+	MostRebuiltTasks []*statsTaskJSON       `json:"most_rebuilt_tasks"`
+	OutputSizeTrend  []*statsOutputSizeJSON `json:"output_size_trend"`
+}
+
+type statsTaskJSON struct {
+	App                string  `json:"app"`
+	Task               string  `json:"task"`
+	Runs               int     `json:"runs"`
+	SuccessfulRuns     int     `json:"successful_runs"`
+	SuccessRate        float64 `json:"success_rate"`
+	DurationP50Seconds float64 `json:"duration_p50"`
+	DurationP95Seconds float64 `json:"duration_p95"`
+	DurationMaxSeconds float64 `json:"duration_max"`
+}
+
+type statsOutputSizeJSON struct {
+	IntervalStart   time.Time `json:"interval_start"`
+	Runs            int       `json:"runs"`
+	OutputSizeBytes uint64    `json:"output_size_bytes"`
+}
+
+func newStatsTasksJSON(stats []*storage.TaskStats) []*statsTaskJSON {
+	result := make([]*statsTaskJSON, 0, len(stats))
+
+	for _, s := range stats {
+		result = append(result, &statsTaskJSON{
+			App:                s.ApplicationName,
+			Task:               s.TaskName,
+			Runs:               s.Runs,
+			SuccessfulRuns:     s.SuccessfulRuns,
+			SuccessRate:        s.SuccessRate(),
+			DurationP50Seconds: s.DurationP50.Seconds(),
+			DurationP95Seconds: s.DurationP95.Seconds(),
+			DurationMaxSeconds: s.DurationMax.Seconds(),
+		})
+	}
+
+	return result
+}
+
+func newStatsOutputSizesJSON(buckets []*storage.OutputSizeBucket) []*statsOutputSizeJSON {
+	result := make([]*statsOutputSizeJSON, 0, len(buckets))
+
+	for _, b := range buckets {
+		result = append(result, &statsOutputSizeJSON{
+			IntervalStart:   b.Start,
+			Runs:            b.Runs,
+			OutputSizeBytes: b.OutputSizeBytes,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,27 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+func TestTopTaskStats(t *testing.T) {
+	stats := []*storage.TaskStats{
+		{TaskName: "a", Runs: 1},
+		{TaskName: "b", Runs: 3},
+		{TaskName: "c", Runs: 2},
+	}
+
+	byRuns := func(a, b *storage.TaskStats) bool { return a.Runs > b.Runs }
+
+	top := topTaskStats(stats, 2, byRuns)
+	assert.Equal(t, []*storage.TaskStats{stats[1], stats[2]}, top)
+
+	assert.Len(t, topTaskStats(stats, 5, byRuns), 3)
+	assert.Empty(t, topTaskStats(stats, 0, byRuns))
+
+	assert.Equal(t, "a", stats[0].TaskName, "passed slice was modified")
+}
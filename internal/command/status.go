@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -9,8 +10,6 @@ import (
 	"github.com/simplesurance/baur/v1/internal/command/flag"
 	"github.com/simplesurance/baur/v1/internal/command/term"
 	"github.com/simplesurance/baur/v1/internal/format"
-	"github.com/simplesurance/baur/v1/internal/format/csv"
-	"github.com/simplesurance/baur/v1/internal/format/table"
 	"github.com/simplesurance/baur/v1/internal/log"
 	"github.com/simplesurance/baur/v1/storage"
 )
@@ -26,6 +25,7 @@ const (
 	statusRunIDParam      = "run-id"
 	statusGitCommitHeader = "Git Commit"
 	statusGitCommitParam  = "git-commit"
+	statusExplainHeader   = "Explanation"
 )
 
 func init() {
@@ -38,10 +38,13 @@ type statusCmd struct {
 	csv            bool
 	quiet          bool
 	absPaths       bool
+	explain        bool
 	inputStr       string
 	lookupInputStr string
 	buildStatus    flag.TaskStatus
 	fields         *flag.Fields
+
+	format string
 }
 
 func newStatusCmd() *statusCmd {
@@ -63,7 +66,8 @@ func newStatusCmd() *statusCmd {
 	cmd.Run = cmd.run
 
 	cmd.Flags().BoolVar(&cmd.csv, "csv", false,
-		"List applications in RFC4180 CSV format")
+		"List applications in RFC4180 CSV format,\n"+
+			"same as --format csv")
 
 	cmd.Flags().BoolVarP(&cmd.quiet, "quiet", "q", false,
 		"Suppress printing a header and progress dots")
@@ -78,6 +82,10 @@ func newStatusCmd() *statusCmd {
 	cmd.Flags().VarP(cmd.fields, "fields", "f",
 		cmd.fields.Usage(term.Highlight))
 
+	cmd.Flags().BoolVar(&cmd.explain, "explain", false,
+		"add a column that lists for pending tasks the inputs that\n"+
+			"changed compared to the most recent run of the task")
+
 	cmd.Flags().StringVar(&cmd.inputStr, "input-str", "",
 		"include a string as input")
 
@@ -108,6 +116,10 @@ func (c *statusCmd) statusCreateHeader() []string {
 		}
 	}
 
+	if c.explain {
+		headers = append(headers, statusExplainHeader)
+	}
+
 	return headers
 }
 
@@ -128,7 +140,8 @@ func (c *statusCmd) run(cmd *cobra.Command, args []string) {
 	tasks, err := loader.LoadTasks(args...)
 	exitOnErr(err)
 
-	writeHeaders := !c.quiet && !c.csv
+	c.format = mustOutputFormat(c.csv)
+	writeHeaders := !c.quiet && c.format == flag.FormatTable
 	storageQueryNeeded := c.storageQueryIsNeeded()
 
 	if storageQueryNeeded {
@@ -139,39 +152,40 @@ func (c *statusCmd) run(cmd *cobra.Command, args []string) {
 		headers = c.statusCreateHeader()
 	}
 
-	if c.csv {
-		formatter = csv.New(headers, stdout)
-	} else {
-		formatter = table.New(headers, stdout)
+	keys := fieldsToKeys(c.fields.Fields)
+	if c.explain {
+		keys = append(keys, "explanation")
 	}
 
-	showProgress := len(tasks) >= 5 && !c.quiet && !c.csv
+	formatter = newFormatter(c.format, headers, keys)
 
-	statusMgr := baur.NewTaskStatusEvaluator(repo.Path, storageClt, baur.NewInputResolver(), c.inputStr, c.lookupInputStr)
+	inputResolver, saveDigestCache := newInputResolver(repo)
+	statusMgr := baur.NewTaskStatusEvaluator(repo.Path, storageClt, inputResolver, c.inputStr, c.lookupInputStr)
 
 	baur.SortTasksByID(tasks)
 
+	var statuses []*baur.TaskStatusResult
+	if storageQueryNeeded {
+		statuses, err = statusMgr.StatusBatch(ctx, tasks)
+		exitOnErr(err, "evaluating task status failed")
+
+		saveDigestCache()
+	}
+
+	var explanations []*baur.TaskStatusExplanation
+	if c.explain {
+		explanations, err = statusMgr.ExplainBatch(ctx, statuses)
+		exitOnErr(err, "comparing inputs with previous runs failed")
+	}
+
 	for i, task := range tasks {
 		var row []interface{}
 		var taskRun *storage.TaskRunWithID
 		var taskStatus baur.TaskStatus
 
 		if storageQueryNeeded {
-			var err error
-
-			taskStatus, _, taskRun, err = statusMgr.Status(ctx, task)
-			exitOnErrf(err, "%s: evaluating task status failed", task)
-
-			// querying the build status for all applications can
-			// take some time, output progress dots to let the user
-			// know that something is happening
-			if showProgress {
-				stdout.Printf(".")
-
-				if i+1 == len(tasks) {
-					stdout.Printf("\n\n")
-				}
-			}
+			taskStatus = statuses[i].Status
+			taskRun = statuses[i].Run
 		}
 
 		if c.buildStatus.IsSet() && taskStatus != c.buildStatus.Status {
@@ -180,6 +194,10 @@ func (c *statusCmd) run(cmd *cobra.Command, args []string) {
 
 		row = c.statusAssembleRow(repo.Path, task, taskRun, taskStatus)
 
+		if c.explain {
+			row = append(row, explanationStr(explanations[i]))
+		}
+
 		mustWriteRow(formatter, row...)
 	}
 
@@ -188,6 +206,10 @@ func (c *statusCmd) run(cmd *cobra.Command, args []string) {
 }
 
 func (c *statusCmd) storageQueryIsNeeded() bool {
+	if c.explain {
+		return true
+	}
+
 	for _, f := range c.fields.Fields {
 		switch f {
 		case statusStatusParam:
@@ -221,9 +243,14 @@ func (c *statusCmd) statusAssembleRow(repositoryDir string, task *baur.Task, tas
 			row = append(row, buildStatus)
 
 		case statusRunIDParam:
-			if buildStatus == baur.TaskStatusRunExist {
+			switch {
+			case buildStatus == baur.TaskStatusRunExist && isJSONFormat(c.format):
+				row = append(row, taskRun.ID)
+			case buildStatus == baur.TaskStatusRunExist:
 				row = append(row, fmt.Sprint(taskRun.ID))
-			} else {
+			case isJSONFormat(c.format):
+				row = append(row, nil)
+			default:
 				// no build exist, we don't have a build id
 				row = append(row, "")
 			}
@@ -239,3 +266,39 @@ func (c *statusCmd) statusAssembleRow(repositoryDir string, task *baur.Task, tas
 
 	return row
 }
+
+// explanationStr returns a summary of the input differences in explanation.
+// If explanation is nil, an empty string is returned.
+func explanationStr(explanation *baur.TaskStatusExplanation) string {
+	if explanation == nil {
+		return ""
+	}
+
+	if explanation.PreviousRun == nil {
+		return "no run of the task was recorded"
+	}
+
+	if explanation.DigestVersionDiffers() {
+		return fmt.Sprintf("inputs can not be compared to run %d, its input digests were calculated with version %d, current version is %d",
+			explanation.PreviousRun.ID, explanation.PreviousRun.InputDigestVersion, baur.InputDigestVersion)
+	}
+
+	if len(explanation.InputDiffs) == 0 {
+		return fmt.Sprintf("inputs are equal to run %d with result %s",
+			explanation.PreviousRun.ID, explanation.PreviousRun.Result)
+	}
+
+	byState := map[baur.InputDiffState][]string{}
+	for _, d := range explanation.InputDiffs {
+		byState[d.State] = append(byState[d.State], d.URI)
+	}
+
+	parts := make([]string, 0, len(byState))
+	for _, state := range []baur.InputDiffState{baur.InputDiffChanged, baur.InputDiffAdded, baur.InputDiffRemoved} {
+		if uris := byState[state]; len(uris) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", state, strings.Join(uris, ", ")))
+		}
+	}
+
+	return fmt.Sprintf("compared to run %d: %s", explanation.PreviousRun.ID, strings.Join(parts, "; "))
+}
@@ -0,0 +1,135 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/simplesurance/baur/v1"
+	"github.com/simplesurance/baur/v1/internal/routines"
+)
+
+// taskScheduler runs tasks concurrently on a routine pool in the order of
+// their dependencies.
+type taskScheduler struct {
+	parallel  uint
+	keepGoing bool
+	// taskRunner is used to acquire the mutex groups of the tasks.
+	taskRunner *baur.TaskRunner
+	// runFn runs the task, it returns true if it was successful.
+	runFn func(*pendingTask) bool
+	// skipFn is called for tasks that are not run.
+	skipFn func(t *pendingTask, reason string)
+}
+
+// run runs the tasks, a task is queued when all tasks in tasks that it
+// depends on finished.
+// A task is skipped when a task in tasks that it depends on failed or was
+// skipped, or when ctx is cancelled.
+// If keepGoing is false, no further tasks are started after a task failed.
+// The mutex groups of a task are acquired before it is queued in the pool,
+// tasks that wait for a mutex group do not occupy a worker.
+// tasks must be sorted by baur.SortTasksByDependencies().
+func (s *taskScheduler) run(ctx context.Context, tasks []*pendingTask) {
+	var wg sync.WaitGroup
+	var schedLock sync.Mutex // protects the following maps and abort
+
+	runPool := routines.NewPool(s.parallel)
+
+	pendingDeps := make(map[string]int, len(tasks))
+	dependents := make(map[string][]*pendingTask, len(tasks))
+	successful := make(map[string]bool, len(tasks))
+	var abort bool
+
+	for _, t := range tasks {
+		pendingDeps[t.task.ID()] = 0
+	}
+
+	for _, t := range tasks {
+		for _, depID := range t.task.DependsOn {
+			if _, exist := pendingDeps[depID]; !exist {
+				continue
+			}
+
+			pendingDeps[t.task.ID()]++
+			dependents[depID] = append(dependents[depID], t)
+		}
+	}
+
+	// skipReason must be called with schedLock held
+	skipReason := func(t *pendingTask) string {
+		if ctx.Err() != nil {
+			return "baur was interrupted"
+		}
+
+		if abort {
+			return "a previous task failed"
+		}
+
+		for _, depID := range t.task.DependsOn {
+			if _, exist := pendingDeps[depID]; !exist {
+				continue
+			}
+
+			if !successful[depID] {
+				return fmt.Sprintf("dependency %s did not run successfully", depID)
+			}
+		}
+
+		return ""
+	}
+
+	var queue func(t *pendingTask)
+	queue = func(t *pendingTask) {
+		go func() {
+			unlock := s.taskRunner.LockMutexGroups(t.task)
+
+			runPool.Queue(func() {
+				var success bool
+
+				defer wg.Done()
+
+				schedLock.Lock()
+				reason := skipReason(t)
+				schedLock.Unlock()
+
+				if reason == "" {
+					success = s.runFn(t)
+				} else {
+					s.skipFn(t, reason)
+				}
+
+				unlock()
+
+				schedLock.Lock()
+				defer schedLock.Unlock()
+
+				successful[t.task.ID()] = success
+				if !success && reason == "" && !s.keepGoing {
+					abort = true
+				}
+
+				for _, dependent := range dependents[t.task.ID()] {
+					pendingDeps[dependent.task.ID()]--
+
+					if pendingDeps[dependent.task.ID()] == 0 {
+						queue(dependent)
+					}
+				}
+			})
+		}()
+	}
+
+	wg.Add(len(tasks))
+
+	schedLock.Lock()
+	for _, t := range tasks {
+		if pendingDeps[t.task.ID()] == 0 {
+			queue(t)
+		}
+	}
+	schedLock.Unlock()
+
+	wg.Wait()
+	runPool.Wait()
+}
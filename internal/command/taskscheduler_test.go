@@ -0,0 +1,200 @@
+package command
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1"
+)
+
+// schedulerRecorder records which tasks the taskScheduler ran and skipped.
+type schedulerRecorder struct {
+	lock       sync.Mutex
+	running    int
+	maxRunning int
+	finished   []string
+	skipped    []string
+}
+
+func (r *schedulerRecorder) start() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.running++
+	if r.running > r.maxRunning {
+		r.maxRunning = r.running
+	}
+}
+
+func (r *schedulerRecorder) finish(t *pendingTask) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.running--
+	r.finished = append(r.finished, t.task.ID())
+}
+
+func (r *schedulerRecorder) skip(t *pendingTask, _ string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.skipped = append(r.skipped, t.task.ID())
+}
+
+func newPendingTask(appName string, mutexGroups []string, dependsOn ...string) *pendingTask {
+	return &pendingTask{
+		task: &baur.Task{
+			AppName:     appName,
+			Name:        "build",
+			MutexGroups: mutexGroups,
+			DependsOn:   dependsOn,
+		},
+	}
+}
+
+func TestTaskSchedulerRunsTasksInParallel(t *testing.T) {
+	var rec schedulerRecorder
+
+	scheduler := taskScheduler{
+		parallel:   2,
+		taskRunner: baur.NewTaskRunner(),
+		runFn: func(t *pendingTask) bool {
+			rec.start()
+			time.Sleep(50 * time.Millisecond)
+			rec.finish(t)
+
+			return true
+		},
+		skipFn: rec.skip,
+	}
+
+	scheduler.run(context.Background(), []*pendingTask{
+		newPendingTask("app1", nil),
+		newPendingTask("app2", nil),
+		newPendingTask("app3", nil),
+		newPendingTask("app4", nil),
+	})
+
+	assert.Len(t, rec.finished, 4)
+	assert.Empty(t, rec.skipped)
+	assert.Equal(t, 2, rec.maxRunning)
+}
+
+func TestTaskSchedulerRunsDependenciesFirst(t *testing.T) {
+	var rec schedulerRecorder
+
+	scheduler := taskScheduler{
+		parallel:   4,
+		taskRunner: baur.NewTaskRunner(),
+		runFn: func(t *pendingTask) bool {
+			rec.start()
+			time.Sleep(10 * time.Millisecond)
+			rec.finish(t)
+
+			return true
+		},
+		skipFn: rec.skip,
+	}
+
+	scheduler.run(context.Background(), []*pendingTask{
+		newPendingTask("app1", nil),
+		newPendingTask("app2", nil, "app1.build"),
+		newPendingTask("app3", nil, "app2.build"),
+	})
+
+	assert.Equal(t, []string{"app1.build", "app2.build", "app3.build"}, rec.finished)
+	assert.Equal(t, 1, rec.maxRunning)
+}
+
+func TestTaskSchedulerSkipsDependentsOfFailedTasks(t *testing.T) {
+	var rec schedulerRecorder
+
+	scheduler := taskScheduler{
+		parallel:   1,
+		keepGoing:  true,
+		taskRunner: baur.NewTaskRunner(),
+		runFn: func(t *pendingTask) bool {
+			rec.start()
+			rec.finish(t)
+
+			return t.task.AppName != "app1"
+		},
+		skipFn: rec.skip,
+	}
+
+	scheduler.run(context.Background(), []*pendingTask{
+		newPendingTask("app1", nil),
+		newPendingTask("app2", nil),
+		newPendingTask("app3", nil, "app1.build"),
+	})
+
+	assert.ElementsMatch(t, []string{"app1.build", "app2.build"}, rec.finished)
+	assert.Equal(t, []string{"app3.build"}, rec.skipped)
+}
+
+func TestTaskSchedulerMutexGroups(t *testing.T) {
+	var rec schedulerRecorder
+	var groupLock sync.Mutex
+	var groupRunning int
+	var groupOverlapped bool
+	var waitTimedOut bool
+
+	unGroupedFinished := make(chan struct{})
+
+	scheduler := taskScheduler{
+		parallel:   2,
+		taskRunner: baur.NewTaskRunner(),
+		runFn: func(t *pendingTask) bool {
+			rec.start()
+			defer rec.finish(t)
+
+			if len(t.task.MutexGroups) == 0 {
+				close(unGroupedFinished)
+				return true
+			}
+
+			groupLock.Lock()
+			groupRunning++
+			groupOverlapped = groupOverlapped || groupRunning > 1
+			groupLock.Unlock()
+
+			defer func() {
+				groupLock.Lock()
+				groupRunning--
+				groupLock.Unlock()
+			}()
+
+			// the task without a mutex group can only run while
+			// a task of the group runs, if the task that waits
+			// for the group does not occupy the 2. worker
+			select {
+			case <-unGroupedFinished:
+			case <-time.After(5 * time.Second):
+				groupLock.Lock()
+				waitTimedOut = true
+				groupLock.Unlock()
+
+				return false
+			}
+
+			return true
+		},
+		skipFn: rec.skip,
+	}
+
+	scheduler.run(context.Background(), []*pendingTask{
+		newPendingTask("app1", []string{"port"}),
+		newPendingTask("app2", []string{"port"}),
+		newPendingTask("app3", nil),
+	})
+
+	require.Empty(t, rec.skipped)
+	require.Len(t, rec.finished, 3)
+	assert.False(t, waitTimedOut, "task without mutex group did not run while a task of the group was running")
+	assert.False(t, groupOverlapped, "tasks of the same mutex group ran at the same time")
+}
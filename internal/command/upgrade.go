@@ -4,6 +4,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const cmdUpgradeDb = "baur upgrade db"
+
 var upgradeCmd = &cobra.Command{
 	Use:   "upgrade",
 	Short: "upgrade configuration files and the database schema",
@@ -0,0 +1,123 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v1"
+	"github.com/simplesurance/baur/v1/internal/command/term"
+	"github.com/simplesurance/baur/v1/internal/log"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+const upgradeDbExample = `
+baur upgrade db							upgrade the database of the repository
+baur upgrade db --dry-run					show the migrations that an upgrade applies
+baur upgrade db postgres://postgres@localhost:5432/baur	upgrade the database at the URL
+`
+
+var upgradeDbLongHelp = fmt.Sprintf(`
+Upgrade the schema of the baur database to the version that this baur version
+requires.
+
+All pending migrations are applied in a single transaction, if one fails the
+schema is not changed.
+Database files (file:// URLs) are upgraded by replacing the file atomically.
+
+The database URL is read from the repository configuration file.
+Alternatively the URL can be passed as argument or
+by setting the '%s' environment variable.`,
+	term.Highlight(envVarPSQLURL))
+
+func init() {
+	upgradeCmd.AddCommand(&newUpgradeDbCmd().Command)
+}
+
+type upgradeDbCmd struct {
+	cobra.Command
+
+	dryRun bool
+}
+
+func newUpgradeDbCmd() *upgradeDbCmd {
+	cmd := upgradeDbCmd{
+		Command: cobra.Command{
+			Use:     "db [DATABASE-URL]",
+			Short:   "upgrade the database schema",
+			Long:    strings.TrimSpace(upgradeDbLongHelp),
+			Example: strings.TrimSpace(upgradeDbExample),
+			Args:    cobra.MaximumNArgs(1),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().BoolVarP(&cmd.dryRun, "dry-run", "n", false,
+		"only print the statements of the pending migrations, do not apply them")
+
+	return &cmd
+}
+
+func (c *upgradeDbCmd) run(cmd *cobra.Command, args []string) {
+	var dbURL string
+
+	if len(args) == 0 {
+		repo, err := findRepository()
+		if err != nil {
+			if os.IsNotExist(err) {
+				log.Fatalf("could not find '%s' repository config file.\n"+
+					"Run '%s' first or pass the database URL as argument.",
+					term.Highlight(baur.RepositoryCfgFile), term.Highlight(cmdInitRepo))
+			}
+
+			log.Fatalln(err)
+		}
+
+		mustHavePSQLURI(repo)
+		dbURL = repo.PSQLURL
+	} else {
+		dbURL = args[0]
+	}
+
+	storageClt, err := newStorageClient(dbURL)
+	exitOnErr(err, "establishing connection failed")
+	defer storageClt.Close()
+
+	upgrader, ok := storageClt.(storage.Upgrader)
+	if !ok {
+		stderr.Printf("the storage does not support schema upgrades\n")
+		exitFunc(1)
+	}
+
+	pending, err := upgrader.PendingMigrations(ctx)
+	exitOnErr(err)
+
+	if len(pending) == 0 {
+		stdout.Println("database schema is up to date")
+		return
+	}
+
+	if c.dryRun {
+		for _, m := range pending {
+			stdout.Printf("-- migration to version %d: %s\n", m.Version, m.Description)
+
+			if stmt := strings.TrimSpace(m.Statement); stmt != "" {
+				stdout.Println(stmt)
+			}
+		}
+
+		return
+	}
+
+	applied, err := upgrader.Upgrade(ctx)
+	exitOnErr(err)
+
+	for _, m := range applied {
+		stdout.Printf("applied migration to version %s: %s\n", term.Highlight(m.Version), m.Description)
+	}
+
+	stdout.Println("database schema upgraded successfully")
+}
@@ -0,0 +1,258 @@
+// Package digestcache provides a persistent cache for the digests of files.
+package digestcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/simplesurance/baur/v1/internal/digest"
+	"github.com/simplesurance/baur/v1/internal/fs"
+)
+
+// formatVersion is the version of the cache file format. Cache files with a
+// different version are ignored.
+// Version 2 is used since digests of input files are calculated from their
+// repository relative paths.
+const formatVersion = 2
+
+// racyThreshold is the min. age of the modification and change time of a file
+// that is required to store it's digest in the cache.
+// Timestamps of filesystems can have a coarse granularity, a file that is
+// modified directly after it was hashed might keep the same timestamps.
+const racyThreshold = 2 * time.Second
+
+// fileMeta is the metadata of a file that a cache entry is valid for.
+// Timestamps are stored as nanoseconds since the Unix epoch.
+type fileMeta struct {
+	Inode uint64 `json:"inode"`
+	Size  int64  `json:"size"`
+	Mtime int64  `json:"mtime"`
+	Ctime int64  `json:"ctime"`
+}
+
+type entry struct {
+	fileMeta
+	Digest string `json:"digest"`
+}
+
+type cacheFile struct {
+	Version int               `json:"version"`
+	Entries map[string]*entry `json:"entries"`
+}
+
+// Cache maps the absolute paths of files to their digests.
+// An entry is only valid while the inode, size, modification and change time
+// of the file are unchanged.
+// The cache is stored in a single file that is replaced atomically when it is
+// saved. Saving is synchronized via a lock file, the cache can be used by
+// multiple baur processes concurrently.
+// Cache is safe for concurrent use.
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// added contains the entries that were added since the cache was
+	// loaded.
+	added map[string]*entry
+
+	now func() time.Time
+}
+
+// DefaultPath returns the path of the cache file for the baur repository in
+// repositoryDir. It is located in the user's cache directory, e.g.
+// $XDG_CACHE_HOME/baur/digests/ on Linux.
+func DefaultPath(repositoryDir string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(repositoryDir))
+
+	return filepath.Join(cacheDir, "baur", "digests", hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// Load reads the cache from path.
+// If the file does not exist, has an unsupported version or is corrupted, an
+// empty cache is returned.
+func Load(path string) (*Cache, error) {
+	entries, err := readEntries(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		path:    path,
+		entries: entries,
+		added:   map[string]*entry{},
+		now:     time.Now,
+	}, nil
+}
+
+func readEntries(path string) (map[string]*entry, error) {
+	var f cacheFile
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]*entry{}, nil
+		}
+
+		return nil, fmt.Errorf("reading digest cache failed: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &f); err != nil || f.Version != formatVersion || f.Entries == nil {
+		return map[string]*entry{}, nil
+	}
+
+	return f.Entries, nil
+}
+
+// Digest returns the cached digest of the file at path, if the metadata of
+// the file did not change since it was cached.
+// Otherwise calc is called to calculate the digest and the result is added to
+// the cache.
+func (c *Cache) Digest(path string, calc func() (*digest.Digest, error)) (*digest.Digest, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return calc()
+	}
+
+	meta := statMeta(fi)
+
+	c.mu.Lock()
+	e := c.entries[path]
+	c.mu.Unlock()
+
+	if e != nil && e.fileMeta == meta {
+		if d, err := digest.FromString(e.Digest); err == nil {
+			return d, nil
+		}
+	}
+
+	d, err := calc()
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err = os.Stat(path)
+	if err != nil || statMeta(fi) != meta || isRacy(meta, c.now()) {
+		return d, nil
+	}
+
+	e = &entry{fileMeta: meta, Digest: d.String()}
+
+	c.mu.Lock()
+	c.entries[path] = e
+	c.added[path] = e
+	c.mu.Unlock()
+
+	return d, nil
+}
+
+func isRacy(meta fileMeta, now time.Time) bool {
+	threshold := now.Add(-racyThreshold).UnixNano()
+
+	return meta.Mtime > threshold || meta.Ctime > threshold
+}
+
+// Save writes the entries that were added to the cache file.
+// The cache file is read again before, to keep entries that were saved by
+// other processes in the meantime. An exclusive lock on the lock file is held
+// while the file is read and written, to prevent that entries of concurrent
+// Save() calls of other processes get lost.
+// Entries of files that do not exist anymore or whose metadata changed are
+// removed from the file, they can not become valid again.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.added) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("creating digest cache directory failed: %w", err)
+	}
+
+	lockPath := c.path + ".lock"
+
+	lockFile, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("opening digest cache lock file failed: %w", err)
+	}
+	defer lockFile.Close()
+
+	if err := fs.LockExclusive(lockFile); err != nil {
+		return fmt.Errorf("locking %s failed: %w", lockPath, err)
+	}
+	defer fs.Unlock(lockFile) //nolint: errcheck
+
+	entries, err := readEntries(c.path)
+	if err != nil {
+		return err
+	}
+
+	for path, e := range c.added {
+		entries[path] = e
+	}
+
+	pruneStaleEntries(entries)
+
+	if err := write(c.path, &cacheFile{Version: formatVersion, Entries: entries}); err != nil {
+		return fmt.Errorf("writing digest cache failed: %w", err)
+	}
+
+	c.added = map[string]*entry{}
+
+	return nil
+}
+
+// pruneStaleEntries removes the entries whose files can not be stat'ed or
+// whose metadata differs from the cached one.
+func pruneStaleEntries(entries map[string]*entry) {
+	for path, e := range entries {
+		fi, err := os.Stat(path)
+		if err != nil || statMeta(fi) != e.fileMeta {
+			delete(entries, path)
+		}
+	}
+}
+
+// write replaces the file at path atomically with the JSON encoding of f.
+func write(path string, f *cacheFile) error {
+	content, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmpFile.Write(content)
+	if err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+
+		return err
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -0,0 +1,244 @@
+package digestcache
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/internal/digest"
+	"github.com/simplesurance/baur/v1/internal/digest/sha384"
+)
+
+// digestCalculator returns a function that calculates the digest of the
+// file and counts how often it was called.
+func digestCalculator(t *testing.T, path string, calls *int) func() (*digest.Digest, error) {
+	return func() (*digest.Digest, error) {
+		*calls++
+
+		h := sha384.New()
+		require.NoError(t, h.AddFile(path))
+
+		return h.Digest(), nil
+	}
+}
+
+func loadCache(t *testing.T, path string) *Cache {
+	c, err := Load(path)
+	require.NoError(t, err)
+
+	// files that are created by the tests are never older than
+	// racyThreshold
+	c.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	return c
+}
+
+func TestDigestIsCachedAcrossLoads(t *testing.T) {
+	var calls int
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "cache", "digests.json")
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, []byte("hello"), 0644))
+
+	c := loadCache(t, cachePath)
+	d1, err := c.Digest(file, digestCalculator(t, file, &calls))
+	require.NoError(t, err)
+	require.NoError(t, c.Save())
+
+	c = loadCache(t, cachePath)
+	d2, err := c.Digest(file, digestCalculator(t, file, &calls))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, d1.String(), d2.String())
+}
+
+func TestDigestIsRecalculatedWhenFileChanged(t *testing.T) {
+	var calls int
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, []byte("hello"), 0644))
+
+	c := loadCache(t, filepath.Join(dir, "digests.json"))
+	d1, err := c.Digest(file, digestCalculator(t, file, &calls))
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("hello world"), 0644))
+
+	d2, err := c.Digest(file, digestCalculator(t, file, &calls))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, calls)
+	assert.NotEqual(t, d1.String(), d2.String())
+}
+
+func TestRacyEntriesAreNotCached(t *testing.T) {
+	var calls int
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, []byte("hello"), 0644))
+
+	c, err := Load(filepath.Join(dir, "digests.json"))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Digest(file, digestCalculator(t, file, &calls))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestErrorsAreNotCached(t *testing.T) {
+	calcErr := errors.New("failed")
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, []byte("hello"), 0644))
+
+	c := loadCache(t, filepath.Join(dir, "digests.json"))
+
+	_, err := c.Digest(file, func() (*digest.Digest, error) { return nil, calcErr })
+	require.Equal(t, calcErr, err)
+	assert.Empty(t, c.entries)
+}
+
+func TestSaveMergesConcurrentlySavedEntries(t *testing.T) {
+	var calls int
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "digests.json")
+	file1 := filepath.Join(dir, "file1")
+	file2 := filepath.Join(dir, "file2")
+	require.NoError(t, ioutil.WriteFile(file1, []byte("1"), 0644))
+	require.NoError(t, ioutil.WriteFile(file2, []byte("2"), 0644))
+
+	c1 := loadCache(t, cachePath)
+	c2 := loadCache(t, cachePath)
+
+	_, err := c1.Digest(file1, digestCalculator(t, file1, &calls))
+	require.NoError(t, err)
+	_, err = c2.Digest(file2, digestCalculator(t, file2, &calls))
+	require.NoError(t, err)
+
+	require.NoError(t, c1.Save())
+	require.NoError(t, c2.Save())
+
+	c := loadCache(t, cachePath)
+	assert.Contains(t, c.entries, file1)
+	assert.Contains(t, c.entries, file2)
+}
+
+func TestSaveRemovesEntriesOfDeletedAndChangedFiles(t *testing.T) {
+	var calls int
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "digests.json")
+	deletedFile := filepath.Join(dir, "deleted")
+	changedFile := filepath.Join(dir, "changed")
+	unchangedFile := filepath.Join(dir, "unchanged")
+	newFile := filepath.Join(dir, "new")
+
+	for _, f := range []string{deletedFile, changedFile, unchangedFile, newFile} {
+		require.NoError(t, ioutil.WriteFile(f, []byte("1"), 0644))
+	}
+
+	c := loadCache(t, cachePath)
+	for _, f := range []string{deletedFile, changedFile, unchangedFile} {
+		_, err := c.Digest(f, digestCalculator(t, f, &calls))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.Save())
+
+	require.NoError(t, os.Remove(deletedFile))
+	require.NoError(t, ioutil.WriteFile(changedFile, []byte("12"), 0644))
+
+	c = loadCache(t, cachePath)
+	_, err := c.Digest(newFile, digestCalculator(t, newFile, &calls))
+	require.NoError(t, err)
+	require.NoError(t, c.Save())
+
+	c = loadCache(t, cachePath)
+	assert.Len(t, c.entries, 2)
+	assert.Contains(t, c.entries, unchangedFile)
+	assert.Contains(t, c.entries, newFile)
+}
+
+func TestParallelSavesDoNotLoseEntries(t *testing.T) {
+	const cacheCount = 20
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "digests.json")
+
+	caches := make([]*Cache, 0, cacheCount)
+	files := make([]string, 0, cacheCount)
+
+	for i := 0; i < cacheCount; i++ {
+		var calls int
+
+		file := filepath.Join(dir, fmt.Sprintf("file%d", i))
+		require.NoError(t, ioutil.WriteFile(file, []byte(file), 0644))
+
+		c := loadCache(t, cachePath)
+		_, err := c.Digest(file, digestCalculator(t, file, &calls))
+		require.NoError(t, err)
+
+		caches = append(caches, c)
+		files = append(files, file)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, cacheCount)
+
+	for i, c := range caches {
+		wg.Add(1)
+
+		go func(i int, c *Cache) {
+			defer wg.Done()
+			errs[i] = c.Save()
+		}(i, c)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	c := loadCache(t, cachePath)
+	for _, file := range files {
+		assert.Contains(t, c.entries, file)
+	}
+}
+
+func TestLoadIgnoresCorruptedFile(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "digests.json")
+	require.NoError(t, ioutil.WriteFile(cachePath, []byte("{"), 0644))
+
+	c, err := Load(cachePath)
+	require.NoError(t, err)
+	assert.Empty(t, c.entries)
+}
+
+func TestLoadFailsOnUnreadableFile(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+
+	cachePath := filepath.Join(t.TempDir(), "digests.json")
+	require.NoError(t, ioutil.WriteFile(cachePath, []byte("{}"), 0))
+
+	_, err := Load(cachePath)
+	assert.Error(t, err)
+}
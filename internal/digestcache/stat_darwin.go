@@ -0,0 +1,22 @@
+// +build darwin
+
+package digestcache
+
+import (
+	"os"
+	"syscall"
+)
+
+func statMeta(fi os.FileInfo) fileMeta {
+	meta := fileMeta{
+		Size:  fi.Size(),
+		Mtime: fi.ModTime().UnixNano(),
+	}
+
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		meta.Inode = st.Ino
+		meta.Ctime = st.Ctimespec.Nano()
+	}
+
+	return meta
+}
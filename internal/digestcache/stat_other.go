@@ -0,0 +1,14 @@
+// +build !linux,!darwin
+
+package digestcache
+
+import "os"
+
+// statMeta returns the size and modification time of the file, the inode
+// and change time are not available on all platforms and are always 0.
+func statMeta(fi os.FileInfo) fileMeta {
+	return fileMeta{
+		Size:  fi.Size(),
+		Mtime: fi.ModTime().UnixNano(),
+	}
+}
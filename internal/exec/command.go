@@ -4,10 +4,12 @@ package exec
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
 	"syscall"
+	"time"
 )
 
 var (
@@ -15,6 +17,10 @@ var (
 	DefaultDebugfFn = func(string, ...interface{}) {}
 	// DefaultDebugPrefix is the default prefix that is prepended to messages passed to the debugf function.
 	DefaultDebugPrefix = "exec: "
+	// TerminationGracePeriod is the duration that a command has to
+	// terminate after it received a SIGTERM because it's context was
+	// cancelled. When the period expired SIGKILL is sent.
+	TerminationGracePeriod = 10 * time.Second
 )
 
 // ExitCodeError is returned from Run() when a command exited with a code != 0.
@@ -24,19 +30,26 @@ type ExitCodeError struct {
 
 // Error returns the error description.
 func (e ExitCodeError) Error() string {
+	if len(e.Stderr) > 0 {
+		return fmt.Sprintf("exec: running '%s' in directory '%s' exited with code %d, expected 0, output: '%s', stderr: '%s'",
+			e.Command, e.Dir, e.ExitCode, e.Output, e.Stderr)
+	}
+
 	return fmt.Sprintf("exec: running '%s' in directory '%s' exited with code %d, expected 0, output: '%s'",
 		e.Command, e.Dir, e.ExitCode, e.Output)
 }
 
 // Cmd represents a command that can be run.
 type Cmd struct {
+	ctx  context.Context
 	path string
 	args []string
 
-	dir           string
-	debugfFn      func(format string, v ...interface{})
-	debugfPrefix  string
-	expectSuccess bool
+	dir            string
+	debugfFn       func(format string, v ...interface{})
+	debugfPrefix   string
+	expectSuccess  bool
+	separateStderr bool
 }
 
 // Command returns a new Cmd struct.
@@ -45,7 +58,17 @@ type Cmd struct {
 // as Path.
 // By default a command is run in the current working directory.
 func Command(name string, arg ...string) *Cmd {
+	return CommandContext(context.Background(), name, arg...)
+}
+
+// CommandContext is like Command but includes a context.
+// The command and all processes that it started are run in their own process
+// group. When the context is done before the command terminated, SIGTERM is
+// sent to the process group, if the processes did not terminate after
+// TerminationGracePeriod, they are killed.
+func CommandContext(ctx context.Context, name string, arg ...string) *Cmd {
 	return &Cmd{
+		ctx:          ctx,
 		path:         name,
 		args:         arg,
 		dir:          ".",
@@ -81,6 +104,13 @@ func (c *Cmd) ExpectSuccess() *Cmd {
 	return c
 }
 
+// SeparateStderr if called, the STDERR output of the command is stored in
+// Result.Stderr instead of being merged with STDOUT into Result.Output.
+func (c *Cmd) SeparateStderr() *Cmd {
+	c.separateStderr = true
+	return c
+}
+
 func cmdString(cmd *exec.Cmd) string {
 	// cmd.Args[0] contains the command name, cmd.Path the absolute command path,
 	// omit cmd.Args[0] from the string
@@ -97,6 +127,10 @@ type Result struct {
 	Dir      string
 	Output   []byte
 	ExitCode int
+
+	// Stderr is only set when Cmd.SeparateStderr() was called, otherwise
+	// the STDERR output is part of Output.
+	Stderr []byte
 }
 
 // StrOutput returns Result.Output as string.
@@ -133,12 +167,19 @@ func exitCodeFromErr(err error) (int, error) {
 func (c *Cmd) Run() (*Result, error) {
 	cmd := exec.Command(c.path, c.args...)
 	cmd.Dir = c.dir
+	setProcessGroup(cmd)
 
 	outReader, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
 	}
-	cmd.Stderr = cmd.Stdout
+
+	var errBuf bytes.Buffer
+	if c.separateStderr {
+		cmd.Stderr = &errBuf
+	} else {
+		cmd.Stderr = cmd.Stdout
+	}
 
 	c.debugfFn(c.debugfPrefix+"running '%s' in directory '%s'", cmdString(cmd), cmd.Dir)
 	err = cmd.Start()
@@ -146,6 +187,11 @@ func (c *Cmd) Run() (*Result, error) {
 		return nil, err
 	}
 
+	terminatedChan := make(chan struct{})
+	defer close(terminatedChan)
+
+	go c.terminateOnCtxDone(cmd, terminatedChan)
+
 	var outBuf bytes.Buffer
 	firstline := true
 	in := bufio.NewScanner(outReader)
@@ -156,7 +202,7 @@ func (c *Cmd) Run() (*Result, error) {
 			outBuf.WriteRune('\n')
 		}
 
-		c.debugfFn(c.debugfPrefix + in.Text())
+		c.debugfFn("%s%s", c.debugfPrefix, in.Text())
 
 		outBuf.Write(in.Bytes())
 	}
@@ -180,6 +226,7 @@ func (c *Cmd) Run() (*Result, error) {
 		Dir:      cmd.Dir,
 		ExitCode: exitCode,
 		Output:   outBuf.Bytes(),
+		Stderr:   errBuf.Bytes(),
 	}
 
 	if c.expectSuccess && exitCode != 0 {
@@ -188,3 +235,36 @@ func (c *Cmd) Run() (*Result, error) {
 
 	return &result, nil
 }
+
+// terminateOnCtxDone terminates the process group of cmd when c.ctx is done
+// before terminatedChan is closed.
+func (c *Cmd) terminateOnCtxDone(cmd *exec.Cmd, terminatedChan <-chan struct{}) {
+	select {
+	case <-terminatedChan:
+		return
+
+	case <-c.ctx.Done():
+	}
+
+	c.debugfFn(c.debugfPrefix+"%s, terminating process", c.ctx.Err())
+
+	if err := signalProcessGroup(cmd, syscall.SIGTERM); err != nil {
+		c.debugfFn(c.debugfPrefix+"sending SIGTERM failed: %s", err)
+	}
+
+	timer := time.NewTimer(TerminationGracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-terminatedChan:
+		return
+
+	case <-timer.C:
+	}
+
+	c.debugfFn(c.debugfPrefix+"process did not terminate after %s, killing it", TerminationGracePeriod)
+
+	if err := killProcessGroup(cmd); err != nil {
+		c.debugfFn(c.debugfPrefix+"killing process failed: %s", err)
+	}
+}
@@ -1,7 +1,9 @@
 package exec
 
 import (
+	"context"
 	"testing"
+	"time"
 )
 
 func TestEchoStdout(t *testing.T) {
@@ -21,6 +23,21 @@ func TestEchoStdout(t *testing.T) {
 	}
 }
 
+func TestSeparateStderr(t *testing.T) {
+	res, err := Command("sh", "-c", "echo -n out; echo -n err >&2").SeparateStderr().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StrOutput() != "out" {
+		t.Errorf("expected output 'out', got '%s'", res.StrOutput())
+	}
+
+	if string(res.Stderr) != "err" {
+		t.Errorf("expected stderr 'err', got '%s'", res.Stderr)
+	}
+}
+
 func TestCommandFails(t *testing.T) {
 	res, err := Command("false").Run()
 	if err != nil {
@@ -47,3 +64,25 @@ func TestExpectSuccess(t *testing.T) {
 	}
 
 }
+
+func TestCancelTerminatesProcessGroup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	// the background sleep process keeps STDOUT open, Run() only returns
+	// when it was terminated too
+	res, err := CommandContext(ctx, "sh", "-c", "sleep 60 & sleep 60").Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.ExitCode == 0 {
+		t.Errorf("cmd exited with code 0, expected non-zero exit code")
+	}
+
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("command was not terminated, it ran for %s", elapsed)
+	}
+}
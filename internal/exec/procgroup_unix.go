@@ -0,0 +1,25 @@
+// +build !windows
+
+package exec
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup configures cmd to be started in a new process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup sends sig to all processes in the process group of cmd.
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	// the process group id is the pid of the started process,
+	// a negative pid addresses the whole process group
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
+
+// killProcessGroup sends SIGKILL to all processes in the process group of cmd.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return signalProcessGroup(cmd, syscall.SIGKILL)
+}
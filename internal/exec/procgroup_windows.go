@@ -0,0 +1,20 @@
+package exec
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup is a noop on windows.
+func setProcessGroup(*exec.Cmd) {}
+
+// signalProcessGroup kills the started process, sending other signals is not
+// supported on windows.
+func signalProcessGroup(cmd *exec.Cmd, _ syscall.Signal) error {
+	return cmd.Process.Kill()
+}
+
+// killProcessGroup kills the started process.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
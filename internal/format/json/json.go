@@ -0,0 +1,153 @@
+// Package json provides formatters that output rows as JSON objects.
+package json
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formatter converts rows into JSON objects. The values of a row are
+// assigned to the keys that were passed to the constructor, in the same
+// order.
+// Values are encoded with their types, values that implement fmt.Stringer
+// and not json.Marshaler are encoded as strings.
+type Formatter struct {
+	out   *bufio.Writer
+	keys  []string
+	lines bool
+	rows  int
+}
+
+// New returns a formatter that writes the rows as a JSON array of objects.
+// The closing bracket of the array is written on Flush.
+func New(keys []string, out io.Writer) *Formatter {
+	return &Formatter{
+		out:  bufio.NewWriter(out),
+		keys: keys,
+	}
+}
+
+// NewLines returns a formatter that writes the rows in JSON-lines format,
+// one object per line.
+func NewLines(keys []string, out io.Writer) *Formatter {
+	return &Formatter{
+		out:   bufio.NewWriter(out),
+		keys:  keys,
+		lines: true,
+	}
+}
+
+// WriteRow writes a row as JSON object to the buffer.
+func (f *Formatter) WriteRow(row ...interface{}) error {
+	if len(row) != len(f.keys) {
+		return fmt.Errorf("row has %d columns, expected %d (%s)", len(row), len(f.keys), strings.Join(f.keys, ", "))
+	}
+
+	obj, err := f.encodeObject(row)
+	if err != nil {
+		return err
+	}
+
+	if f.lines {
+		_, err = fmt.Fprintf(f.out, "%s\n", obj)
+		f.rows++
+
+		return err
+	}
+
+	if f.rows == 0 {
+		_, err = f.out.WriteString("[\n  ")
+	} else {
+		_, err = f.out.WriteString(",\n  ")
+	}
+	if err != nil {
+		return err
+	}
+
+	f.rows++
+
+	_, err = f.out.Write(obj)
+	return err
+}
+
+// encodeObject encodes the row as JSON object, the fields are ordered like
+// the keys.
+func (f *Formatter) encodeObject(row []interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteRune('{')
+
+	for i, val := range row {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+
+		key, err := encode(f.keys[i])
+		if err != nil {
+			return nil, err
+		}
+
+		encVal, err := encode(toJSONValue(val))
+		if err != nil {
+			return nil, fmt.Errorf("encoding value of %q failed: %w", f.keys[i], err)
+		}
+
+		buf.Write(key)
+		buf.WriteRune(':')
+		buf.Write(encVal)
+	}
+
+	buf.WriteRune('}')
+
+	return buf.Bytes(), nil
+}
+
+func toJSONValue(val interface{}) interface{} {
+	if _, ok := val.(json.Marshaler); ok {
+		return val
+	}
+
+	if s, ok := val.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	return val
+}
+
+func encode(val interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(val); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Flush terminates the JSON array if the formatter was created with New and
+// writes the buffer to the output.
+// It must be called once after all rows were written.
+func (f *Formatter) Flush() error {
+	if !f.lines {
+		var err error
+
+		if f.rows == 0 {
+			_, err = f.out.WriteString("[]\n")
+		} else {
+			_, err = f.out.WriteString("\n]\n")
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return f.out.Flush()
+}
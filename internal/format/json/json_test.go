@@ -0,0 +1,65 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stringer int
+
+func (s stringer) String() string {
+	return "str"
+}
+
+func TestArray(t *testing.T) {
+	var buf bytes.Buffer
+
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	f := New([]string{"id", "name", "time", "status", "ok"}, &buf)
+	require.NoError(t, f.WriteRow(1, "a,b\nc", ts, stringer(1), true))
+	require.NoError(t, f.WriteRow(2, "x&y", ts, stringer(2), false))
+	require.NoError(t, f.Flush())
+
+	assert.Equal(t,
+		`[
+  {"id":1,"name":"a,b\nc","time":"2020-01-02T03:04:05Z","status":"str","ok":true},
+  {"id":2,"name":"x&y","time":"2020-01-02T03:04:05Z","status":"str","ok":false}
+]
+`, buf.String())
+
+	var result []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Len(t, result, 2)
+}
+
+func TestEmptyArray(t *testing.T) {
+	var buf bytes.Buffer
+
+	f := New([]string{"id"}, &buf)
+	require.NoError(t, f.Flush())
+
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestLines(t *testing.T) {
+	var buf bytes.Buffer
+
+	f := NewLines([]string{"id", "digests"}, &buf)
+	require.NoError(t, f.WriteRow(1, []string{"a", "b"}))
+	require.NoError(t, f.WriteRow(2, nil))
+	require.NoError(t, f.Flush())
+
+	assert.Equal(t, "{\"id\":1,\"digests\":[\"a\",\"b\"]}\n{\"id\":2,\"digests\":null}\n", buf.String())
+}
+
+func TestWriteRowFailsOnColumnMismatch(t *testing.T) {
+	f := NewLines([]string{"id", "name"}, &bytes.Buffer{})
+
+	assert.Error(t, f.WriteRow(1))
+}
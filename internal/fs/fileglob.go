@@ -3,6 +3,7 @@ package fs
 import (
 	"fmt"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -141,3 +142,69 @@ func expandDoubleStarGlob(absGlobPath string) ([]string, error) {
 
 	return dirs, nil
 }
+
+// MatchGlob reports whether path matches the glob pattern.
+// The pattern supports the same syntax as FileGlob(), '**' matches
+// the directory in front of it and all it's subdirectories.
+// Only the strings are compared, the filesystem is not accessed.
+func MatchGlob(pattern, path string) (bool, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Match(pattern, path)
+	}
+
+	spl := strings.SplitN(pattern, "**", 2)
+	basePath := spl[0]
+	glob := spl[1]
+
+	if len(glob) == 0 {
+		glob = "*"
+	}
+
+	if !strings.HasPrefix(path, basePath) {
+		// validate the pattern
+		_, err := filepath.Match(pattern, "")
+		return false, err
+	}
+
+	// try every directory between basePath and the file as replacement
+	// for '**', starting with basePath itself
+	subDir := ""
+	rest := path[len(basePath):]
+
+	for {
+		matched, err := filepath.Match(filepath.Join(escapeGlob(basePath), escapeGlob(subDir), glob), path)
+		if err != nil || matched {
+			return matched, err
+		}
+
+		idx := strings.IndexRune(rest, filepath.Separator)
+		if idx == -1 {
+			return false, nil
+		}
+
+		subDir = filepath.Join(subDir, rest[:idx])
+		rest = rest[idx+1:]
+	}
+}
+
+// escapeGlob escapes the characters in path that have a special meaning in
+// glob patterns.
+// On Windows path is returned unchanged, escaping is not supported there.
+func escapeGlob(path string) string {
+	if runtime.GOOS == "windows" {
+		return path
+	}
+
+	var sb strings.Builder
+
+	for _, r := range path {
+		switch r {
+		case '*', '?', '[', '\\':
+			sb.WriteRune('\\')
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
@@ -190,3 +190,42 @@ func Test_Resolve(t *testing.T) {
 	}
 
 }
+
+func Test_MatchGlob(t *testing.T) {
+	testcases := []struct {
+		pattern       string
+		path          string
+		expectedMatch bool
+	}{
+		{pattern: "/app/*.md", path: "/app/README.md", expectedMatch: true},
+		{pattern: "/app/*.md", path: "/app/doc/README.md", expectedMatch: false},
+		{pattern: "/app/**", path: "/app/a.go", expectedMatch: true},
+		{pattern: "/app/**", path: "/app/1/2/a.go", expectedMatch: true},
+		{pattern: "/app/**", path: "/other/a.go", expectedMatch: false},
+		{pattern: "/app/**/*_test.go", path: "/app/a_test.go", expectedMatch: true},
+		{pattern: "/app/**/*_test.go", path: "/app/1/2/a_test.go", expectedMatch: true},
+		{pattern: "/app/**/*_test.go", path: "/app/1/2/a.go", expectedMatch: false},
+		{pattern: "/app/testdata/**", path: "/app/testdata/x/y.json", expectedMatch: true},
+		{pattern: "/app/testdata/**", path: "/app/src/testdata/y.json", expectedMatch: false},
+		{pattern: "/app/1/**/*.go", path: "/app/1/2/3/three.go", expectedMatch: true},
+		{pattern: "/app/1/**/*.go", path: "/app/base.go", expectedMatch: false},
+		{pattern: "/app/**/*.go", path: "/app/[1]/*/a.go", expectedMatch: true},
+	}
+
+	for _, tc := range testcases {
+		matched, err := MatchGlob(tc.pattern, tc.path)
+		if err != nil {
+			t.Fatalf("matching %q against %q failed: %s", tc.path, tc.pattern, err)
+		}
+
+		if matched != tc.expectedMatch {
+			t.Errorf("MatchGlob(%q, %q) returned %t, expected %t", tc.pattern, tc.path, matched, tc.expectedMatch)
+		}
+	}
+}
+
+func Test_MatchGlobInvalidPattern(t *testing.T) {
+	if _, err := MatchGlob("/app/**/[", "/app/a"); err == nil {
+		t.Error("MatchGlob did not return an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,26 @@
+// +build !windows
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// LockShared acquires a shared advisory lock on f, it blocks until the
+// lock is acquired.
+func LockShared(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_SH)
+}
+
+// LockExclusive acquires an exclusive advisory lock on f, it blocks until
+// the lock is acquired.
+func LockExclusive(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+// Unlock releases a lock that was acquired with LockShared() or
+// LockExclusive().
+func Unlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
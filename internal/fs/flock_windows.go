@@ -0,0 +1,30 @@
+package fs
+
+import (
+	"math"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func lockFile(f *os.File, flags uint32) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, math.MaxUint32, math.MaxUint32, &windows.Overlapped{})
+}
+
+// LockShared acquires a shared advisory lock on f, it blocks until the
+// lock is acquired.
+func LockShared(f *os.File) error {
+	return lockFile(f, 0)
+}
+
+// LockExclusive acquires an exclusive advisory lock on f, it blocks until
+// the lock is acquired.
+func LockExclusive(f *os.File) error {
+	return lockFile(f, windows.LOCKFILE_EXCLUSIVE_LOCK)
+}
+
+// Unlock releases a lock that was acquired with LockShared() or
+// LockExclusive().
+func Unlock(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, math.MaxUint32, math.MaxUint32, &windows.Overlapped{})
+}
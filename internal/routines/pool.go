@@ -73,8 +73,9 @@ func (p *Pool) popWork() WorkFn {
 		return nil
 	}
 
-	w := p.wq[len(p.wq)-1]
-	p.wq = p.wq[:len(p.wq)-1]
+	w := p.wq[0]
+	p.wq[0] = nil
+	p.wq = p.wq[1:]
 
 	return w
 }
@@ -35,3 +35,23 @@ func TestQueuePanicsAfterWait(t *testing.T) {
 		pool.Queue(func() {})
 	})
 }
+
+func TestWorkIsExecutedInFIFOOrder(t *testing.T) {
+	var order []int
+
+	pool := NewPool(1)
+
+	for i := 0; i < 100; i++ {
+		i := i
+		pool.Queue(func() {
+			order = append(order, i)
+		})
+	}
+
+	pool.Wait()
+
+	assert.Len(t, order, 100)
+	for i := range order {
+		assert.Equal(t, i, order[i])
+	}
+}
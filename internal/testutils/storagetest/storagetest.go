@@ -0,0 +1,1534 @@
+// Package storagetest provides a test suite that is run against all
+// storage.Storer implementations.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+var ctx = context.Background()
+
+// NewStorerFn returns a new Storer that is used by a single test and a
+// function that is called when the test finished.
+// The Storer must not be initialized, tests call Init() when they require
+// it.
+type NewStorerFn func(t *testing.T) (storage.Storer, func())
+
+var tests = []struct {
+	name string
+	fn   func(*testing.T, NewStorerFn)
+}{
+	{"SaveTaskRun", testSaveTaskRun},
+	{"LatestTaskRunByDigest", testLatestTaskRunByDigest},
+	{"LatestTaskRunByDigest_IgnoresFailedRuns", testLatestTaskRunByDigest_IgnoresFailedRuns},
+	{"LatestTaskRunByDigest_ReturnsErrNotExist", testLatestTaskRunByDigest_ReturnsErrNotExist},
+	{"LatestTaskRunsByDigest", testLatestTaskRunsByDigest},
+	{"TaskRun_ReturnsErrNotExist", testTaskRun_ReturnsErrNotExist},
+	{"Inputs_ReturnsErrNotExist", testInputs_ReturnsErrNotExist},
+	{"Outputs_ReturnsErrNotExist", testOutputs_ReturnsErrNotExist},
+	{"Outputs", testOutputs},
+	{"Inputs", testInputs},
+	{"TaskRun", testTaskRun},
+	{"TaskRunProvenance", testTaskRunProvenance},
+	{"TaskRuns", testTaskRuns},
+	{"TaskRunsFiltersAndPagination", testTaskRunsFiltersAndPagination},
+	{"TaskRunQueryRunWithoutOutputWithoutVCS", testTaskRunQueryRunWithoutOutputWithoutVCS},
+	{"TaskRunLog", testTaskRunLog},
+	{"TaskStats", testTaskStats},
+	{"TaskStats_ReturnsErrNotExist", testTaskStats_ReturnsErrNotExist},
+	{"OutputSizeTrend", testOutputSizeTrend},
+	{"SaveTaskRunsAndTaskRunsFull", testSaveTaskRunsAndTaskRunsFull},
+	{"TaskRunsFull_Order", testTaskRunsFull_Order},
+	{"ExportImport", testExportImport},
+	{"Import_RejectsUnsupportedVersion", testImport_RejectsUnsupportedVersion},
+}
+
+// Run runs the test suite, for every test a new Storer is created via
+// newStorer.
+func Run(t *testing.T, newStorer NewStorerFn) {
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, newStorer)
+		})
+	}
+}
+
+func testSaveTaskRun(t *testing.T, newStorer NewStorerFn) {
+	testcases := []*struct {
+		name string
+
+		taskRuns      []*storage.TaskRunFull
+		expectSuccess []bool
+	}{
+		{
+			name: "1",
+			taskRuns: []*storage.TaskRunFull{
+				{
+					TaskRun: storage.TaskRun{
+						ApplicationName:  "baurHimself",
+						TaskName:         "build",
+						VCSRevision:      "1",
+						VCSIsDirty:       false,
+						StartTimestamp:   time.Now(),
+						StopTimestamp:    time.Now().Add(5 * time.Minute),
+						Result:           storage.ResultSuccess,
+						TotalInputDigest: "1234567890",
+					},
+					Inputs: []*storage.Input{
+						{
+							URI:    "main.go",
+							Digest: "45",
+						},
+					},
+					Outputs: []*storage.Output{
+						{
+							Name:      "binary",
+							Type:      storage.ArtifactTypeFile,
+							Digest:    "456",
+							SizeBytes: 300,
+							Uploads: []*storage.Upload{
+								{
+									UploadStartTimestamp: time.Now(),
+									UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+									Method:               storage.UploadMethodS3,
+								},
+							},
+						},
+					},
+				},
+			},
+			expectSuccess: []bool{true},
+		},
+
+		{
+			name: "no_outputs",
+			taskRuns: []*storage.TaskRunFull{
+				{
+					TaskRun: storage.TaskRun{
+						ApplicationName:  "baurHimself",
+						TaskName:         "build",
+						VCSRevision:      "1",
+						VCSIsDirty:       false,
+						StartTimestamp:   time.Now(),
+						StopTimestamp:    time.Now().Add(5 * time.Minute),
+						TotalInputDigest: "1234567890",
+						Result:           storage.ResultSuccess,
+					},
+					Inputs: []*storage.Input{
+						{
+							URI:    "main.go",
+							Digest: "45",
+						},
+					},
+				},
+			},
+			expectSuccess: []bool{true},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if len(tc.taskRuns) != len(tc.expectSuccess) {
+				t.Fatal("taskRuns and expectSuccess slice of testcase do not contain same number of elements")
+			}
+
+			client, cleanupFn := newStorer(t)
+			defer cleanupFn()
+
+			require.NoError(t, client.Init(ctx))
+
+			for i := range tc.taskRuns {
+				taskRun := tc.taskRuns[i]
+				expectedResult := tc.expectSuccess[i]
+
+				id, err := client.SaveTaskRun(ctx, taskRun)
+
+				if expectedResult {
+					assert.NoError(t, err)
+					assert.Greater(t, id, 0)
+
+					return
+				}
+
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+// drop the local monotonic values from timestamps and rounding it is required
+// to prevent that comparsions of local and retrieved objects fail because of the monotonic clock value or
+// minor timestamp changes
+// See also: https://github.com/stretchr/testify/issues/502
+func taskRunDropMonotonicTimevals(tr *storage.TaskRun) *storage.TaskRun {
+	tr.StartTimestamp = tr.StartTimestamp.Round(time.Millisecond)
+	tr.StopTimestamp = tr.StopTimestamp.Round(time.Millisecond)
+
+	return tr
+}
+
+func outputDropMonotonicTimevals(outputs []*storage.Output) []*storage.Output {
+	for _, o := range outputs {
+		for _, upload := range o.Uploads {
+			upload.UploadStartTimestamp = upload.UploadStartTimestamp.Round(time.Millisecond)
+			upload.UploadStopTimestamp = upload.UploadStopTimestamp.Round(time.Millisecond)
+		}
+	}
+
+	return outputs
+}
+
+func testLatestTaskRunByDigest(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	run1 := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:    "baurHimself",
+			TaskName:           "build",
+			VCSRevision:        "1",
+			VCSIsDirty:         false,
+			StartTimestamp:     time.Now(),
+			StopTimestamp:      time.Now().Add(5 * time.Minute),
+			Result:             storage.ResultSuccess,
+			TotalInputDigest:   "1234567890",
+			InputDigestVersion: 2,
+		},
+		Inputs: []*storage.Input{
+			{
+				URI:    "main.go",
+				Digest: "45",
+			},
+		},
+		Outputs: []*storage.Output{
+			{
+				Name:      "binary",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "456",
+				SizeBytes: 300,
+				Uploads: []*storage.Upload{
+					{
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodS3,
+					},
+				},
+			},
+		},
+	}
+
+	run2 := run1
+	run2.StopTimestamp = run2.StopTimestamp.Add(time.Second)
+
+	_, err := client.SaveTaskRun(ctx, &run1)
+	require.NoError(t, err)
+
+	id, err := client.SaveTaskRun(ctx, &run2)
+	require.NoError(t, err)
+
+	latestTaskRun, err := client.LatestTaskRunByDigest(ctx, run2.ApplicationName, run2.TaskName, run2.TotalInputDigest, run2.InputDigestVersion)
+	require.NoError(t, err)
+
+	assert.Equal(t, id, latestTaskRun.ID, "wrong record id")
+	assert.Equal(t, taskRunDropMonotonicTimevals(&run2.TaskRun), taskRunDropMonotonicTimevals(&latestTaskRun.TaskRun))
+
+	_, err = client.LatestTaskRunByDigest(ctx, run2.ApplicationName, run2.TaskName, run2.TotalInputDigest, run2.InputDigestVersion+1)
+	assert.Equal(t, storage.ErrNotExist, err, "run with a different input digest version was returned")
+}
+
+func testLatestTaskRunByDigest_IgnoresFailedRuns(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	successfulRun := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "baurHimself",
+			TaskName:         "build",
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now().Add(5 * time.Minute),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1234567890",
+		},
+		Inputs: []*storage.Input{
+			{
+				URI:    "main.go",
+				Digest: "45",
+			},
+		},
+	}
+
+	failedRun := successfulRun
+	failedRun.StopTimestamp = failedRun.StopTimestamp.Add(time.Second)
+	failedRun.Result = storage.ResultFailure
+	failedRun.ExitCode = 2
+
+	id, err := client.SaveTaskRun(ctx, &successfulRun)
+	require.NoError(t, err)
+
+	_, err = client.SaveTaskRun(ctx, &failedRun)
+	require.NoError(t, err)
+
+	latestTaskRun, err := client.LatestTaskRunByDigest(ctx, failedRun.ApplicationName, failedRun.TaskName, failedRun.TotalInputDigest, failedRun.InputDigestVersion)
+	require.NoError(t, err)
+
+	assert.Equal(t, id, latestTaskRun.ID, "wrong record id")
+	assert.Equal(t, storage.ResultSuccess, latestTaskRun.Result)
+}
+
+func testLatestTaskRunByDigest_ReturnsErrNotExist(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+	taskRun, err := client.LatestTaskRunByDigest(ctx, "myapp", "mytask", "241abc", 2)
+
+	assert.Equal(t, storage.ErrNotExist, err)
+	assert.Nil(t, taskRun)
+}
+
+func testLatestTaskRunsByDigest(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	newRun := func(appName, digest string, stop time.Time, result storage.Result) *storage.TaskRunFull {
+		return &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:    appName,
+				TaskName:           "build",
+				StartTimestamp:     stop.Add(-time.Minute),
+				StopTimestamp:      stop,
+				Result:             result,
+				TotalInputDigest:   digest,
+				InputDigestVersion: 2,
+			},
+			Inputs: []*storage.Input{
+				{
+					URI:    "main.go",
+					Digest: digest,
+				},
+			},
+		}
+	}
+
+	now := time.Now()
+
+	_, err := client.SaveTaskRun(ctx, newRun("app1", "1", now.Add(-time.Hour), storage.ResultSuccess))
+	require.NoError(t, err)
+
+	app1ID, err := client.SaveTaskRun(ctx, newRun("app1", "1", now, storage.ResultSuccess))
+	require.NoError(t, err)
+
+	_, err = client.SaveTaskRun(ctx, newRun("app1", "1", now.Add(time.Minute), storage.ResultFailure))
+	require.NoError(t, err)
+
+	app2ID, err := client.SaveTaskRun(ctx, newRun("app2", "2", now, storage.ResultSuccess))
+	require.NoError(t, err)
+
+	runs, err := client.LatestTaskRunsByDigest(ctx, []*storage.TaskDigest{
+		{ApplicationName: "app2", TaskName: "build", TotalInputDigest: "2", InputDigestVersion: 2},
+		{ApplicationName: "app1", TaskName: "build", TotalInputDigest: "2", InputDigestVersion: 2},
+		{ApplicationName: "app3", TaskName: "build", TotalInputDigest: "1", InputDigestVersion: 2},
+		{ApplicationName: "app1", TaskName: "build", TotalInputDigest: "1", InputDigestVersion: 2},
+		{ApplicationName: "app1", TaskName: "build", TotalInputDigest: "1", InputDigestVersion: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 5)
+
+	require.NotNil(t, runs[0])
+	assert.Equal(t, app2ID, runs[0].ID)
+	assert.Equal(t, "app2", runs[0].ApplicationName)
+	assert.Nil(t, runs[1])
+	assert.Nil(t, runs[2])
+	require.NotNil(t, runs[3])
+	assert.Equal(t, app1ID, runs[3].ID)
+	assert.Nil(t, runs[4])
+
+	runs, err = client.LatestTaskRunsByDigest(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func testTaskRun_ReturnsErrNotExist(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+	taskRun, err := client.TaskRun(ctx, 113124)
+
+	assert.Equal(t, storage.ErrNotExist, err)
+	assert.Nil(t, taskRun)
+}
+
+func testInputs_ReturnsErrNotExist(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+	inputs, err := client.Inputs(ctx, 113124)
+
+	assert.Equal(t, storage.ErrNotExist, err)
+	assert.Nil(t, inputs)
+}
+
+func testOutputs_ReturnsErrNotExist(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+	outputs, err := client.Outputs(ctx, 113124)
+
+	assert.Equal(t, storage.ErrNotExist, err)
+	assert.Nil(t, outputs)
+}
+
+// TODO: Write Tests for:
+// - Outputs()
+// - Inputs()
+
+func testOutputs(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	run := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "baurHimself",
+			TaskName:         "build",
+			VCSRevision:      "1",
+			VCSIsDirty:       false,
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now().Add(5 * time.Minute),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1234567890",
+		},
+		Inputs: []*storage.Input{
+			{
+				URI:    "main.go",
+				Digest: "45",
+			},
+		},
+		Outputs: []*storage.Output{
+			{
+				Name:      "binary",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "456",
+				SizeBytes: 300,
+				Uploads: []*storage.Upload{
+					{
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodS3,
+					},
+				},
+			},
+			{
+				Name:      "binary2",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "4561",
+				SizeBytes: 2,
+				Uploads: []*storage.Upload{
+					{
+						URI:                  "s3://tmp/test",
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodS3,
+					},
+					{
+						URI:                  "file://myftp.com/file.bin",
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodFileCopy,
+					},
+				},
+			},
+		},
+	}
+
+	id, err := client.SaveTaskRun(ctx, &run)
+	require.NoError(t, err)
+	assert.Greater(t, id, 0)
+
+	outputs, err := client.Outputs(ctx, id)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, outputDropMonotonicTimevals(run.Outputs), outputDropMonotonicTimevals(outputs))
+}
+
+func testInputs(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	run := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "baurHimself",
+			TaskName:         "build",
+			VCSRevision:      "1",
+			VCSIsDirty:       false,
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now().Add(5 * time.Minute),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1234567890",
+		},
+		Inputs: []*storage.Input{
+			{
+				URI:    "main.go",
+				Digest: "45",
+			},
+
+			{
+				URI:    "util.go",
+				Digest: "46",
+			},
+			{
+				URI:    "file://Makefile",
+				Digest: "47",
+			},
+		},
+	}
+
+	id, err := client.SaveTaskRun(ctx, &run)
+	require.NoError(t, err)
+	assert.Greater(t, id, 0)
+
+	inputs, err := client.Inputs(ctx, id)
+	require.NoError(t, err)
+
+	assert.Equal(t, run.Inputs, inputs)
+}
+
+func testTaskRun(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	run := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "baurHimself",
+			TaskName:         "build",
+			VCSRevision:      "1",
+			VCSIsDirty:       false,
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now().Add(5 * time.Minute),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1234567890",
+		},
+		Inputs: []*storage.Input{
+			{
+				URI:    "main.go",
+				Digest: "45",
+			},
+		},
+		Outputs: []*storage.Output{
+			{
+				Name:      "binary",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "456",
+				SizeBytes: 300,
+				Uploads: []*storage.Upload{
+					{
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodS3,
+					},
+				},
+			},
+			{
+				Name:      "binary2",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "4561",
+				SizeBytes: 2,
+				Uploads: []*storage.Upload{
+					{
+						URI:                  "s3://tmp/test",
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodS3,
+					},
+					{
+						URI:                  "file://myftp.com/file.bin",
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodFileCopy,
+					},
+				},
+			},
+		},
+	}
+
+	id, err := client.SaveTaskRun(ctx, &run)
+	require.NoError(t, err)
+	assert.Greater(t, id, 0)
+
+	taskRun, err := client.TaskRun(ctx, id)
+	require.NoError(t, err)
+	assert.NotNil(t, taskRun)
+
+	assert.Equal(t, taskRunDropMonotonicTimevals(&run.TaskRun), taskRunDropMonotonicTimevals(&taskRun.TaskRun))
+}
+
+func testTaskRunProvenance(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	run := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "baurHimself",
+			TaskName:         "build",
+			VCSRevision:      "1",
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now().Add(5 * time.Minute),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1234567890",
+			Hostname:         "ci-worker-1",
+			Username:         "builder",
+			BaurVersion:      "1.2.3",
+			Command:          []string{"make", "-C", "src", "all"},
+			EnvVars: map[string]string{
+				"CI_JOB_URL": "https://ci.example.com/jobs/1",
+				"CI_JOB_ID":  "1",
+			},
+		},
+		Inputs: []*storage.Input{
+			{
+				URI:    "main.go",
+				Digest: "45",
+			},
+		},
+	}
+
+	id, err := client.SaveTaskRun(ctx, &run)
+	require.NoError(t, err)
+
+	expected := taskRunDropMonotonicTimevals(&run.TaskRun)
+
+	taskRun, err := client.TaskRun(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, expected, taskRunDropMonotonicTimevals(&taskRun.TaskRun))
+
+	latest, err := client.LatestTaskRunByDigest(ctx, run.ApplicationName, run.TaskName, run.TotalInputDigest, run.InputDigestVersion)
+	require.NoError(t, err)
+	assert.Equal(t, expected, taskRunDropMonotonicTimevals(&latest.TaskRun))
+
+	var queried []*storage.TaskRunWithID
+	err = client.TaskRuns(ctx, nil, nil, nil, func(tr *storage.TaskRunWithID) error {
+		queried = append(queried, tr)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, queried, 1)
+	assert.Equal(t, expected, taskRunDropMonotonicTimevals(&queried[0].TaskRun))
+}
+
+func testTaskRuns(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	run := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "baurHimself",
+			TaskName:         "build",
+			VCSRevision:      "1",
+			VCSIsDirty:       false,
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now().Add(5 * time.Minute),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1234567890",
+		},
+		Inputs: []*storage.Input{
+			{
+				URI:    "main.go",
+				Digest: "45",
+			},
+		},
+		Outputs: []*storage.Output{
+			{
+				Name:      "binary",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "456",
+				SizeBytes: 300,
+				Uploads: []*storage.Upload{
+					{
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodS3,
+					},
+				},
+			},
+			{
+				Name:      "binary2",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "4561",
+				SizeBytes: 2,
+				Uploads: []*storage.Upload{
+					{
+						URI:                  "s3://tmp/test",
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodS3,
+					},
+					{
+						URI:                  "file://myftp.com/file.bin",
+						UploadStartTimestamp: time.Now(),
+						UploadStopTimestamp:  time.Now().Add(5 * time.Second),
+						Method:               storage.UploadMethodFileCopy,
+					},
+				},
+			},
+		},
+	}
+
+	taskRunDropMonotonicTimevals(&run.TaskRun)
+
+	id, err := client.SaveTaskRun(ctx, &run)
+	require.NoError(t, err)
+	assert.Greater(t, id, 0)
+
+	run1 := run
+	run1.StartTimestamp = run1.StartTimestamp.Add(time.Second)
+	run1.TaskName = "check"
+	taskRunDropMonotonicTimevals(&run1.TaskRun)
+
+	id1, err := client.SaveTaskRun(ctx, &run1)
+	require.NoError(t, err)
+	assert.Greater(t, id1, 0)
+	assert.NotEqual(t, id, id1)
+
+	testcases := []*struct {
+		name    string
+		filters []*storage.Filter
+		sorters []*storage.Sorter
+
+		expectedTaskRuns []*storage.TaskRunWithID
+		expectedError    error
+	}{
+		{
+			name: "EqAppNameAndTaskName",
+			filters: []*storage.Filter{
+				&storage.Filter{
+					Field:    storage.FieldTaskName,
+					Operator: storage.OpEQ,
+					Value:    run1.TaskName,
+				},
+
+				&storage.Filter{
+					Field:    storage.FieldApplicationName,
+					Operator: storage.OpEQ,
+					Value:    run1.ApplicationName,
+				},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{
+				&storage.TaskRunWithID{
+					ID:      id1,
+					TaskRun: run1.TaskRun,
+				},
+			},
+		},
+
+		{
+			name: "INAppNames",
+			filters: []*storage.Filter{
+				&storage.Filter{
+					Field:    storage.FieldApplicationName,
+					Operator: storage.OpIN,
+					Value:    []string{run1.ApplicationName, "testApp"},
+				},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{
+				&storage.TaskRunWithID{
+					ID:      id,
+					TaskRun: run.TaskRun,
+				},
+
+				&storage.TaskRunWithID{
+					ID:      id1,
+					TaskRun: run1.TaskRun,
+				},
+			},
+		},
+
+		{
+			name: "appNameOrderByDurationAsc",
+			filters: []*storage.Filter{
+				&storage.Filter{
+					Field:    storage.FieldApplicationName,
+					Operator: storage.OpEQ,
+					Value:    run.ApplicationName,
+				},
+			},
+			sorters: []*storage.Sorter{
+				&storage.Sorter{
+					Field: storage.FieldDuration,
+					Order: storage.OrderAsc,
+				},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{
+				&storage.TaskRunWithID{
+					ID:      id,
+					TaskRun: run.TaskRun,
+				},
+				&storage.TaskRunWithID{
+					ID:      id1,
+					TaskRun: run1.TaskRun,
+				},
+			},
+		},
+
+		{
+			name: "appNameOrderByDurationDesc",
+			filters: []*storage.Filter{
+				&storage.Filter{
+					Field:    storage.FieldApplicationName,
+					Operator: storage.OpEQ,
+					Value:    run.ApplicationName,
+				},
+			},
+			sorters: []*storage.Sorter{
+				&storage.Sorter{
+					Field: storage.FieldDuration,
+					Order: storage.OrderDesc,
+				},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{
+				&storage.TaskRunWithID{
+					ID:      id1,
+					TaskRun: run1.TaskRun,
+				},
+
+				&storage.TaskRunWithID{
+					ID:      id,
+					TaskRun: run.TaskRun,
+				},
+			},
+		},
+
+		{
+			name: "NoMatch",
+			filters: []*storage.Filter{
+				&storage.Filter{
+					Field:    storage.FieldID,
+					Operator: storage.OpEQ,
+					Value:    -500,
+				},
+			},
+			expectedError: storage.ErrNotExist,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			var result []*storage.TaskRunWithID
+
+			err := client.TaskRuns(ctx, testcase.filters, testcase.sorters, nil, func(tr *storage.TaskRunWithID) error {
+				result = append(result, tr)
+				return nil
+			})
+			assert.Equal(t, testcase.expectedError, err)
+
+			for _, taskRun := range result {
+				taskRunDropMonotonicTimevals(&taskRun.TaskRun)
+			}
+
+			assert.ElementsMatch(t, testcase.expectedTaskRuns, result)
+		})
+	}
+
+}
+
+func testTaskRunsFiltersAndPagination(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	start := time.Now()
+
+	runs := []*storage.TaskRunFull{
+		{
+			TaskRun: storage.TaskRun{
+				ApplicationName:  "baurHimself",
+				TaskName:         "build",
+				VCSRevision:      "abc123",
+				StartTimestamp:   start,
+				StopTimestamp:    start.Add(time.Minute),
+				Result:           storage.ResultSuccess,
+				TotalInputDigest: "1",
+			},
+		},
+		{
+			TaskRun: storage.TaskRun{
+				ApplicationName:  "baurHimself",
+				TaskName:         "build",
+				VCSRevision:      "abd456",
+				VCSIsDirty:       true,
+				StartTimestamp:   start.Add(time.Hour),
+				StopTimestamp:    start.Add(time.Hour + 10*time.Minute),
+				Result:           storage.ResultFailure,
+				TotalInputDigest: "2",
+			},
+		},
+		{
+			TaskRun: storage.TaskRun{
+				ApplicationName:  "baurHimself",
+				TaskName:         "build",
+				VCSRevision:      "b_c%",
+				StartTimestamp:   start.Add(2 * time.Hour),
+				StopTimestamp:    start.Add(2*time.Hour + 5*time.Minute),
+				Result:           storage.ResultTimeout,
+				TotalInputDigest: "2",
+			},
+		},
+	}
+
+	ids := make([]int, 0, len(runs))
+
+	for _, run := range runs {
+		run.Inputs = []*storage.Input{{URI: "main.go", Digest: run.TotalInputDigest}}
+		taskRunDropMonotonicTimevals(&run.TaskRun)
+
+		id, err := client.SaveTaskRun(ctx, run)
+		require.NoError(t, err)
+
+		ids = append(ids, id)
+	}
+
+	sortByStartTime := []*storage.Sorter{
+		{
+			Field: storage.FieldStartTime,
+			Order: storage.OrderAsc,
+		},
+	}
+
+	testcases := []*struct {
+		name       string
+		filters    []*storage.Filter
+		pagination *storage.Pagination
+
+		expectedIDs   []int
+		expectedError error
+	}{
+		{
+			name: "ResultEQ",
+			filters: []*storage.Filter{
+				{Field: storage.FieldResult, Operator: storage.OpEQ, Value: storage.ResultFailure},
+			},
+			expectedIDs: []int{ids[1]},
+		},
+		{
+			name: "ResultIN",
+			filters: []*storage.Filter{
+				{Field: storage.FieldResult, Operator: storage.OpIN, Value: []storage.Result{storage.ResultSuccess, storage.ResultTimeout}},
+			},
+			expectedIDs: []int{ids[0], ids[2]},
+		},
+		{
+			name: "VCSRevisionEQ",
+			filters: []*storage.Filter{
+				{Field: storage.FieldVCSRevision, Operator: storage.OpEQ, Value: "abd456"},
+			},
+			expectedIDs: []int{ids[1]},
+		},
+		{
+			name: "VCSRevisionPrefix",
+			filters: []*storage.Filter{
+				{Field: storage.FieldVCSRevision, Operator: storage.OpPrefix, Value: "ab"},
+			},
+			expectedIDs: []int{ids[0], ids[1]},
+		},
+		{
+			name: "VCSRevisionPrefixWithWildcardChars",
+			filters: []*storage.Filter{
+				{Field: storage.FieldVCSRevision, Operator: storage.OpPrefix, Value: "b_c%"},
+			},
+			expectedIDs: []int{ids[2]},
+		},
+		{
+			name: "VCSRevisionPrefixWildcardCharsMatchLiterally",
+			filters: []*storage.Filter{
+				{Field: storage.FieldVCSRevision, Operator: storage.OpPrefix, Value: "a_"},
+			},
+			expectedError: storage.ErrNotExist,
+		},
+		{
+			name: "VCSIsDirty",
+			filters: []*storage.Filter{
+				{Field: storage.FieldVCSIsDirty, Operator: storage.OpEQ, Value: true},
+			},
+			expectedIDs: []int{ids[1]},
+		},
+		{
+			name: "VCSIsNotDirty",
+			filters: []*storage.Filter{
+				{Field: storage.FieldVCSIsDirty, Operator: storage.OpEQ, Value: false},
+			},
+			expectedIDs: []int{ids[0], ids[2]},
+		},
+		{
+			name: "TotalInputDigest",
+			filters: []*storage.Filter{
+				{Field: storage.FieldTotalInputDigest, Operator: storage.OpEQ, Value: "2"},
+			},
+			expectedIDs: []int{ids[1], ids[2]},
+		},
+		{
+			name: "DurationRange",
+			filters: []*storage.Filter{
+				{Field: storage.FieldDuration, Operator: storage.OpGT, Value: 2 * time.Minute},
+				{Field: storage.FieldDuration, Operator: storage.OpLT, Value: 6 * time.Minute},
+			},
+			expectedIDs: []int{ids[2]},
+		},
+		{
+			name:        "Limit",
+			pagination:  &storage.Pagination{Limit: 2},
+			expectedIDs: []int{ids[0], ids[1]},
+		},
+		{
+			name:        "Offset",
+			pagination:  &storage.Pagination{Offset: 1},
+			expectedIDs: []int{ids[1], ids[2]},
+		},
+		{
+			name:        "LimitAndOffset",
+			pagination:  &storage.Pagination{Offset: 1, Limit: 1},
+			expectedIDs: []int{ids[1]},
+		},
+		{
+			name: "FilterAndLimit",
+			filters: []*storage.Filter{
+				{Field: storage.FieldTotalInputDigest, Operator: storage.OpEQ, Value: "2"},
+			},
+			pagination:  &storage.Pagination{Limit: 1},
+			expectedIDs: []int{ids[1]},
+		},
+		{
+			name:          "OffsetBeyondRecords",
+			pagination:    &storage.Pagination{Offset: 3},
+			expectedError: storage.ErrNotExist,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			var result []int
+
+			err := client.TaskRuns(ctx, testcase.filters, sortByStartTime, testcase.pagination, func(tr *storage.TaskRunWithID) error {
+				result = append(result, tr.ID)
+				return nil
+			})
+			assert.Equal(t, testcase.expectedError, err)
+			assert.Equal(t, testcase.expectedIDs, result)
+		})
+	}
+}
+
+func testTaskRunQueryRunWithoutOutputWithoutVCS(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	run := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "baurHimself",
+			TaskName:         "build",
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now().Add(5 * time.Minute),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1234567890",
+		},
+		Inputs: []*storage.Input{
+			{
+				URI:    "main.go",
+				Digest: "45",
+			},
+		},
+	}
+
+	taskRunDropMonotonicTimevals(&run.TaskRun)
+
+	id, err := client.SaveTaskRun(ctx, &run)
+	require.NoError(t, err)
+	assert.Greater(t, id, 0)
+
+	tr, err := client.TaskRun(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, tr)
+
+	assert.Equal(t, run.TaskRun, tr.TaskRun)
+	assert.Equal(t, id, tr.ID)
+}
+
+func testTaskRunLog(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	run := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "baurHimself",
+			TaskName:         "build",
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now().Add(5 * time.Minute),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1234567890",
+		},
+		Inputs: []*storage.Input{
+			{
+				URI:    "main.go",
+				Digest: "45",
+			},
+		},
+	}
+
+	id, err := client.SaveTaskRun(ctx, &run)
+	require.NoError(t, err)
+
+	_, err = client.TaskRunLog(ctx, id)
+	assert.Equal(t, storage.ErrNotExist, err)
+
+	taskRun, err := client.TaskRun(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, taskRun.HasLog)
+
+	runLog, err := storage.NewTaskRunLog([]byte("building..."), 1024)
+	require.NoError(t, err)
+
+	run.Log = runLog
+
+	id, err = client.SaveTaskRun(ctx, &run)
+	require.NoError(t, err)
+
+	storedLog, err := client.TaskRunLog(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, runLog, storedLog)
+
+	taskRun, err = client.TaskRun(ctx, id)
+	require.NoError(t, err)
+	assert.True(t, taskRun.HasLog)
+
+	batchLog, err := storage.NewTaskRunLog([]byte("building again..."), 1024)
+	require.NoError(t, err)
+
+	run.Log = batchLog
+
+	ids, err := client.SaveTaskRuns(ctx, []*storage.TaskRunFull{&run})
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+
+	storedLog, err = client.TaskRunLog(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, batchLog, storedLog)
+}
+
+func testTaskStats(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	runs := []struct {
+		task     string
+		duration time.Duration
+		result   storage.Result
+	}{
+		{"build", 10 * time.Second, storage.ResultSuccess},
+		{"build", 40 * time.Second, storage.ResultSuccess},
+		{"build", 30 * time.Second, storage.ResultFailure},
+		{"build", 20 * time.Second, storage.ResultSuccess},
+		{"check", 5 * time.Second, storage.ResultTimeout},
+	}
+
+	for i, r := range runs {
+		runStart := start.Add(time.Duration(i) * time.Hour)
+
+		_, err := client.SaveTaskRun(ctx, &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:  "baurHimself",
+				TaskName:         r.task,
+				StartTimestamp:   runStart,
+				StopTimestamp:    runStart.Add(r.duration),
+				Result:           r.result,
+				TotalInputDigest: "1",
+			},
+			Inputs: []*storage.Input{{URI: "main.go", Digest: "1"}},
+		})
+		require.NoError(t, err)
+	}
+
+	stats, err := client.TaskStats(ctx, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*storage.TaskStats{
+		{
+			ApplicationName: "baurHimself",
+			TaskName:        "build",
+			Runs:            4,
+			SuccessfulRuns:  3,
+			DurationP50:     25 * time.Second,
+			DurationP95:     38500 * time.Millisecond,
+			DurationMax:     40 * time.Second,
+		},
+		{
+			ApplicationName: "baurHimself",
+			TaskName:        "check",
+			Runs:            1,
+			SuccessfulRuns:  0,
+			DurationP50:     5 * time.Second,
+			DurationP95:     5 * time.Second,
+			DurationMax:     5 * time.Second,
+		},
+	}, stats)
+
+	stats, err = client.TaskStats(ctx, []*storage.Filter{
+		{
+			Field:    storage.FieldStartTime,
+			Operator: storage.OpGT,
+			Value:    start.Add(2 * time.Hour),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, 1, stats[0].Runs)
+	assert.Equal(t, 20*time.Second, stats[0].DurationMax)
+	assert.Equal(t, 1, stats[1].Runs)
+}
+
+func testTaskStats_ReturnsErrNotExist(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	_, err := client.TaskStats(ctx, nil)
+	assert.Equal(t, storage.ErrNotExist, err)
+
+	_, err = client.OutputSizeTrend(ctx, nil, time.Hour)
+	assert.Equal(t, storage.ErrNotExist, err)
+}
+
+func testOutputSizeTrend(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	runs := []*storage.TaskRunFull{
+		{
+			TaskRun: storage.TaskRun{
+				StartTimestamp: start,
+			},
+			Outputs: []*storage.Output{
+				{
+					Name:      "binary",
+					Type:      storage.ArtifactTypeFile,
+					Digest:    "1",
+					SizeBytes: 100,
+					Uploads: []*storage.Upload{
+						{
+							URI:                  "s3://bucket/binary",
+							UploadStartTimestamp: start,
+							UploadStopTimestamp:  start.Add(time.Second),
+							Method:               storage.UploadMethodS3,
+						},
+						{
+							URI:                  "file:///tmp/binary",
+							UploadStartTimestamp: start,
+							UploadStopTimestamp:  start.Add(time.Second),
+							Method:               storage.UploadMethodFileCopy,
+						},
+					},
+				},
+			},
+		},
+		{
+			TaskRun: storage.TaskRun{
+				StartTimestamp: start.Add(10 * time.Minute),
+			},
+			Outputs: []*storage.Output{
+				{
+					Name:      "binary",
+					Type:      storage.ArtifactTypeFile,
+					Digest:    "2",
+					SizeBytes: 50,
+					Uploads: []*storage.Upload{
+						{
+							URI:                  "s3://bucket/binary",
+							UploadStartTimestamp: start,
+							UploadStopTimestamp:  start.Add(time.Second),
+							Method:               storage.UploadMethodS3,
+						},
+					},
+				},
+			},
+		},
+		{
+			TaskRun: storage.TaskRun{
+				StartTimestamp: start.Add(2*time.Hour + time.Minute),
+			},
+		},
+	}
+
+	for _, run := range runs {
+		run.ApplicationName = "baurHimself"
+		run.TaskName = "build"
+		run.StopTimestamp = run.StartTimestamp.Add(time.Second)
+		run.Result = storage.ResultSuccess
+		run.TotalInputDigest = "1"
+		run.Inputs = []*storage.Input{{URI: "main.go", Digest: "1"}}
+
+		_, err := client.SaveTaskRun(ctx, run)
+		require.NoError(t, err)
+	}
+
+	buckets, err := client.OutputSizeTrend(ctx, nil, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, buckets, 2)
+
+	assert.True(t, start.Equal(buckets[0].Start), "unexpected start of first bucket: %s", buckets[0].Start)
+	assert.Equal(t, 2, buckets[0].Runs)
+	assert.Equal(t, uint64(150), buckets[0].OutputSizeBytes)
+
+	assert.True(t, start.Add(2*time.Hour).Equal(buckets[1].Start), "unexpected start of second bucket: %s", buckets[1].Start)
+	assert.Equal(t, 1, buckets[1].Runs)
+	assert.Equal(t, uint64(0), buckets[1].OutputSizeBytes)
+}
+
+// newTransferTestRuns returns task runs with inputs and outputs, that have
+// timestamps with a precision that all storages support.
+func newTransferTestRuns() []*storage.TaskRunFull {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.Local)
+
+	return []*storage.TaskRunFull{
+		{
+			TaskRun: storage.TaskRun{
+				ApplicationName:    "baurHimself",
+				TaskName:           "build",
+				VCSRevision:        "abc",
+				StartTimestamp:     start,
+				StopTimestamp:      start.Add(time.Minute),
+				Result:             storage.ResultSuccess,
+				TotalInputDigest:   "1",
+				InputDigestVersion: 2,
+				Command:            []string{"make"},
+			},
+			Inputs: []*storage.Input{{URI: "main.go", Digest: "10"}},
+			Outputs: []*storage.Output{
+				{
+					Name:      "binary",
+					Type:      storage.ArtifactTypeFile,
+					Digest:    "100",
+					SizeBytes: 10,
+					Uploads: []*storage.Upload{
+						{
+							URI:                  "s3://bucket/binary",
+							UploadStartTimestamp: start.Add(time.Minute),
+							UploadStopTimestamp:  start.Add(time.Minute + time.Second),
+							Method:               storage.UploadMethodS3,
+						},
+					},
+				},
+			},
+		},
+		{
+			TaskRun: storage.TaskRun{
+				ApplicationName:    "baurHimself",
+				TaskName:           "check",
+				VCSRevision:        "abc",
+				StartTimestamp:     start.Add(time.Hour),
+				StopTimestamp:      start.Add(time.Hour + time.Minute),
+				Result:             storage.ResultFailure,
+				ExitCode:           1,
+				TotalInputDigest:   "2",
+				InputDigestVersion: storage.LegacyInputDigestVersion,
+				Command:            []string{"make", "check"},
+			},
+			Inputs: []*storage.Input{{URI: "main_test.go", Digest: "20"}},
+		},
+	}
+}
+
+func queryTaskRunsFull(t *testing.T, client storage.Storer, filters []*storage.Filter) []*storage.TaskRunFull {
+	var result []*storage.TaskRunFull
+
+	err := client.TaskRunsFull(
+		ctx,
+		filters,
+		func(run *storage.TaskRunFull) error {
+			result = append(result, run)
+			return nil
+		},
+	)
+	require.NoError(t, err)
+
+	return result
+}
+
+func testSaveTaskRunsAndTaskRunsFull(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	runs := newTransferTestRuns()
+
+	ids, err := client.SaveTaskRuns(ctx, runs)
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	assert.NotEqual(t, ids[0], ids[1])
+
+	run, err := client.TaskRun(ctx, ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, "check", run.TaskName)
+
+	assert.Equal(t, runs, queryTaskRunsFull(t, client, nil))
+
+	assert.Equal(t, runs[1:], queryTaskRunsFull(t, client, []*storage.Filter{
+		{Field: storage.FieldTaskName, Operator: storage.OpEQ, Value: "check"},
+	}))
+
+	err = client.TaskRunsFull(ctx,
+		[]*storage.Filter{{Field: storage.FieldTaskName, Operator: storage.OpEQ, Value: "none"}},
+		func(*storage.TaskRunFull) error { return nil },
+	)
+	assert.Equal(t, storage.ErrNotExist, err)
+}
+
+func testTaskRunsFull_Order(t *testing.T, newStorer NewStorerFn) {
+	// more runs than storages query at once, runs with the same start
+	// timestamp are spread over multiple pages
+	const runCnt = 2500
+
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.Local)
+	runs := make([]*storage.TaskRunFull, 0, runCnt)
+
+	for i := 0; i < runCnt; i++ {
+		runs = append(runs, &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:    "baurHimself",
+				TaskName:           "build",
+				StartTimestamp:     start.Add(time.Duration(2-i%3) * time.Minute),
+				StopTimestamp:      start.Add(time.Hour),
+				Result:             storage.ResultSuccess,
+				TotalInputDigest:   strconv.Itoa(i),
+				InputDigestVersion: 2,
+			},
+			Inputs: []*storage.Input{{URI: strconv.Itoa(i) + ".go", Digest: "1"}},
+		})
+	}
+
+	ids, err := client.SaveTaskRuns(ctx, runs)
+	require.NoError(t, err)
+
+	idsByDigest := make(map[string]int, runCnt)
+	for i, run := range runs {
+		idsByDigest[run.TotalInputDigest] = ids[i]
+	}
+
+	result := queryTaskRunsFull(t, client, nil)
+	require.Len(t, result, runCnt)
+
+	seen := make(map[string]struct{}, runCnt)
+	for _, run := range result {
+		seen[run.TotalInputDigest] = struct{}{}
+
+		require.Len(t, run.Inputs, 1)
+		assert.Equal(t, run.TotalInputDigest+".go", run.Inputs[0].URI)
+	}
+	assert.Len(t, seen, runCnt)
+
+	assert.True(t, sort.SliceIsSorted(result, func(i, j int) bool {
+		if !result[i].StartTimestamp.Equal(result[j].StartTimestamp) {
+			return result[i].StartTimestamp.Before(result[j].StartTimestamp)
+		}
+
+		return idsByDigest[result[i].TotalInputDigest] < idsByDigest[result[j].TotalInputDigest]
+	}), "task runs are not ordered by start timestamp and id")
+}
+
+func testExportImport(t *testing.T, newStorer NewStorerFn) {
+	var buf bytes.Buffer
+
+	src, cleanupSrc := newStorer(t)
+	defer cleanupSrc()
+	require.NoError(t, src.Init(ctx))
+
+	dest, cleanupDest := newStorer(t)
+	defer cleanupDest()
+	require.NoError(t, dest.Init(ctx))
+
+	runs := newTransferTestRuns()
+
+	_, err := src.SaveTaskRuns(ctx, runs)
+	require.NoError(t, err)
+
+	var progressCalls int
+	exported, err := storage.Export(ctx, src, &buf, nil, func(int) { progressCalls++ })
+	require.NoError(t, err)
+	assert.Equal(t, 2, exported)
+	assert.Equal(t, 2, progressCalls)
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+
+	export := buf.String()
+
+	progress, err := storage.Import(ctx, dest, strings.NewReader(export), nil)
+	require.NoError(t, err)
+	assert.Equal(t, &storage.ImportProgress{Read: 2, Imported: 2}, progress)
+
+	assert.Equal(t, runs, queryTaskRunsFull(t, dest, nil))
+
+	progress, err = storage.Import(ctx, dest, strings.NewReader(export+export), nil)
+	require.NoError(t, err)
+	assert.Equal(t, &storage.ImportProgress{Read: 4, Skipped: 4}, progress)
+
+	assert.Len(t, queryTaskRunsFull(t, dest, nil), 2)
+}
+
+func testImport_RejectsUnsupportedVersion(t *testing.T, newStorer NewStorerFn) {
+	client, cleanupFn := newStorer(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	_, err := storage.Import(ctx, client, strings.NewReader(`{"version": 99, "task_run": {}}`), nil)
+	assert.Error(t, err)
+
+	_, err = storage.Export(ctx, client, &bytes.Buffer{}, nil, nil)
+	assert.Equal(t, storage.ErrNotExist, err)
+}
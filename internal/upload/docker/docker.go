@@ -3,7 +3,9 @@ package docker
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
+	"strings"
 
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/pkg/errors"
@@ -170,3 +172,62 @@ func (c *Client) Exists(imageID string) (bool, error) {
 
 	return err != docker.ErrNoSuchImage, nil
 }
+
+// registryFromRepository returns the registry address that is part of a
+// repository name. If the repository does not contain a registry address, an
+// empty string is returned.
+func registryFromRepository(repository string) string {
+	spl := strings.SplitN(repository, "/", 2)
+	if len(spl) < 2 {
+		return ""
+	}
+
+	if spl[0] == "localhost" || strings.ContainsAny(spl[0], ".:") {
+		return spl[0]
+	}
+
+	return ""
+}
+
+// Pull downloads an image from a docker registry and returns its ID.
+// image must be in the format returned by Upload():
+// [registry/]repository:tag
+func (c *Client) Pull(image string) (string, error) {
+	repository, tag := docker.ParseRepositoryTag(image)
+	if tag == "" {
+		return "", fmt.Errorf("image %q does not contain a tag", image)
+	}
+
+	auth := c.getAuth(registryFromRepository(repository))
+
+	var outBuf bytes.Buffer
+	outStream := bufio.NewWriter(&outBuf)
+
+	c.debugLogFn("docker: pulling image, name: %q, tag: %q", repository, tag)
+	err := c.clt.PullImage(docker.PullImageOptions{
+		Repository:   repository,
+		Tag:          tag,
+		OutputStream: outStream,
+	}, auth)
+
+	for {
+		outStream.Flush()
+		line, err := outBuf.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+
+		c.debugLogFn("docker: " + line)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	img, err := c.clt.InspectImage(repository + ":" + tag)
+	if err != nil {
+		return "", errors.Wrapf(err, "inspecting pulled image failed")
+	}
+
+	return img.ID, nil
+}
@@ -69,3 +69,22 @@ func TestGetAuth(t *testing.T) {
 	assert.Equal(t, auth.Password, defRegistryPasswd)
 	assert.Equal(t, auth.Username, defRegistryUser)
 }
+
+func TestRegistryFromRepository(t *testing.T) {
+	testcases := []struct {
+		repository string
+		registry   string
+	}{
+		{repository: "baur", registry: ""},
+		{repository: "simplesurance/baur", registry: ""},
+		{repository: "eu.gcr.io/simplesurance/baur", registry: "eu.gcr.io"},
+		{repository: "localhost:5000/baur", registry: "localhost:5000"},
+		{repository: "localhost/baur", registry: "localhost"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.repository, func(t *testing.T) {
+			assert.Equal(t, tc.registry, registryFromRepository(tc.repository))
+		})
+	}
+}
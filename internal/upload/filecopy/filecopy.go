@@ -110,3 +110,11 @@ func (c *Client) Upload(src string, dst string) (string, error) {
 
 	return dst, copyFile(src, dst)
 }
+
+// Download copies the file from the src path to the dst path.
+// src is usually the destination path of a previous Upload() operation.
+// Directories and existing files are handled like by Upload().
+func (c *Client) Download(src string, dst string) error {
+	_, err := c.Upload(src, dst)
+	return err
+}
@@ -1,17 +1,25 @@
 package s3
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
-// Client is a S3 uploader client
+// Client is a S3 uploader and downloader client
 type Client struct {
-	sess     *session.Session
-	uploader *s3manager.Uploader
+	sess       *session.Session
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
 }
 
 // Logger defines the interface for an S3 logger
@@ -46,7 +54,8 @@ func NewClient(logger Logger) (*Client, error) {
 	}
 
 	return &Client{sess: sess,
-		uploader: s3manager.NewUploader(sess),
+		uploader:   s3manager.NewUploader(sess),
+		downloader: s3manager.NewDownloader(sess),
 	}, nil
 }
 
@@ -72,3 +81,87 @@ func (c *Client) Upload(filepath, bucket, key string) (string, error) {
 
 	return res.Location, err
 }
+
+// ParseURL returns the bucket and key of an object URL.
+// Supported are URLs in the format s3://<bucket>/<key> and path-style URLs
+// like they are returned by Upload, in the format
+// http(s)://<endpoint>/<bucket>/<key>.
+func ParseURL(objectURL string) (bucket, key string, err error) {
+	u, err := url.Parse(objectURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch u.Scheme {
+	case "s3":
+		bucket = u.Host
+		key = strings.TrimPrefix(u.Path, "/")
+
+	case "http", "https":
+		spl := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+		if len(spl) == 2 {
+			bucket, key = spl[0], spl[1]
+		}
+
+	default:
+		return "", "", fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+
+	if bucket == "" || key == "" {
+		return "", "", errors.New("URL does not contain a bucket and key")
+	}
+
+	return bucket, key, nil
+}
+
+// Download downloads the object with the URL objectURL to the file dest.
+// The supported URL formats are described at ParseURL().
+// The object is downloaded to a temporary file in the directory of dest
+// that is renamed to dest on success, dest is overwritten if it exists.
+// Missing parent directories of dest are created.
+func (c *Client) Download(objectURL, dest string) error {
+	bucket, key, err := ParseURL(objectURL)
+	if err != nil {
+		return fmt.Errorf("parsing url %q failed: %w", objectURL, err)
+	}
+
+	destDir := filepath.Dir(dest)
+	err = os.MkdirAll(destDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(destDir, "."+filepath.Base(dest)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = c.downloader.Download(f, &awss3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	// TempFile creates files with mode 0600, use the same mode that a
+	// newly created file would have
+	err = os.Chmod(f.Name(), 0644)
+	if err != nil {
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	return os.Rename(f.Name(), dest)
+}
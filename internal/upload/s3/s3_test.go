@@ -0,0 +1,43 @@
+package s3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	testcases := []struct {
+		url    string
+		bucket string
+		key    string
+	}{
+		{url: "s3://artifacts/calc/calc-1.tar.xz", bucket: "artifacts", key: "calc/calc-1.tar.xz"},
+		{url: "https://s3.eu-central-1.amazonaws.com/artifacts/calc-1.tar.xz", bucket: "artifacts", key: "calc-1.tar.xz"},
+		{url: "http://localhost:9000/artifacts/dir/calc%201.tar.xz", bucket: "artifacts", key: "dir/calc 1.tar.xz"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.url, func(t *testing.T) {
+			bucket, key, err := ParseURL(tc.url)
+			require.NoError(t, err)
+			assert.Equal(t, tc.bucket, bucket)
+			assert.Equal(t, tc.key, key)
+		})
+	}
+}
+
+func TestParseURLFailsOnInvalidURLs(t *testing.T) {
+	for _, url := range []string{
+		"ftp://artifacts/calc.tar.xz",
+		"s3://artifacts",
+		"https://s3.amazonaws.com/artifacts",
+		"/tmp/calc.tar.xz",
+	} {
+		t.Run(url, func(t *testing.T) {
+			_, _, err := ParseURL(url)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -76,7 +76,23 @@ func splitSpecifiers(specifiers []string) (names, cfgPaths []string, star bool)
 //   - '*'
 // If no specifier is passed all tasks of all apps are returned.
 // If multiple specifiers match the same task, it's only returned 1x in the returned slice.
+// The tasks that the returned tasks transitively depend on are loaded to
+// validate the dependencies, an error is returned if a dependency does not
+// exist or the dependencies form a cycle.
 func (a *Loader) LoadTasks(specifier ...string) ([]*Task, error) {
+	result, err := a.loadTasks(specifier...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := a.loadDependencies(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (a *Loader) loadTasks(specifier ...string) ([]*Task, error) {
 	var result []*Task
 
 	if len(specifier) == 0 {
@@ -112,6 +128,76 @@ func (a *Loader) LoadTasks(specifier ...string) ([]*Task, error) {
 	return result, nil
 }
 
+// LoadDependencies loads all tasks that the passed tasks transitively depend
+// on. It returns the passed tasks together with their dependencies, sorted by
+// SortTasksByDependencies().
+// An error is returned if a dependency does not exist or the dependencies
+// form a cycle.
+func (a *Loader) LoadDependencies(tasks []*Task) ([]*Task, error) {
+	loaded, err := a.loadDependencies(tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Task, 0, len(loaded))
+	for _, task := range loaded {
+		result = append(result, task)
+	}
+
+	return SortTasksByDependencies(result)
+}
+
+// loadDependencies returns the passed tasks and all tasks that they
+// transitively depend on by their IDs.
+// An error is returned if a dependency does not exist or the dependencies
+// form a cycle.
+func (a *Loader) loadDependencies(tasks []*Task) (map[string]*Task, error) {
+	loaded := make(map[string]*Task, len(tasks))
+	queue := make([]*Task, 0, len(tasks))
+
+	for _, task := range tasks {
+		if _, exist := loaded[task.ID()]; exist {
+			continue
+		}
+
+		loaded[task.ID()] = task
+		queue = append(queue, task)
+	}
+
+	for len(queue) > 0 {
+		task := queue[0]
+		queue = queue[1:]
+
+		for _, depID := range task.DependsOn {
+			if _, exist := loaded[depID]; exist {
+				continue
+			}
+
+			a.logger.Debugf("loader: loading %s, %s depends on it", depID, task)
+
+			deps, err := a.loadTasks(depID)
+			if err != nil {
+				return nil, fmt.Errorf("%s: loading dependency %s failed: %w", task, depID, err)
+			}
+
+			if len(deps) == 0 {
+				return nil, fmt.Errorf("%s: dependency %s does not exist", task, depID)
+			}
+
+			for _, dep := range deps {
+				loaded[dep.ID()] = dep
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	if err := checkDependencyCycle(loaded); err != nil {
+		return nil, err
+	}
+
+	return loaded, nil
+}
+
 // LoadApps loads the apps that match the passed specifiers.
 // Valid specifiers are:
 // - application directory path
@@ -0,0 +1,142 @@
+package baur
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/cfg"
+	"github.com/simplesurance/baur/v1/internal/log"
+)
+
+// newTestLoader writes a repository config and the app configs to a
+// temporary directory and returns a Loader for it.
+// The tasks of the apps depend on the tasks in dependsOn, it's keys are task
+// IDs.
+func newTestLoader(t *testing.T, dependsOn map[string][]string) *Loader {
+	t.Helper()
+
+	repoDir := t.TempDir()
+
+	repoCfg := cfg.Repository{
+		ConfigVersion: cfg.Version,
+		Discover: cfg.Discover{
+			Dirs:        []string{"."},
+			SearchDepth: 1,
+		},
+	}
+
+	repoCfgPath := filepath.Join(repoDir, RepositoryCfgFile)
+	require.NoError(t, repoCfg.ToFile(repoCfgPath))
+
+	for _, appName := range []string{"app-a", "app-b", "app-c"} {
+		app := cfg.App{Name: appName}
+
+		for _, taskName := range []string{"build", "check"} {
+			app.Tasks = append(app.Tasks, &cfg.Task{
+				Name:      taskName,
+				Command:   []string{"true"},
+				DependsOn: dependsOn[appName+"."+taskName],
+				Input: cfg.Input{
+					Files: []cfg.FileInputs{{Paths: []string{AppCfgFile}}},
+				},
+			})
+		}
+
+		appDir := filepath.Join(repoDir, appName)
+		require.NoError(t, os.Mkdir(appDir, 0755))
+		require.NoError(t, app.ToFile(filepath.Join(appDir, AppCfgFile)))
+	}
+
+	loadedRepoCfg, err := cfg.RepositoryFromFile(repoCfgPath)
+	require.NoError(t, err)
+
+	loader, err := NewLoader(loadedRepoCfg, func() (string, error) { return "", nil }, log.StdLogger)
+	require.NoError(t, err)
+
+	return loader
+}
+
+func TestLoadDependencies(t *testing.T) {
+	loader := newTestLoader(t, map[string][]string{
+		"app-a.build": {"app-b.build"},
+		"app-b.build": {"app-c.check"},
+	})
+
+	tasks, err := loader.LoadTasks("app-a.build")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app-a.build"}, taskIDs(tasks))
+
+	tasks, err = loader.LoadDependencies(tasks)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app-c.check", "app-b.build", "app-a.build"}, taskIDs(tasks))
+}
+
+func TestLoadTasksFailsOnUnknownDependency(t *testing.T) {
+	testcases := []struct {
+		name      string
+		dependsOn []string
+	}{
+		{
+			name:      "unknown_app",
+			dependsOn: []string{"app-z.build"},
+		},
+		{
+			name:      "unknown_task",
+			dependsOn: []string{"app-b.deploy"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			loader := newTestLoader(t, map[string][]string{
+				"app-a.build": tc.dependsOn,
+			})
+
+			_, err := loader.LoadTasks("app-a.build")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.dependsOn[0])
+
+			_, err = loader.LoadTasks("app-a.check")
+			assert.NoError(t, err, "loading a task without dependencies failed")
+		})
+	}
+}
+
+func TestLoadTasksFailsOnDependencyCycle(t *testing.T) {
+	testcases := []struct {
+		name      string
+		dependsOn map[string][]string
+	}{
+		{
+			name: "self",
+			dependsOn: map[string][]string{
+				"app-a.build": {"app-a.build"},
+			},
+		},
+		{
+			name: "transitive",
+			dependsOn: map[string][]string{
+				"app-a.build": {"app-b.build"},
+				"app-b.build": {"app-c.build"},
+				"app-c.build": {"app-a.build"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			loader := newTestLoader(t, tc.dependsOn)
+
+			_, err := loader.LoadTasks("app-a.build")
+			assert.True(t, errors.Is(err, ErrDependencyCycle), "unexpected error: %v", err)
+
+			_, err = loader.LoadTasks()
+			assert.True(t, errors.Is(err, ErrDependencyCycle), "unexpected error: %v", err)
+		})
+	}
+}
@@ -83,9 +83,15 @@ func fileOutputs(task *Task) ([]Output, error) {
 			fileCopyUpload = &UploadInfoFileCopy{DestinationPath: fileOutput.FileCopy.Path}
 		}
 
+		absPath, relPath, err := outputFilePaths(task, fileOutput.Path)
+		if err != nil {
+			return nil, err
+		}
+
 		result = append(result, NewOutputFile(
 			fileOutput.Path,
-			filepath.Join(task.Directory, fileOutput.Path),
+			absPath,
+			relPath,
 			s3Upload,
 			fileCopyUpload,
 		))
@@ -1,6 +1,9 @@
 package baur
 
 import (
+	"fmt"
+	"path/filepath"
+
 	"github.com/simplesurance/baur/v1/internal/digest"
 	"github.com/simplesurance/baur/v1/internal/fs"
 )
@@ -15,15 +18,35 @@ type OutputFile struct {
 	UploadsFilecopy *UploadInfoFileCopy
 }
 
-func NewOutputFile(name, absPath string, s3upload *UploadInfoS3, filecopyUpload *UploadInfoFileCopy) *OutputFile {
+// NewOutputFile returns a new OutputFile.
+// relPath is the path of the file relative to the repository root, it is
+// part of the digest instead of absPath. This makes the digest independent of
+// the location of the repository.
+func NewOutputFile(name, absPath, relPath string, s3upload *UploadInfoS3, filecopyUpload *UploadInfoFileCopy) *OutputFile {
 	return &OutputFile{
-		name:            name,
-		File:            &File{AbsPath: absPath},
+		name: name,
+		File: &File{
+			AbsPath:    absPath,
+			digestPath: filepath.ToSlash(relPath),
+		},
 		UploadsS3:       s3upload,
 		UploadsFilecopy: filecopyUpload,
 	}
 }
 
+// outputFilePaths returns the absolute and the repository relative path of
+// the file output of the task with the given name.
+func outputFilePaths(task *Task, name string) (absPath, relPath string, err error) {
+	absPath = filepath.Join(task.Directory, name)
+
+	relPath, err = filepath.Rel(task.RepositoryRoot, absPath)
+	if err != nil {
+		return "", "", fmt.Errorf("resolving path of %q relative to the repository root failed: %w", absPath, err)
+	}
+
+	return absPath, relPath, nil
+}
+
 func (f *OutputFile) String() string {
 	return "file: " + f.name
 }
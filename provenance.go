@@ -0,0 +1,57 @@
+package baur
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+)
+
+// RunProvenance describes the environment in that tasks are run.
+// It is recorded with task runs, to be able to find out which host, user or
+// CI job produced them.
+type RunProvenance struct {
+	Hostname    string
+	Username    string
+	BaurVersion string
+	// EnvVars contains the names and values of the recorded environment
+	// variables.
+	EnvVars map[string]string
+}
+
+// NewRunProvenance gathers the provenance information of the current
+// process.
+// Of the environment variables in recordEnvVars, the ones that are set are
+// recorded.
+func NewRunProvenance(baurVersion string, recordEnvVars []string) (*RunProvenance, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("retrieving hostname failed: %w", err)
+	}
+
+	envVars := map[string]string{}
+	for _, name := range recordEnvVars {
+		if val, exist := os.LookupEnv(name); exist {
+			envVars[name] = val
+		}
+	}
+
+	return &RunProvenance{
+		Hostname:    hostname,
+		Username:    currentUsername(),
+		BaurVersion: baurVersion,
+		EnvVars:     envVars,
+	}, nil
+}
+
+// currentUsername returns the name of the user that runs the process.
+// If it can not be looked up, e.g. because the user does not exist in the
+// passwd file of a container, the value of the USER environment variable is
+// returned.
+func currentUsername() string {
+	u, err := user.Current()
+	if err == nil {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
@@ -0,0 +1,25 @@
+package baur
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRunProvenanceRecordsOnlySetEnvVars(t *testing.T) {
+	const setVar = "BAUR_TEST_PROVENANCE_JOB_URL"
+	const unsetVar = "BAUR_TEST_PROVENANCE_UNSET"
+
+	require.NoError(t, os.Setenv(setVar, "https://ci.example.com/jobs/1"))
+	defer os.Unsetenv(setVar)
+	require.NoError(t, os.Unsetenv(unsetVar))
+
+	p, err := NewRunProvenance("1.0.0", []string{setVar, unsetVar})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{setVar: "https://ci.example.com/jobs/1"}, p.EnvVars)
+	assert.Equal(t, "1.0.0", p.BaurVersion)
+	assert.NotEmpty(t, p.Hostname)
+}
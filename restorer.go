@@ -0,0 +1,177 @@
+package baur
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/simplesurance/baur/v1/internal/digest"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+type S3Downloader interface {
+	Download(url, dest string) error
+}
+
+type DockerImgDownloader interface {
+	Pull(image string) (string, error)
+}
+
+type FileCopyDownloader interface {
+	Download(src string, dst string) error
+}
+
+// Restorer restores the outputs of recorded task runs from the locations they
+// were uploaded to.
+type Restorer struct {
+	dockerclient       DockerImgDownloader
+	s3client           S3Downloader
+	filecopyDownloader FileCopyDownloader
+}
+
+func NewRestorer(dockerClient DockerImgDownloader, s3client S3Downloader, filecopyDownloader FileCopyDownloader) *Restorer {
+	return &Restorer{
+		dockerclient:       dockerClient,
+		s3client:           s3client,
+		filecopyDownloader: filecopyDownloader,
+	}
+}
+
+// RestoreResult describes a restored output.
+type RestoreResult struct {
+	Output *storage.Output
+	// Upload is the upload record of the location the output was
+	// restored from.
+	Upload *storage.Upload
+	// Path is the path of the restored file, for docker images the path of
+	// the written image ID file.
+	Path string
+}
+
+// uploadMethodPriority defines the order in that the upload locations of a
+// file are tried, copying local files is preferred over downloading from S3.
+var uploadMethodPriority = map[storage.UploadMethod]int{
+	storage.UploadMethodFileCopy:       0,
+	storage.UploadMethodS3:             1,
+	storage.UploadMethodDockerRegistry: 2,
+}
+
+// Restore restores an output of a recorded run of the task.
+// File outputs are downloaded to their declared path in the task directory.
+// Docker images are pulled and their image ID is written to the declared ID
+// file.
+// If an output was uploaded to multiple locations, they are tried one after
+// another until restoring succeeds.
+// The digest of a restored output is compared with the recorded digest,
+// if they differ an error is returned and the restored file is removed.
+func (r *Restorer) Restore(task *Task, output *storage.Output) (*RestoreResult, error) {
+	var errs []string
+
+	if len(output.Uploads) == 0 {
+		return nil, errors.New("no upload of the output was recorded")
+	}
+
+	dest, relPath, err := outputFilePaths(task, output.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads := make([]*storage.Upload, len(output.Uploads))
+	copy(uploads, output.Uploads)
+	sort.SliceStable(uploads, func(i, j int) bool {
+		return uploadMethodPriority[uploads[i].Method] < uploadMethodPriority[uploads[j].Method]
+	})
+
+	for _, upload := range uploads {
+		err := r.restore(output, upload, dest, relPath)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("restoring from %s failed: %s", upload.URI, err))
+			continue
+		}
+
+		return &RestoreResult{
+			Output: output,
+			Upload: upload,
+			Path:   dest,
+		}, nil
+	}
+
+	return nil, errors.New(strings.Join(errs, ", "))
+}
+
+func (r *Restorer) restore(output *storage.Output, upload *storage.Upload, dest, relPath string) error {
+	switch output.Type {
+	case storage.ArtifactTypeFile:
+		return r.restoreFile(output, upload, dest, relPath)
+
+	case storage.ArtifactTypeDocker:
+		return r.restoreDockerImage(output, upload, dest)
+
+	default:
+		return fmt.Errorf("unsupported output type: %q", output.Type)
+	}
+}
+
+func (r *Restorer) restoreFile(output *storage.Output, upload *storage.Upload, dest, relPath string) error {
+	var err error
+
+	switch upload.Method {
+	case storage.UploadMethodFileCopy:
+		err = r.filecopyDownloader.Download(upload.URI, dest)
+
+	case storage.UploadMethodS3:
+		err = r.s3client.Download(upload.URI, dest)
+
+	default:
+		return fmt.Errorf("file outputs can not be restored from uploads with method %q", upload.Method)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	f := NewOutputFile(output.Name, dest, relPath, nil, nil)
+	d, err := f.CalcDigest()
+	if err != nil {
+		return fmt.Errorf("calculating digest of restored file failed: %w", err)
+	}
+
+	if d.String() != output.Digest {
+		_ = os.Remove(dest)
+
+		return fmt.Errorf("digest of restored file %q is %q, expected %q, file was removed", dest, d, output.Digest)
+	}
+
+	return nil
+}
+
+func (r *Restorer) restoreDockerImage(output *storage.Output, upload *storage.Upload, idFile string) error {
+	if upload.Method != storage.UploadMethodDockerRegistry {
+		return fmt.Errorf("docker images can not be restored from uploads with method %q", upload.Method)
+	}
+
+	imageID, err := r.dockerclient.Pull(upload.URI)
+	if err != nil {
+		return err
+	}
+
+	d, err := digest.FromString(imageID)
+	if err != nil {
+		return fmt.Errorf("image id %q of pulled image has an invalid format: %w", imageID, err)
+	}
+
+	if d.String() != output.Digest {
+		return fmt.Errorf("pulled image has id %q, expected %q", d, output.Digest)
+	}
+
+	err = os.MkdirAll(filepath.Dir(idFile), 0755)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(idFile, []byte(imageID), 0644)
+}
@@ -0,0 +1,130 @@
+package baur
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/internal/fs"
+	"github.com/simplesurance/baur/v1/internal/upload/filecopy"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+func outputFileDigest(t *testing.T, task *Task, name string) string {
+	t.Helper()
+
+	absPath, relPath, err := outputFilePaths(task, name)
+	require.NoError(t, err)
+
+	d, err := NewOutputFile(name, absPath, relPath, nil, nil).Digest()
+	require.NoError(t, err)
+
+	return d.String()
+}
+
+func TestRestoreFileFromFileCopy(t *testing.T) {
+	const outputName = "dist/app.tar.xz"
+
+	tempDir := t.TempDir()
+	task := Task{RepositoryRoot: tempDir, Directory: filepath.Join(tempDir, "app")}
+	dest := filepath.Join(task.Directory, outputName)
+	uploadedFile := filepath.Join(tempDir, "artifacts", "app.tar.xz")
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(dest), 0755))
+	require.NoError(t, ioutil.WriteFile(dest, []byte("content"), 0644))
+	expectedDigest := outputFileDigest(t, &task, outputName)
+	require.NoError(t, os.Remove(dest))
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(uploadedFile), 0755))
+	require.NoError(t, ioutil.WriteFile(uploadedFile, []byte("content"), 0644))
+
+	output := storage.Output{
+		Name:   outputName,
+		Type:   storage.ArtifactTypeFile,
+		Digest: expectedDigest,
+		Uploads: []*storage.Upload{
+			{
+				URI:    filepath.Join(tempDir, "artifacts", "missing.tar.xz"),
+				Method: storage.UploadMethodFileCopy,
+			},
+			{
+				URI:    uploadedFile,
+				Method: storage.UploadMethodFileCopy,
+			},
+		},
+	}
+
+	restorer := NewRestorer(nil, nil, filecopy.New(nil))
+
+	result, err := restorer.Restore(&task, &output)
+	require.NoError(t, err)
+
+	assert.Equal(t, dest, result.Path)
+	assert.Equal(t, uploadedFile, result.Upload.URI)
+	assert.Equal(t, expectedDigest, outputFileDigest(t, &task, outputName))
+}
+
+func TestRestoreFileIntoOtherRepositoryLocation(t *testing.T) {
+	const outputName = "dist/app.tar.xz"
+
+	recordRepoDir := t.TempDir()
+	recordTask := Task{RepositoryRoot: recordRepoDir, Directory: filepath.Join(recordRepoDir, "app")}
+	recordedFile := filepath.Join(recordTask.Directory, outputName)
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(recordedFile), 0755))
+	require.NoError(t, ioutil.WriteFile(recordedFile, []byte("content"), 0644))
+
+	output := storage.Output{
+		Name:   outputName,
+		Type:   storage.ArtifactTypeFile,
+		Digest: outputFileDigest(t, &recordTask, outputName),
+		Uploads: []*storage.Upload{
+			{
+				URI:    recordedFile,
+				Method: storage.UploadMethodFileCopy,
+			},
+		},
+	}
+
+	restoreRepoDir := t.TempDir()
+	restoreTask := Task{RepositoryRoot: restoreRepoDir, Directory: filepath.Join(restoreRepoDir, "app")}
+
+	restorer := NewRestorer(nil, nil, filecopy.New(nil))
+
+	result, err := restorer.Restore(&restoreTask, &output)
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(restoreTask.Directory, outputName), result.Path)
+	assert.Equal(t, output.Digest, outputFileDigest(t, &restoreTask, outputName))
+}
+
+func TestRestoreFileFailsOnDigestMismatch(t *testing.T) {
+	tempDir := t.TempDir()
+	task := Task{RepositoryRoot: tempDir, Directory: tempDir}
+	uploadedFile := filepath.Join(tempDir, "uploaded")
+
+	require.NoError(t, ioutil.WriteFile(uploadedFile, []byte("content"), 0644))
+
+	output := storage.Output{
+		Name:   "output",
+		Type:   storage.ArtifactTypeFile,
+		Digest: "sha384:123",
+		Uploads: []*storage.Upload{
+			{
+				URI:    uploadedFile,
+				Method: storage.UploadMethodFileCopy,
+			},
+		},
+	}
+
+	restorer := NewRestorer(nil, nil, filecopy.New(nil))
+
+	_, err := restorer.Restore(&task, &output)
+	require.Error(t, err)
+
+	assert.False(t, fs.FileExists(filepath.Join(tempDir, "output")), "file with mismatching digest was not removed")
+}
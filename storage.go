@@ -9,6 +9,12 @@ import (
 	"github.com/simplesurance/baur/v1/storage"
 )
 
+// MaxTaskRunLogSize is the max. number of bytes of a task run's command output
+// that is stored. Of bigger outputs only the end is stored.
+const MaxTaskRunLogSize = 4 * 1024 * 1024
+
+// StoreRun records the task run and its command output in the storage.
+// If provenance is not nil, it is recorded with the run.
 func StoreRun(
 	ctx context.Context,
 	storer storage.Storer,
@@ -17,6 +23,7 @@ func StoreRun(
 	inputs *Inputs,
 	runResult *RunResult,
 	uploads []*UploadResult,
+	provenance *RunProvenance,
 ) (int, error) {
 	var commitID string
 	var isDirty bool
@@ -32,10 +39,17 @@ func StoreRun(
 	}
 
 	var result storage.Result
-	if runResult.Result.ExitCode == 0 {
+	switch runResult.Status {
+	case RunStatusSuccess:
 		result = storage.ResultSuccess
-	} else {
+	case RunStatusFailure:
 		result = storage.ResultFailure
+	case RunStatusTimeout:
+		result = storage.ResultTimeout
+	case RunStatusCancelled:
+		result = storage.ResultCancelled
+	default:
+		return -1, fmt.Errorf("run has unsupported status: %s", runResult.Status)
 	}
 
 	totalDigest, err := inputs.Digest()
@@ -53,19 +67,38 @@ func StoreRun(
 		return -1, err
 	}
 
+	runLog, err := storage.NewTaskRunLog(runResult.Output, MaxTaskRunLogSize)
+	if err != nil {
+		return -1, fmt.Errorf("compressing command output failed: %w", err)
+	}
+
 	tr := storage.TaskRunFull{
 		TaskRun: storage.TaskRun{
-			ApplicationName:  task.AppName,
-			TaskName:         task.Name,
-			VCSRevision:      commitID,
-			VCSIsDirty:       isDirty,
-			StartTimestamp:   runResult.StartTime,
-			StopTimestamp:    runResult.StopTime,
-			TotalInputDigest: totalDigest.String(),
-			Result:           result,
+			ApplicationName:    task.AppName,
+			TaskName:           task.Name,
+			VCSRevision:        commitID,
+			VCSIsDirty:         isDirty,
+			StartTimestamp:     runResult.StartTime,
+			StopTimestamp:      runResult.StopTime,
+			TotalInputDigest:   totalDigest.String(),
+			InputDigestVersion: InputDigestVersion,
+			Result:             result,
+			ExitCode:           runResult.ExitCode,
+			Command:            task.Command,
 		},
 		Inputs:  storageInputs,
 		Outputs: storageOutputs,
+		Log:     runLog,
+	}
+
+	if provenance != nil {
+		tr.Hostname = provenance.Hostname
+		tr.Username = provenance.Username
+		tr.BaurVersion = provenance.BaurVersion
+
+		if len(provenance.EnvVars) > 0 {
+			tr.EnvVars = provenance.EnvVars
+		}
 	}
 
 	return storer.SaveTaskRun(ctx, &tr)
@@ -0,0 +1,330 @@
+// Package filedb implements a storage.Storer that stores task runs in a
+// single local file.
+// The command outputs of the runs are stored in separate files in a log
+// directory next to it.
+// It is intended for local usage, when no PostgreSQL database is available.
+// Every operation reads the file, concurrent access of multiple processes
+// is synchronized via a lock file.
+package filedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/simplesurance/baur/v1/internal/fs"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// URLScheme is the scheme of URLs that refer to a filedb database file.
+const URLScheme = "file"
+
+// schemaVer is the version of the database file format.
+// Version 2 records the input digest versions of task runs, version 3 stores
+// the command outputs in the log directory instead of the database file.
+// Files with older versions must be upgraded via Client.Upgrade.
+var schemaVer = migrations[len(migrations)-1].Version
+
+// Client is a filedb storage client.
+type Client struct {
+	path     string
+	lockPath string
+	logDir   string
+
+	// mu serializes operations of the client, file locks do not
+	// synchronize access of multiple goroutines of the same process.
+	mu sync.Mutex
+}
+
+// database is the content of a database file.
+type database struct {
+	SchemaVersion int
+	LastID        int
+	TaskRuns      []*taskRunRecord
+}
+
+type taskRunRecord struct {
+	ID int
+	storage.TaskRun
+	Inputs  []*storage.Input
+	Outputs []*storage.Output
+	// LogFile references the file that contains the command output of
+	// the run.
+	LogFile *logRecord `json:",omitempty"`
+	// Log is a command output that was not written to the log directory
+	// yet. It is set for newly recorded runs and for runs in schema
+	// version 2 files, write() moves it to a file.
+	Log *storage.TaskRunLog `json:",omitempty"`
+}
+
+// logRecord references a file in the log directory.
+type logRecord struct {
+	File        string
+	Compression storage.LogCompression
+	Truncated   bool
+}
+
+func (r *taskRunRecord) toTaskRunWithID() *storage.TaskRunWithID {
+	return &storage.TaskRunWithID{
+		ID:      r.ID,
+		TaskRun: r.TaskRun,
+		HasLog:  r.Log != nil || r.LogFile != nil,
+	}
+}
+
+// New returns a client for the database file at path.
+// Command outputs are stored in the directory path + ".logs".
+func New(path string) *Client {
+	return &Client{
+		path:     path,
+		lockPath: path + ".lock",
+		logDir:   path + ".logs",
+	}
+}
+
+// Close does nothing, it always returns a nil error.
+func (c *Client) Close() error {
+	return nil
+}
+
+// withLock locks the database file and calls fn.
+// If exclusive is true an exclusive lock is acquired, otherwise a shared
+// lock.
+func (c *Client) withLock(exclusive bool, fn func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, err := os.OpenFile(c.lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("opening lock file failed: %w", err)
+	}
+	defer f.Close()
+
+	if exclusive {
+		err = fs.LockExclusive(f)
+	} else {
+		err = fs.LockShared(f)
+	}
+	if err != nil {
+		return fmt.Errorf("locking %s failed: %w", c.lockPath, err)
+	}
+
+	defer fs.Unlock(f) //nolint: errcheck
+
+	return fn()
+}
+
+// read reads the database file, the caller must hold the lock.
+// If the file does not have the schema version that baur requires, an
+// error wrapping storage.ErrSchemaOutdated or storage.ErrSchemaTooNew is
+// returned.
+func (c *Client) read() (*database, error) {
+	db, err := c.readAnyVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if db.SchemaVersion < schemaVer {
+		return nil, fmt.Errorf("schema version: %d, required version: %d: %w", db.SchemaVersion, schemaVer, storage.ErrSchemaOutdated)
+	}
+
+	db.timestampsToLocal()
+
+	return db, nil
+}
+
+// readAnyVersion reads the database file, the caller must hold the lock.
+// Only files with a schema version that is newer than schemaVer are
+// rejected.
+func (c *Client) readAnyVersion() (*database, error) {
+	var db database
+
+	content, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("database schema does not exist")
+		}
+
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &db)
+	if err != nil {
+		return nil, fmt.Errorf("parsing database file %s failed: %w", c.path, err)
+	}
+
+	if db.SchemaVersion > schemaVer {
+		return nil, fmt.Errorf("schema version: %d, newest supported version: %d: %w", db.SchemaVersion, schemaVer, storage.ErrSchemaTooNew)
+	}
+
+	return &db, nil
+}
+
+// timestampsToLocal converts all timestamps to local time, like they are
+// returned by other storage implementations.
+func (db *database) timestampsToLocal() {
+	for _, r := range db.TaskRuns {
+		r.StartTimestamp = r.StartTimestamp.Local()
+		r.StopTimestamp = r.StopTimestamp.Local()
+
+		for _, o := range r.Outputs {
+			for _, u := range o.Uploads {
+				u.UploadStartTimestamp = u.UploadStartTimestamp.Local()
+				u.UploadStopTimestamp = u.UploadStopTimestamp.Local()
+			}
+		}
+	}
+}
+
+// write replaces the database file atomically, the caller must hold an
+// exclusive lock.
+// Logs of runs that are not stored in the log directory yet are written to
+// it before the database file is replaced, the database never references
+// log files that do not exist.
+func (c *Client) write(db *database) error {
+	if err := c.writeLogs(db); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(db)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(c.path, content)
+}
+
+// writeLogs writes the logs that are stored in taskRunRecord.Log to files in
+// the log directory and replaces them by references to the files.
+// The files are named by the ID of the run. A file of a run ID that is not
+// referenced, because writing the database file failed, is overwritten
+// when the ID is assigned again.
+func (c *Client) writeLogs(db *database) error {
+	for _, r := range db.TaskRuns {
+		if r.Log == nil {
+			continue
+		}
+
+		if err := os.MkdirAll(c.logDir, 0755); err != nil {
+			return fmt.Errorf("creating log directory failed: %w", err)
+		}
+
+		name := strconv.Itoa(r.ID) + ".log"
+
+		err := writeFileAtomic(filepath.Join(c.logDir, name), r.Log.Data)
+		if err != nil {
+			return fmt.Errorf("writing log of task run with id %d failed: %w", r.ID, err)
+		}
+
+		r.LogFile = &logRecord{
+			File:        name,
+			Compression: r.Log.Compression,
+			Truncated:   r.Log.Truncated,
+		}
+		r.Log = nil
+	}
+
+	return nil
+}
+
+// readLog returns the command output of the run.
+// If no log was stored for the run, storage.ErrNotExist is returned.
+func (c *Client) readLog(r *taskRunRecord) (*storage.TaskRunLog, error) {
+	if r.Log != nil {
+		return r.Log, nil
+	}
+
+	if r.LogFile == nil {
+		return nil, storage.ErrNotExist
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(c.logDir, r.LogFile.File))
+	if err != nil {
+		return nil, fmt.Errorf("reading log of task run with id %d failed: %w", r.ID, err)
+	}
+
+	return &storage.TaskRunLog{
+		Compression: r.LogFile.Compression,
+		Data:        data,
+		Truncated:   r.LogFile.Truncated,
+	}, nil
+}
+
+// writeFileAtomic replaces the file at path with a file that has the given
+// content.
+func writeFileAtomic(path string, content []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(content)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// view calls fn with the content of the database file.
+// fn must not modify the database.
+func (c *Client) view(fn func(*database) error) error {
+	return c.withLock(false, func() error {
+		db, err := c.read()
+		if err != nil {
+			return err
+		}
+
+		return fn(db)
+	})
+}
+
+// update calls fn with the content of the database file and writes it back
+// when fn returns nil.
+func (c *Client) update(fn func(*database) error) error {
+	return c.withLock(true, func() error {
+		db, err := c.read()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(db); err != nil {
+			return err
+		}
+
+		return c.write(db)
+	})
+}
+
+func (db *database) taskRun(id int) *taskRunRecord {
+	for _, r := range db.TaskRuns {
+		if r.ID == id {
+			return r
+		}
+	}
+
+	return nil
+}
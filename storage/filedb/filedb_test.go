@@ -0,0 +1,242 @@
+package filedb
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/internal/testutils/storagetest"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+var ctx = context.Background()
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+
+	return New(filepath.Join(t.TempDir(), "baur.db"))
+}
+
+func TestStorer(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) (storage.Storer, func()) {
+		return newTestClient(t), func() {}
+	})
+}
+
+func TestIsCompatible_AfterInit(t *testing.T) {
+	client := newTestClient(t)
+
+	require.NoError(t, client.Init(ctx))
+	require.NoError(t, client.IsCompatible(ctx))
+}
+
+func TestIsCompatible_SchemaNotExist(t *testing.T) {
+	client := newTestClient(t)
+
+	err := client.IsCompatible(ctx)
+	require.EqualError(t, err, "database schema does not exist")
+}
+
+func TestIsCompatible_SchemaVersionDoesNotMatch(t *testing.T) {
+	client := newTestClient(t)
+
+	require.NoError(t, ioutil.WriteFile(client.path, []byte(`{"SchemaVersion": 100}`), 0644))
+
+	err := client.IsCompatible(ctx)
+	assert.True(t, errors.Is(err, storage.ErrSchemaTooNew))
+}
+
+func TestSchemaV1RunsHaveLegacyInputDigestVersion(t *testing.T) {
+	client := newTestClient(t)
+
+	require.NoError(t, ioutil.WriteFile(client.path, []byte(`{
+		"SchemaVersion": 1,
+		"LastID": 1,
+		"TaskRuns": [{"ID": 1, "ApplicationName": "calc", "TaskName": "build", "Result": "success"}]
+	}`), 0644))
+
+	err := client.IsCompatible(ctx)
+	assert.True(t, errors.Is(err, storage.ErrSchemaOutdated))
+
+	pending, err := client.PendingMigrations(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, 2, pending[0].Version)
+	assert.Equal(t, 3, pending[1].Version)
+
+	applied, err := client.Upgrade(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, pending, applied)
+
+	require.NoError(t, client.IsCompatible(ctx))
+
+	pending, err = client.PendingMigrations(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	run, err := client.TaskRun(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, storage.LegacyInputDigestVersion, run.InputDigestVersion)
+}
+
+func TestOutdatedSchemaIsNotChangedByReads(t *testing.T) {
+	client := newTestClient(t)
+
+	content := []byte(`{"SchemaVersion": 1, "LastID": 1, "TaskRuns": [{"ID": 1, "ApplicationName": "calc", "TaskName": "build", "Result": "success"}]}`)
+	require.NoError(t, ioutil.WriteFile(client.path, content, 0644))
+
+	_, err := client.TaskRun(ctx, 1)
+	assert.True(t, errors.Is(err, storage.ErrSchemaOutdated))
+
+	_, err = client.PendingMigrations(ctx)
+	require.NoError(t, err)
+
+	dbContent, err := ioutil.ReadFile(client.path)
+	require.NoError(t, err)
+	assert.Equal(t, content, dbContent)
+}
+
+func TestLogIsStoredInLogDirectory(t *testing.T) {
+	client := newTestClient(t)
+	require.NoError(t, client.Init(ctx))
+
+	runLog, err := storage.NewTaskRunLog([]byte("building..."), 1024)
+	require.NoError(t, err)
+
+	id, err := client.SaveTaskRun(ctx, &storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "baurHimself",
+			TaskName:         "build",
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now(),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1234567890",
+		},
+		Log: runLog,
+	})
+	require.NoError(t, err)
+
+	dbContent, err := ioutil.ReadFile(client.path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(dbContent), base64.StdEncoding.EncodeToString(runLog.Data))
+
+	logContent, err := ioutil.ReadFile(filepath.Join(client.logDir, strconv.Itoa(id)+".log"))
+	require.NoError(t, err)
+	assert.Equal(t, runLog.Data, logContent)
+
+	storedLog, err := client.TaskRunLog(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, runLog, storedLog)
+}
+
+func TestSchemaV2LogsAreMovedToLogDirectory(t *testing.T) {
+	client := newTestClient(t)
+
+	runLog, err := storage.NewTaskRunLog([]byte("building..."), 1024)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(client.path, []byte(`{
+		"SchemaVersion": 2,
+		"LastID": 1,
+		"TaskRuns": [{
+			"ID": 1, "ApplicationName": "calc", "TaskName": "build", "Result": "success",
+			"Log": {"Compression": "gzip", "Data": "`+base64.StdEncoding.EncodeToString(runLog.Data)+`", "Truncated": false}
+		}]
+	}`), 0644))
+
+	_, err = client.TaskRunLog(ctx, 1)
+	assert.True(t, errors.Is(err, storage.ErrSchemaOutdated))
+
+	_, err = client.Upgrade(ctx)
+	require.NoError(t, err)
+
+	dbContent, err := ioutil.ReadFile(client.path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(dbContent), base64.StdEncoding.EncodeToString(runLog.Data))
+
+	logContent, err := ioutil.ReadFile(filepath.Join(client.logDir, "1.log"))
+	require.NoError(t, err)
+	assert.Equal(t, runLog.Data, logContent)
+
+	storedLog, err := client.TaskRunLog(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, runLog, storedLog)
+
+	run, err := client.TaskRun(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, run.HasLog)
+}
+
+func TestInitFailsWhenDatabaseExist(t *testing.T) {
+	client := newTestClient(t)
+
+	require.NoError(t, client.Init(ctx))
+	require.Error(t, client.Init(ctx))
+}
+
+func TestConcurrentSaveTaskRun(t *testing.T) {
+	const clientCnt = 4
+	const runsPerClient = 10
+
+	var wg sync.WaitGroup
+
+	path := filepath.Join(t.TempDir(), "baur.db")
+	require.NoError(t, New(path).Init(ctx))
+
+	ids := make(chan int, clientCnt*runsPerClient)
+
+	for i := 0; i < clientCnt; i++ {
+		wg.Add(1)
+
+		// every goroutine uses its own client to simulate access by
+		// multiple processes
+		go func() {
+			defer wg.Done()
+
+			client := New(path)
+
+			for j := 0; j < runsPerClient; j++ {
+				id, err := client.SaveTaskRun(ctx, &storage.TaskRunFull{
+					TaskRun: storage.TaskRun{
+						ApplicationName:  "baurHimself",
+						TaskName:         "build",
+						StartTimestamp:   time.Now(),
+						StopTimestamp:    time.Now(),
+						Result:           storage.ResultSuccess,
+						TotalInputDigest: "1234567890",
+					},
+				})
+				assert.NoError(t, err)
+
+				ids <- id
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(ids)
+
+	uniqIDs := map[int]struct{}{}
+	for id := range ids {
+		uniqIDs[id] = struct{}{}
+	}
+
+	assert.Len(t, uniqIDs, clientCnt*runsPerClient)
+
+	var cnt int
+	err := New(path).TaskRuns(ctx, nil, nil, nil, func(*storage.TaskRunWithID) error {
+		cnt++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, clientCnt*runsPerClient, cnt)
+}
@@ -0,0 +1,278 @@
+package filedb
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+func fieldValue(r *taskRunRecord, f storage.Field) (interface{}, error) {
+	switch f {
+	case storage.FieldApplicationName:
+		return r.ApplicationName, nil
+	case storage.FieldTaskName:
+		return r.TaskName, nil
+	case storage.FieldDuration:
+		return r.StopTimestamp.Sub(r.StartTimestamp), nil
+	case storage.FieldStartTime:
+		return r.StartTimestamp, nil
+	case storage.FieldID:
+		return r.ID, nil
+	case storage.FieldTotalInputDigest:
+		return r.TotalInputDigest, nil
+	case storage.FieldResult:
+		return r.Result, nil
+	case storage.FieldVCSRevision:
+		return r.VCSRevision, nil
+	case storage.FieldVCSIsDirty:
+		return r.VCSIsDirty, nil
+
+	default:
+		return nil, fmt.Errorf("no filedb mapping for storage field %s exists", f)
+	}
+}
+
+// compare returns -1 if a is smaller than b, 0 if they are equal and 1 if
+// a is greater than b.
+// a and b must be of the same type.
+func compare(a, b interface{}) (int, error) {
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			break
+		}
+
+		switch {
+		case av < bv:
+			return -1, nil
+		case av > bv:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+
+	case storage.Result:
+		bv, ok := b.(storage.Result)
+		if !ok {
+			break
+		}
+
+		return compare(string(av), string(bv))
+
+	case bool:
+		bv, ok := b.(bool)
+		if !ok {
+			break
+		}
+
+		switch {
+		case av == bv:
+			return 0, nil
+		case bv:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+
+	case int:
+		bv, ok := b.(int)
+		if !ok {
+			break
+		}
+
+		switch {
+		case av < bv:
+			return -1, nil
+		case av > bv:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+
+	case time.Duration:
+		bv, ok := b.(time.Duration)
+		if !ok {
+			break
+		}
+
+		switch {
+		case av < bv:
+			return -1, nil
+		case av > bv:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+
+	case time.Time:
+		bv, ok := b.(time.Time)
+		if !ok {
+			break
+		}
+
+		switch {
+		case av.Before(bv):
+			return -1, nil
+		case av.After(bv):
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	}
+
+	return 0, fmt.Errorf("can not compare value of type %T with value of type %T", a, b)
+}
+
+func matches(r *taskRunRecord, f *storage.Filter) (bool, error) {
+	val, err := fieldValue(r, f.Field)
+	if err != nil {
+		return false, err
+	}
+
+	if f.Operator == storage.OpIN {
+		filterVals := reflect.ValueOf(f.Value)
+		if filterVals.Kind() != reflect.Slice {
+			return false, fmt.Errorf("value of %s filter must be a slice, is %T", f.Operator, f.Value)
+		}
+
+		for i := 0; i < filterVals.Len(); i++ {
+			cmp, err := compare(val, filterVals.Index(i).Interface())
+			if err != nil {
+				return false, err
+			}
+
+			if cmp == 0 {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	if f.Operator == storage.OpPrefix {
+		prefix, ok := f.Value.(string)
+		if !ok {
+			return false, fmt.Errorf("value of %s filter must be a string, is %T", f.Operator, f.Value)
+		}
+
+		str, ok := val.(string)
+		if !ok {
+			return false, fmt.Errorf("%s filter is not supported for field %s", f.Operator, f.Field)
+		}
+
+		return strings.HasPrefix(str, prefix), nil
+	}
+
+	cmp, err := compare(val, f.Value)
+	if err != nil {
+		return false, err
+	}
+
+	switch f.Operator {
+	case storage.OpEQ:
+		return cmp == 0, nil
+	case storage.OpGT:
+		return cmp > 0, nil
+	case storage.OpLT:
+		return cmp < 0, nil
+
+	default:
+		return false, fmt.Errorf("no filedb mapping for storage operator %s exists", f.Operator)
+	}
+}
+
+// filterTaskRuns returns the records that match all filters.
+func filterTaskRuns(runs []*taskRunRecord, filters []*storage.Filter) ([]*taskRunRecord, error) {
+	result := make([]*taskRunRecord, 0, len(runs))
+
+nextRun:
+	for _, r := range runs {
+		for _, f := range filters {
+			match, err := matches(r, f)
+			if err != nil {
+				return nil, err
+			}
+
+			if !match {
+				continue nextRun
+			}
+		}
+
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+// sortTaskRuns sorts runs by the sorters, the first sorter has the highest
+// precedence.
+func sortTaskRuns(runs []*taskRunRecord, sorters []*storage.Sorter) error {
+	var sortErr error
+
+	for _, s := range sorters {
+		if s.Order != storage.OrderAsc && s.Order != storage.OrderDesc {
+			return fmt.Errorf("no filedb mapping for storage order direction %s exists", s.Order)
+		}
+
+		if _, err := fieldValue(&taskRunRecord{}, s.Field); err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		for _, s := range sorters {
+			// errors can only happen for unsupported fields, they
+			// were checked before
+			a, _ := fieldValue(runs[i], s.Field)
+			b, _ := fieldValue(runs[j], s.Field)
+
+			cmp, err := compare(a, b)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+
+			if cmp == 0 {
+				continue
+			}
+
+			if s.Order == storage.OrderDesc {
+				return cmp > 0
+			}
+
+			return cmp < 0
+		}
+
+		return false
+	})
+
+	return sortErr
+}
+
+// paginate returns the records of runs that are in the range described by p.
+func paginate(runs []*taskRunRecord, p *storage.Pagination) ([]*taskRunRecord, error) {
+	if p == nil {
+		return runs, nil
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if p.Offset >= len(runs) {
+		return nil, nil
+	}
+
+	runs = runs[p.Offset:]
+
+	if p.Limit > 0 && p.Limit < len(runs) {
+		runs = runs[:p.Limit]
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,61 @@
+package filedb
+
+import (
+	"context"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// SaveTaskRun stores the task run and returns its ID.
+func (c *Client) SaveTaskRun(_ context.Context, run *storage.TaskRunFull) (int, error) {
+	var id int
+
+	err := c.update(func(db *database) error {
+		db.LastID++
+		id = db.LastID
+
+		db.TaskRuns = append(db.TaskRuns, &taskRunRecord{
+			ID:      id,
+			TaskRun: run.TaskRun,
+			Inputs:  run.Inputs,
+			Outputs: run.Outputs,
+			Log:     run.Log,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// SaveTaskRuns stores the task runs and returns their IDs.
+// The database file is written once for all runs.
+func (c *Client) SaveTaskRuns(_ context.Context, runs []*storage.TaskRunFull) ([]int, error) {
+	ids := make([]int, 0, len(runs))
+
+	err := c.update(func(db *database) error {
+		for _, run := range runs {
+			db.LastID++
+
+			db.TaskRuns = append(db.TaskRuns, &taskRunRecord{
+				ID:      db.LastID,
+				TaskRun: run.TaskRun,
+				Inputs:  run.Inputs,
+				Outputs: run.Outputs,
+				Log:     run.Log,
+			})
+
+			ids = append(ids, db.LastID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
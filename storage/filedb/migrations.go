@@ -0,0 +1,118 @@
+package filedb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// migration upgrades a database file from one schema version to the next.
+type migration struct {
+	storage.Migration
+	apply func(*database)
+}
+
+// migrations must be ordered by their version, the version of the last
+// migration is schemaVer.
+var migrations = []*migration{
+	{
+		Migration: storage.Migration{
+			Version:     2,
+			Description: "record input digest versions of task runs",
+		},
+		// The runs in version 1 databases were all recorded with the
+		// legacy input digest version.
+		apply: func(db *database) {
+			for _, r := range db.TaskRuns {
+				r.InputDigestVersion = storage.LegacyInputDigestVersion
+			}
+		},
+	},
+	{
+		Migration: storage.Migration{
+			Version:     3,
+			Description: "store command outputs in the log directory",
+		},
+		// The logs that version 2 databases stored inline are kept in
+		// taskRunRecord.Log and moved to the log directory by write().
+		apply: func(*database) {},
+	},
+}
+
+func pendingMigrations(currentVer int) []*migration {
+	for i, m := range migrations {
+		if m.Version > currentVer {
+			return migrations[i:]
+		}
+	}
+
+	return nil
+}
+
+func toStorageMigrations(migrations []*migration) []*storage.Migration {
+	result := make([]*storage.Migration, 0, len(migrations))
+
+	for _, m := range migrations {
+		sm := m.Migration
+		result = append(result, &sm)
+	}
+
+	return result
+}
+
+// PendingMigrations returns the migrations that must be applied to upgrade
+// the database file to the schema version that baur requires.
+func (c *Client) PendingMigrations(_ context.Context) ([]*storage.Migration, error) {
+	var result []*storage.Migration
+
+	err := c.withLock(false, func() error {
+		db, err := c.readAnyVersion()
+		if err != nil {
+			return err
+		}
+
+		result = toStorageMigrations(pendingMigrations(db.SchemaVersion))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Upgrade applies all pending migrations and replaces the database file
+// atomically. It returns the applied migrations.
+func (c *Client) Upgrade(_ context.Context) ([]*storage.Migration, error) {
+	var applied []*migration
+
+	err := c.withLock(true, func() error {
+		db, err := c.readAnyVersion()
+		if err != nil {
+			return err
+		}
+
+		applied = pendingMigrations(db.SchemaVersion)
+		if len(applied) == 0 {
+			return nil
+		}
+
+		for _, m := range applied {
+			m.apply(db)
+			db.SchemaVersion = m.Version
+		}
+
+		if err := c.write(db); err != nil {
+			return fmt.Errorf("writing upgraded database file failed: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toStorageMigrations(applied), nil
+}
@@ -0,0 +1,280 @@
+package filedb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// LatestTaskRunByDigest returns the most recent successful run of the task
+// with the given total input digest and input digest version.
+// If no record was found, storage.ErrNotExist is returned.
+func (c *Client) LatestTaskRunByDigest(_ context.Context, appName, taskName, totalInputDigest string, inputDigestVersion int) (*storage.TaskRunWithID, error) {
+	var result *storage.TaskRunWithID
+
+	err := c.view(func(db *database) error {
+		latest := db.latestTaskRunByDigest(appName, taskName, totalInputDigest, inputDigestVersion)
+		if latest == nil {
+			return storage.ErrNotExist
+		}
+
+		result = latest.toTaskRunWithID()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// LatestTaskRunsByDigest returns the most recent successful runs for
+// multiple tasks and total input digests. The database file is only read
+// once.
+func (c *Client) LatestTaskRunsByDigest(_ context.Context, digests []*storage.TaskDigest) ([]*storage.TaskRunWithID, error) {
+	result := make([]*storage.TaskRunWithID, len(digests))
+
+	err := c.view(func(db *database) error {
+		for i, d := range digests {
+			latest := db.latestTaskRunByDigest(d.ApplicationName, d.TaskName, d.TotalInputDigest, d.InputDigestVersion)
+			if latest != nil {
+				result[i] = latest.toTaskRunWithID()
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// latestTaskRunByDigest returns the successful run of the task with the
+// total input digest and input digest version, that finished last. If none
+// exist, nil is returned.
+func (db *database) latestTaskRunByDigest(appName, taskName, totalInputDigest string, inputDigestVersion int) *taskRunRecord {
+	var latest *taskRunRecord
+
+	for _, r := range db.TaskRuns {
+		if r.ApplicationName != appName ||
+			r.TaskName != taskName ||
+			r.TotalInputDigest != totalInputDigest ||
+			r.InputDigestVersion != inputDigestVersion ||
+			r.Result != storage.ResultSuccess {
+			continue
+		}
+
+		if latest == nil || r.StopTimestamp.After(latest.StopTimestamp) {
+			latest = r
+		}
+	}
+
+	return latest
+}
+
+// TaskRun returns the run with the given ID.
+// If no record was found, storage.ErrNotExist is returned.
+func (c *Client) TaskRun(_ context.Context, id int) (*storage.TaskRunWithID, error) {
+	var result *storage.TaskRunWithID
+
+	err := c.view(func(db *database) error {
+		run := db.taskRun(id)
+		if run == nil {
+			return storage.ErrNotExist
+		}
+
+		result = run.toTaskRunWithID()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TaskRuns queries the storage for runs that match the filters.
+// The results are sorted by the sorters, restricted to the range described by
+// pagination and passed one by one to the callback function. The callback function is called after the database file
+// was unlocked.
+// If the callback function returns an error, the iteration stops and the
+// error is returned.
+// If no matching records exist, storage.ErrNotExist is returned.
+func (c *Client) TaskRuns(
+	_ context.Context,
+	filters []*storage.Filter,
+	sorters []*storage.Sorter,
+	pagination *storage.Pagination,
+	cb func(*storage.TaskRunWithID) error,
+) error {
+	var result []*storage.TaskRunWithID
+
+	err := c.view(func(db *database) error {
+		matches, err := filterTaskRuns(db.TaskRuns, filters)
+		if err != nil {
+			return err
+		}
+
+		err = sortTaskRuns(matches, sorters)
+		if err != nil {
+			return err
+		}
+
+		matches, err = paginate(matches, pagination)
+		if err != nil {
+			return err
+		}
+
+		result = make([]*storage.TaskRunWithID, 0, len(matches))
+		for _, r := range matches {
+			result = append(result, r.toTaskRunWithID())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(result) == 0 {
+		return storage.ErrNotExist
+	}
+
+	for _, taskRun := range result {
+		if err := cb(taskRun); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// taskRunsFullSorters is the order in which TaskRunsFull passes task runs to
+// the callback.
+var taskRunsFullSorters = []*storage.Sorter{
+	{Field: storage.FieldStartTime, Order: storage.OrderAsc},
+	{Field: storage.FieldID, Order: storage.OrderAsc},
+}
+
+// TaskRunsFull queries the storage for runs that match the filters and passes
+// them with their inputs and outputs to the callback function, ordered by
+// their start timestamp and ID.
+// The callback function is called after the database file was unlocked.
+// If no matching records exist, storage.ErrNotExist is returned.
+func (c *Client) TaskRunsFull(
+	_ context.Context,
+	filters []*storage.Filter,
+	cb func(*storage.TaskRunFull) error,
+) error {
+	var result []*storage.TaskRunFull
+
+	err := c.view(func(db *database) error {
+		matches, err := filterTaskRuns(db.TaskRuns, filters)
+		if err != nil {
+			return err
+		}
+
+		err = sortTaskRuns(matches, taskRunsFullSorters)
+		if err != nil {
+			return err
+		}
+
+		result = make([]*storage.TaskRunFull, 0, len(matches))
+		for _, r := range matches {
+			result = append(result, &storage.TaskRunFull{
+				TaskRun: r.TaskRun,
+				Inputs:  r.Inputs,
+				Outputs: r.Outputs,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(result) == 0 {
+		return storage.ErrNotExist
+	}
+
+	for _, taskRun := range result {
+		if err := cb(taskRun); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Inputs returns the inputs of a task run.
+// If no inputs were recorded for the run, storage.ErrNotExist is returned.
+func (c *Client) Inputs(_ context.Context, taskRunID int) ([]*storage.Input, error) {
+	var result []*storage.Input
+
+	err := c.view(func(db *database) error {
+		run := db.taskRun(taskRunID)
+		if run == nil || len(run.Inputs) == 0 {
+			return storage.ErrNotExist
+		}
+
+		result = run.Inputs
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Outputs returns the outputs of a task run.
+// If no outputs were recorded for the run, storage.ErrNotExist is returned.
+func (c *Client) Outputs(_ context.Context, taskRunID int) ([]*storage.Output, error) {
+	var result []*storage.Output
+
+	err := c.view(func(db *database) error {
+		run := db.taskRun(taskRunID)
+		if run == nil || len(run.Outputs) == 0 {
+			return storage.ErrNotExist
+		}
+
+		result = run.Outputs
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TaskRunLog returns the command output of a task run.
+// If no log was stored for the run, storage.ErrNotExist is returned.
+func (c *Client) TaskRunLog(_ context.Context, taskRunID int) (*storage.TaskRunLog, error) {
+	var result *storage.TaskRunLog
+
+	err := c.view(func(db *database) error {
+		run := db.taskRun(taskRunID)
+		if run == nil {
+			return storage.ErrNotExist
+		}
+
+		var err error
+		result, err = c.readLog(run)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,31 @@
+package filedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Init creates the database file.
+// If the file already exists an error is returned.
+func (c *Client) Init(_ context.Context) error {
+	return c.withLock(true, func() error {
+		_, err := os.Stat(c.path)
+		if err == nil {
+			return fmt.Errorf("database file %s already exists", c.path)
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return c.write(&database{SchemaVersion: schemaVer})
+	})
+}
+
+// IsCompatible checks if the database file exist and has the required
+// schema version.
+func (c *Client) IsCompatible(_ context.Context) error {
+	return c.view(func(*database) error { return nil })
+}
@@ -0,0 +1,151 @@
+package filedb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// TaskStats returns statistics about the runs that match the filters,
+// aggregated per task.
+// If no matching records exist, storage.ErrNotExist is returned.
+func (c *Client) TaskStats(_ context.Context, filters []*storage.Filter) ([]*storage.TaskStats, error) {
+	type taskKey struct {
+		app  string
+		task string
+	}
+
+	var result []*storage.TaskStats
+
+	err := c.view(func(db *database) error {
+		matches, err := filterTaskRuns(db.TaskRuns, filters)
+		if err != nil {
+			return err
+		}
+
+		durations := map[taskKey][]time.Duration{}
+		stats := map[taskKey]*storage.TaskStats{}
+
+		for _, r := range matches {
+			key := taskKey{app: r.ApplicationName, task: r.TaskName}
+
+			s, exist := stats[key]
+			if !exist {
+				s = &storage.TaskStats{
+					ApplicationName: r.ApplicationName,
+					TaskName:        r.TaskName,
+				}
+				stats[key] = s
+				result = append(result, s)
+			}
+
+			s.Runs++
+			if r.Result == storage.ResultSuccess {
+				s.SuccessfulRuns++
+			}
+
+			durations[key] = append(durations[key], r.StopTimestamp.Sub(r.StartTimestamp))
+		}
+
+		for key, s := range stats {
+			d := durations[key]
+			sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+
+			s.DurationP50 = percentile(d, 0.5)
+			s.DurationP95 = percentile(d, 0.95)
+			s.DurationMax = d[len(d)-1]
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, storage.ErrNotExist
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ApplicationName != result[j].ApplicationName {
+			return result[i].ApplicationName < result[j].ApplicationName
+		}
+
+		return result[i].TaskName < result[j].TaskName
+	})
+
+	return result, nil
+}
+
+// percentile returns the p-th percentile of the sorted durations,
+// interpolated linearly between the closest ranks, like the
+// percentile_cont function of PostgreSQL.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+
+	if lower == upper {
+		return sorted[lower]
+	}
+
+	frac := pos - float64(lower)
+
+	return sorted[lower] + time.Duration(math.Round(frac*float64(sorted[upper]-sorted[lower])))
+}
+
+// OutputSizeTrend returns the accumulated output sizes of the runs that match
+// the filters, grouped by their start time into intervals.
+// If no matching records exist, storage.ErrNotExist is returned.
+func (c *Client) OutputSizeTrend(_ context.Context, filters []*storage.Filter, interval time.Duration) ([]*storage.OutputSizeBucket, error) {
+	var result []*storage.OutputSizeBucket
+
+	if interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+
+	err := c.view(func(db *database) error {
+		matches, err := filterTaskRuns(db.TaskRuns, filters)
+		if err != nil {
+			return err
+		}
+
+		buckets := map[int64]*storage.OutputSizeBucket{}
+
+		for _, r := range matches {
+			start := r.StartTimestamp.UnixNano() / int64(interval) * int64(interval)
+
+			b, exist := buckets[start]
+			if !exist {
+				b = &storage.OutputSizeBucket{Start: time.Unix(0, start)}
+				buckets[start] = b
+				result = append(result, b)
+			}
+
+			b.Runs++
+
+			for _, o := range r.Outputs {
+				b.OutputSizeBytes += o.SizeBytes
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, storage.ErrNotExist
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+
+	return result, nil
+}
@@ -17,6 +17,16 @@ const (
 	FieldDuration
 	FieldStartTime
 	FieldID
+	FieldTotalInputDigest
+	// FieldResult is the result of a run, filter values are of type
+	// Result.
+	FieldResult
+	// FieldVCSRevision is the VCS revision of a run, filter values are
+	// strings.
+	FieldVCSRevision
+	// FieldVCSIsDirty is the dirty flag of the VCS worktree of a run,
+	// filter values are booleans.
+	FieldVCSIsDirty
 )
 
 func (f Field) String() string {
@@ -31,6 +41,14 @@ func (f Field) String() string {
 		return "FieldStartTime"
 	case FieldID:
 		return "FieldID"
+	case FieldTotalInputDigest:
+		return "FieldTotalInputDigest"
+	case FieldResult:
+		return "FieldResult"
+	case FieldVCSRevision:
+		return "FieldVCSRevision"
+	case FieldVCSIsDirty:
+		return "FieldVCSIsDirty"
 	default:
 		return "FieldUndefined"
 	}
@@ -56,6 +74,9 @@ const (
 	// OpIN represents a In operator, works like the SQL IN operator, the
 	// corresponding Value field in The filter struct must be a slice
 	OpIN
+	// OpPrefix matches string fields that start with the Value of the
+	// filter.
+	OpPrefix
 )
 
 func (o Op) String() string {
@@ -64,6 +85,12 @@ func (o Op) String() string {
 		return "OpEQ"
 	case OpGT:
 		return "OpGT"
+	case OpLT:
+		return "OpLT"
+	case OpIN:
+		return "OpIN"
+	case OpPrefix:
+		return "OpPrefix"
 	default:
 		return "OpUndefined"
 	}
@@ -114,3 +141,25 @@ type Sorter struct {
 func (s *Sorter) String() string {
 	return fmt.Sprintf("%s-%s", s.Field, s.Order)
 }
+
+// Pagination restricts the records that a query returns.
+// The first Offset records are skipped, of the remaining ones at most Limit
+// records are returned. A Limit of 0 means that the number of records is not
+// limited.
+type Pagination struct {
+	Offset int
+	Limit  int
+}
+
+// Validate returns an error if Offset or Limit are negative.
+func (p *Pagination) Validate() error {
+	if p.Offset < 0 {
+		return errors.New("offset must not be negative")
+	}
+
+	if p.Limit < 0 {
+		return errors.New("limit must not be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package httpstorage provides a server that makes a storage.Storer
+// accessible via an HTTP/JSON API and a storage.Storer implementation that
+// is a client for it.
+package httpstorage
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// apiPathPrefix is prepended to the paths of all API endpoints, it contains
+// the version of the API.
+const apiPathPrefix = "/v1"
+
+const (
+	pathInit       = "/init"
+	pathCompatible = "/compatible"
+	pathTaskRuns   = "/task_runs"
+	pathLatest     = pathTaskRuns + "/latest"
+	pathLatestMany = pathTaskRuns + "/latest_many"
+	pathQuery      = pathTaskRuns + "/query"
+	pathQueryFull  = pathTaskRuns + "/query_full"
+	pathBatch      = pathTaskRuns + "/batch"
+
+	pathStats           = "/stats"
+	pathTaskStats       = pathStats + "/tasks"
+	pathOutputSizeTrend = pathStats + "/output_sizes"
+)
+
+const (
+	taskRunPathInputs  = "inputs"
+	taskRunPathOutputs = "outputs"
+	taskRunPathLog     = "log"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type saveTaskRunResponse struct {
+	ID int `json:"id"`
+}
+
+type saveTaskRunsResponse struct {
+	IDs []int `json:"ids"`
+}
+
+// filter is the wire representation of a storage.Filter.
+// The Value is decoded depending on the Field and Operator.
+type filter struct {
+	Field    storage.Field   `json:"field"`
+	Operator storage.Op      `json:"operator"`
+	Value    json.RawMessage `json:"value"`
+}
+
+type queryRequest struct {
+	Filters    []*filter           `json:"filters"`
+	Sorters    []*storage.Sorter   `json:"sorters"`
+	Pagination *storage.Pagination `json:"pagination,omitempty"`
+}
+
+type taskStatsRequest struct {
+	Filters []*filter `json:"filters"`
+}
+
+type outputSizeTrendRequest struct {
+	Filters  []*filter     `json:"filters"`
+	Interval time.Duration `json:"interval"`
+}
+
+// queryResponseLine is a line in the JSON-lines response of a query.
+// Exactly one of the fields is set. Error is set when the query failed after
+// records were already sent.
+type queryResponseLine struct {
+	TaskRun     *storage.TaskRunWithID `json:"task_run,omitempty"`
+	TaskRunFull *storage.TaskRunFull   `json:"task_run_full,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+func toWireFilters(filters []*storage.Filter) ([]*filter, error) {
+	result := make([]*filter, 0, len(filters))
+
+	for _, f := range filters {
+		val, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("encoding value of %s filter failed: %w", f.Field, err)
+		}
+
+		result = append(result, &filter{
+			Field:    f.Field,
+			Operator: f.Operator,
+			Value:    val,
+		})
+	}
+
+	return result, nil
+}
+
+func fromWireFilters(filters []*filter) ([]*storage.Filter, error) {
+	result := make([]*storage.Filter, 0, len(filters))
+
+	for _, f := range filters {
+		val, err := decodeFilterValue(f)
+		if err != nil {
+			return nil, fmt.Errorf("decoding value of %s filter failed: %w", f.Field, err)
+		}
+
+		result = append(result, &storage.Filter{
+			Field:    f.Field,
+			Operator: f.Operator,
+			Value:    val,
+		})
+	}
+
+	return result, nil
+}
+
+// decodeFilterValue decodes the value of the filter to the type that the
+// storage implementations expect for the field.
+func decodeFilterValue(f *filter) (interface{}, error) {
+	var single interface{}
+	var slice interface{}
+
+	switch f.Field {
+	case storage.FieldApplicationName, storage.FieldTaskName, storage.FieldTotalInputDigest, storage.FieldVCSRevision:
+		single, slice = new(string), new([]string)
+	case storage.FieldResult:
+		single, slice = new(storage.Result), new([]storage.Result)
+	case storage.FieldVCSIsDirty:
+		single, slice = new(bool), new([]bool)
+	case storage.FieldID:
+		single, slice = new(int), new([]int)
+	case storage.FieldStartTime:
+		single, slice = new(time.Time), new([]time.Time)
+	case storage.FieldDuration:
+		single, slice = new(time.Duration), new([]time.Duration)
+	default:
+		return nil, fmt.Errorf("unsupported field %s", f.Field)
+	}
+
+	target := single
+	if f.Operator == storage.OpIN {
+		target = slice
+	}
+
+	if err := json.Unmarshal(f.Value, target); err != nil {
+		return nil, err
+	}
+
+	return reflect.ValueOf(target).Elem().Interface(), nil
+}
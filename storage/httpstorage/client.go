@@ -0,0 +1,445 @@
+package httpstorage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// Client is a storage.Storer implementation that forwards all operations to
+// a Server.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the server reachable at baseURL.
+// baseURL must be an http or https URL. token is sent as bearer token.
+func NewClient(baseURL, token string) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q, must be http or https", u.Scheme)
+	}
+
+	if token == "" {
+		return nil, errors.New("token is empty")
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/") + apiPathPrefix,
+		token:      token,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// Close closes idle connections of the client, it always returns a nil
+// error.
+func (c *Client) Close() error {
+	c.httpClient.CloseIdleConnections()
+
+	return nil
+}
+
+// do sends a request and returns the response if it has a 2xx status code.
+// For other status codes the error that is contained in the body is
+// returned, for 404 responses it wraps storage.ErrNotExist.
+func (c *Client) do(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encoding request body failed: %w", err)
+		}
+
+		reqBody = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+
+	var errResp errorResponse
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(respBody, &errResp); err != nil || errResp.Error == "" {
+		errResp.Error = strings.TrimSpace(string(respBody))
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s %s: %s: %w", method, path, errResp.Error, storage.ErrNotExist)
+	}
+
+	return nil, fmt.Errorf("%s %s: server responded with status %d: %s", method, path, resp.StatusCode, errResp.Error)
+}
+
+// doJSON sends a request and decodes the response body to result.
+// If result is nil, the response body is discarded.
+func (c *Client) doJSON(ctx context.Context, method, path string, body, result interface{}) error {
+	resp, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("%s %s: decoding response failed: %w", method, path, err)
+	}
+
+	return nil
+}
+
+// Init initializes the storage of the server.
+func (c *Client) Init(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodPost, pathInit, nil, nil)
+}
+
+// IsCompatible verifies that the server supports the API version of the
+// client and that the storage of the server is compatible.
+func (c *Client) IsCompatible(ctx context.Context) error {
+	err := c.doJSON(ctx, http.MethodGet, pathCompatible, nil, nil)
+	if errors.Is(err, storage.ErrNotExist) {
+		return fmt.Errorf("server does not support the storage API %s: %w", apiPathPrefix, err)
+	}
+
+	return err
+}
+
+func (c *Client) SaveTaskRun(ctx context.Context, run *storage.TaskRunFull) (int, error) {
+	var resp saveTaskRunResponse
+
+	err := c.doJSON(ctx, http.MethodPost, pathTaskRuns, run, &resp)
+	if err != nil {
+		return -1, err
+	}
+
+	return resp.ID, nil
+}
+
+// SaveTaskRuns stores the runs with a single request.
+// If the encoded runs are bigger than the max. request size of the server,
+// an error is returned without sending a request.
+func (c *Client) SaveTaskRuns(ctx context.Context, runs []*storage.TaskRunFull) ([]int, error) {
+	var resp saveTaskRunsResponse
+
+	body, err := json.Marshal(runs)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request body failed: %w", err)
+	}
+
+	if len(body) > maxRequestBodySize {
+		return nil, fmt.Errorf("encoded task runs are %d bytes, bigger than the max. request size of the server of %d bytes, store them in smaller batches", len(body), maxRequestBodySize)
+	}
+
+	err = c.doJSON(ctx, http.MethodPost, pathBatch, json.RawMessage(body), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.IDs, nil
+}
+
+// notExistToSentinel returns storage.ErrNotExist if err wraps it, otherwise
+// err. It is used for methods that are documented to return ErrNotExist.
+func notExistToSentinel(err error) error {
+	if errors.Is(err, storage.ErrNotExist) {
+		return storage.ErrNotExist
+	}
+
+	return err
+}
+
+func (c *Client) LatestTaskRunByDigest(ctx context.Context, appName, taskName, totalInputDigest string, inputDigestVersion int) (*storage.TaskRunWithID, error) {
+	var run storage.TaskRunWithID
+
+	q := url.Values{}
+	q.Set("app", appName)
+	q.Set("task", taskName)
+	q.Set("digest", totalInputDigest)
+	q.Set("digest_version", strconv.Itoa(inputDigestVersion))
+
+	err := c.doJSON(ctx, http.MethodGet, pathLatest+"?"+q.Encode(), nil, &run)
+	if err != nil {
+		return nil, notExistToSentinel(err)
+	}
+
+	taskRunTimestampsToLocal(&run.TaskRun)
+
+	return &run, nil
+}
+
+func (c *Client) LatestTaskRunsByDigest(ctx context.Context, digests []*storage.TaskDigest) ([]*storage.TaskRunWithID, error) {
+	var runs []*storage.TaskRunWithID
+
+	err := c.doJSON(ctx, http.MethodPost, pathLatestMany, digests, &runs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(runs) != len(digests) {
+		return nil, fmt.Errorf("server returned %d records for %d digests", len(runs), len(digests))
+	}
+
+	for _, run := range runs {
+		if run != nil {
+			taskRunTimestampsToLocal(&run.TaskRun)
+		}
+	}
+
+	return runs, nil
+}
+
+func (c *Client) TaskRun(ctx context.Context, id int) (*storage.TaskRunWithID, error) {
+	var run storage.TaskRunWithID
+
+	err := c.doJSON(ctx, http.MethodGet, taskRunPath(id, ""), nil, &run)
+	if err != nil {
+		return nil, notExistToSentinel(err)
+	}
+
+	taskRunTimestampsToLocal(&run.TaskRun)
+
+	return &run, nil
+}
+
+// TaskRuns queries the server for runs that match the filters.
+// The records are passed to cb while they are received from the server.
+func (c *Client) TaskRuns(
+	ctx context.Context,
+	filters []*storage.Filter,
+	sorters []*storage.Sorter,
+	pagination *storage.Pagination,
+	cb func(*storage.TaskRunWithID) error,
+) error {
+	wireFilters, err := toWireFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	req := queryRequest{
+		Filters:    wireFilters,
+		Sorters:    sorters,
+		Pagination: pagination,
+	}
+
+	return c.query(ctx, pathQuery, &req, func(line *queryResponseLine) error {
+		if line.TaskRun == nil {
+			return errors.New("query response contains an empty record")
+		}
+
+		taskRunTimestampsToLocal(&line.TaskRun.TaskRun)
+
+		if err := cb(line.TaskRun); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// TaskRunsFull queries the server for runs that match the filters, including
+// their inputs and outputs.
+// The records are passed to cb while they are received from the server.
+func (c *Client) TaskRunsFull(
+	ctx context.Context,
+	filters []*storage.Filter,
+	cb func(*storage.TaskRunFull) error,
+) error {
+	wireFilters, err := toWireFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	req := queryRequest{
+		Filters: wireFilters,
+	}
+
+	return c.query(ctx, pathQueryFull, &req, func(line *queryResponseLine) error {
+		if line.TaskRunFull == nil {
+			return errors.New("query response contains an empty record")
+		}
+
+		taskRunTimestampsToLocal(&line.TaskRunFull.TaskRun)
+		outputTimestampsToLocal(line.TaskRunFull.Outputs)
+
+		if err := cb(line.TaskRunFull); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// query sends a query request and passes the lines of the JSON-lines
+// response to lineFn while they are received.
+func (c *Client) query(ctx context.Context, path string, req *queryRequest, lineFn func(*queryResponseLine) error) error {
+	resp, err := c.do(ctx, http.MethodPost, path, req)
+	if err != nil {
+		return notExistToSentinel(err)
+	}
+
+	defer resp.Body.Close()
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, maxRequestBodySize)
+
+	for sc.Scan() {
+		var line queryResponseLine
+
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			return fmt.Errorf("decoding query response failed: %w", err)
+		}
+
+		if line.Error != "" {
+			return fmt.Errorf("query failed on server: %s", line.Error)
+		}
+
+		if err := lineFn(&line); err != nil {
+			return err
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("reading query response failed: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) Inputs(ctx context.Context, taskRunID int) ([]*storage.Input, error) {
+	var inputs []*storage.Input
+
+	err := c.doJSON(ctx, http.MethodGet, taskRunPath(taskRunID, taskRunPathInputs), nil, &inputs)
+	if err != nil {
+		return nil, notExistToSentinel(err)
+	}
+
+	return inputs, nil
+}
+
+func (c *Client) Outputs(ctx context.Context, taskRunID int) ([]*storage.Output, error) {
+	var outputs []*storage.Output
+
+	err := c.doJSON(ctx, http.MethodGet, taskRunPath(taskRunID, taskRunPathOutputs), nil, &outputs)
+	if err != nil {
+		return nil, notExistToSentinel(err)
+	}
+
+	outputTimestampsToLocal(outputs)
+
+	return outputs, nil
+}
+
+func (c *Client) TaskRunLog(ctx context.Context, taskRunID int) (*storage.TaskRunLog, error) {
+	var runLog storage.TaskRunLog
+
+	err := c.doJSON(ctx, http.MethodGet, taskRunPath(taskRunID, taskRunPathLog), nil, &runLog)
+	if err != nil {
+		return nil, notExistToSentinel(err)
+	}
+
+	return &runLog, nil
+}
+
+func (c *Client) TaskStats(ctx context.Context, filters []*storage.Filter) ([]*storage.TaskStats, error) {
+	var stats []*storage.TaskStats
+
+	wireFilters, err := toWireFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.doJSON(ctx, http.MethodPost, pathTaskStats, &taskStatsRequest{Filters: wireFilters}, &stats)
+	if err != nil {
+		return nil, notExistToSentinel(err)
+	}
+
+	return stats, nil
+}
+
+func (c *Client) OutputSizeTrend(ctx context.Context, filters []*storage.Filter, interval time.Duration) ([]*storage.OutputSizeBucket, error) {
+	var buckets []*storage.OutputSizeBucket
+
+	wireFilters, err := toWireFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.doJSON(ctx, http.MethodPost, pathOutputSizeTrend, &outputSizeTrendRequest{
+		Filters:  wireFilters,
+		Interval: interval,
+	}, &buckets)
+	if err != nil {
+		return nil, notExistToSentinel(err)
+	}
+
+	for _, b := range buckets {
+		b.Start = b.Start.Local()
+	}
+
+	return buckets, nil
+}
+
+func taskRunPath(id int, subPath string) string {
+	p := pathTaskRuns + "/" + strconv.Itoa(id)
+	if subPath != "" {
+		p += "/" + subPath
+	}
+
+	return p
+}
+
+// taskRunTimestampsToLocal converts the timestamps to local time, like they
+// are returned by the other storage implementations.
+func taskRunTimestampsToLocal(run *storage.TaskRun) {
+	run.StartTimestamp = run.StartTimestamp.Local()
+	run.StopTimestamp = run.StopTimestamp.Local()
+}
+
+func outputTimestampsToLocal(outputs []*storage.Output) {
+	for _, o := range outputs {
+		for _, u := range o.Uploads {
+			u.UploadStartTimestamp = u.UploadStartTimestamp.Local()
+			u.UploadStopTimestamp = u.UploadStopTimestamp.Local()
+		}
+	}
+}
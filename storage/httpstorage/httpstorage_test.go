@@ -0,0 +1,179 @@
+package httpstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/internal/testutils/storagetest"
+	"github.com/simplesurance/baur/v1/storage"
+	"github.com/simplesurance/baur/v1/storage/filedb"
+)
+
+const testToken = "secret"
+
+var ctx = context.Background()
+
+func newTestClient(t *testing.T, token string) (*Client, func()) {
+	t.Helper()
+
+	backend := filedb.New(filepath.Join(t.TempDir(), "baur.db"))
+
+	srv, err := NewServer(backend, testToken, nil)
+	require.NoError(t, err)
+
+	httpSrv := httptest.NewServer(srv)
+
+	clt, err := NewClient(httpSrv.URL, token)
+	require.NoError(t, err)
+
+	return clt, func() {
+		clt.Close()
+		httpSrv.Close()
+	}
+}
+
+func TestStorer(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) (storage.Storer, func()) {
+		return newTestClient(t, testToken)
+	})
+}
+
+func TestRequestsWithInvalidTokenFail(t *testing.T) {
+	clt, cleanupFn := newTestClient(t, "invalid")
+	defer cleanupFn()
+
+	err := clt.Init(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestIsCompatible_SchemaNotExist(t *testing.T) {
+	clt, cleanupFn := newTestClient(t, testToken)
+	defer cleanupFn()
+
+	err := clt.IsCompatible(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database schema does not exist")
+}
+
+func TestFilterValuesAreDecodedToTheirTypes(t *testing.T) {
+	now := time.Now().Round(0)
+
+	filters := []*storage.Filter{
+		{Field: storage.FieldApplicationName, Operator: storage.OpEQ, Value: "calc"},
+		{Field: storage.FieldTaskName, Operator: storage.OpIN, Value: []string{"build", "check"}},
+		{Field: storage.FieldID, Operator: storage.OpGT, Value: 5},
+		{Field: storage.FieldStartTime, Operator: storage.OpLT, Value: now},
+		{Field: storage.FieldDuration, Operator: storage.OpGT, Value: time.Minute},
+	}
+
+	wireFilters, err := toWireFilters(filters)
+	require.NoError(t, err)
+
+	decoded, err := fromWireFilters(wireFilters)
+	require.NoError(t, err)
+
+	require.Len(t, decoded, len(filters))
+	for i := range filters {
+		assert.Equal(t, filters[i].Field, decoded[i].Field)
+		assert.Equal(t, filters[i].Operator, decoded[i].Operator)
+
+		if expectedTime, ok := filters[i].Value.(time.Time); ok {
+			assert.True(t, expectedTime.Equal(decoded[i].Value.(time.Time)))
+			continue
+		}
+
+		assert.Equal(t, filters[i].Value, decoded[i].Value)
+	}
+}
+
+// largeTaskRuns returns task runs with long input lists, that are together
+// encoded bigger than maxRequestBodySize.
+func largeTaskRuns() []*storage.TaskRunFull {
+	const runCnt = 100
+	const inputsPerRun = 5000
+
+	inputs := make([]*storage.Input, 0, inputsPerRun)
+	for i := 0; i < inputsPerRun; i++ {
+		inputs = append(inputs, &storage.Input{
+			URI:    fmt.Sprintf("src/pkg/file-%06d.go", i),
+			Digest: fmt.Sprintf("sha384:%096d", i),
+		})
+	}
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs := make([]*storage.TaskRunFull, 0, runCnt)
+
+	for i := 0; i < runCnt; i++ {
+		runs = append(runs, &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:    "baurHimself",
+				TaskName:           "build",
+				StartTimestamp:     start.Add(time.Duration(i) * time.Minute),
+				StopTimestamp:      start.Add(time.Duration(i)*time.Minute + time.Second),
+				Result:             storage.ResultSuccess,
+				TotalInputDigest:   fmt.Sprintf("sha384:%096d", i),
+				InputDigestVersion: 2,
+			},
+			Inputs: inputs,
+		})
+	}
+
+	return runs
+}
+
+func TestSaveTaskRunsFailsWhenRequestIsTooBig(t *testing.T) {
+	clt, cleanupFn := newTestClient(t, testToken)
+	defer cleanupFn()
+
+	require.NoError(t, clt.Init(ctx))
+
+	_, err := clt.SaveTaskRuns(ctx, largeTaskRuns())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max. request size")
+}
+
+func TestImportTaskRunsWithLargeInputLists(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	clt, cleanupFn := newTestClient(t, testToken)
+	defer cleanupFn()
+
+	require.NoError(t, clt.Init(ctx))
+
+	runs := largeTaskRuns()
+
+	var buf bytes.Buffer
+	for _, run := range runs {
+		_, err := fmt.Fprintf(&buf, "{\"version\": %d, \"task_run\": ", storage.TransferFormatVersion)
+		require.NoError(t, err)
+
+		require.NoError(t, json.NewEncoder(&buf).Encode(run))
+
+		_, err = buf.WriteString("}\n")
+		require.NoError(t, err)
+	}
+
+	require.Greater(t, buf.Len(), maxRequestBodySize)
+
+	var batches int
+	result, err := storage.Import(ctx, clt, &buf, func(*storage.ImportProgress) { batches++ })
+	require.NoError(t, err)
+	assert.Equal(t, len(runs), result.Imported)
+	assert.Greater(t, batches, 1)
+
+	inputs, err := clt.Inputs(ctx, len(runs))
+	require.NoError(t, err)
+	assert.Len(t, inputs, len(runs[0].Inputs))
+}
@@ -0,0 +1,444 @@
+package httpstorage
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// maxRequestBodySize is the max. size of request bodies that the server
+// accepts.
+const maxRequestBodySize = 64 * 1024 * 1024
+
+// Logger is an interface for logging debug informations
+type Logger interface {
+	Debugf(format string, v ...interface{})
+}
+
+// Server makes a storage.Storer accessible via HTTP.
+// Requests must authenticate with a bearer token in the Authorization
+// header.
+type Server struct {
+	storer storage.Storer
+	token  string
+	logger Logger
+}
+
+// NewServer returns a new server that serves requests with storer.
+// token is the secret that clients must send as bearer token.
+// If logger is nil, logging is disabled.
+func NewServer(storer storage.Storer, token string, logger Logger) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("token is empty")
+	}
+
+	return &Server{
+		storer: storer,
+		token:  token,
+		logger: logger,
+	}, nil
+}
+
+func (s *Server) debugf(format string, v ...interface{}) {
+	if s.logger != nil {
+		s.logger.Debugf(format, v...)
+	}
+}
+
+func (s *Server) authenticated(r *http.Request) bool {
+	const prefix = "Bearer "
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, prefix)), []byte(s.token)) == 1
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.debugf("httpstorage: %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+
+	if !s.authenticated(r) {
+		s.writeError(w, http.StatusUnauthorized, errors.New("missing or invalid authorization token"))
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, apiPathPrefix+"/") {
+		s.writeError(w, http.StatusNotFound, fmt.Errorf("unsupported API version, supported is %s", apiPathPrefix))
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, apiPathPrefix)
+
+	switch {
+	case path == pathInit && r.Method == http.MethodPost:
+		s.init(w, r)
+	case path == pathCompatible && r.Method == http.MethodGet:
+		s.isCompatible(w, r)
+	case path == pathTaskRuns && r.Method == http.MethodPost:
+		s.saveTaskRun(w, r)
+	case path == pathLatest && r.Method == http.MethodGet:
+		s.latestTaskRunByDigest(w, r)
+	case path == pathLatestMany && r.Method == http.MethodPost:
+		s.latestTaskRunsByDigest(w, r)
+	case path == pathQuery && r.Method == http.MethodPost:
+		s.taskRuns(w, r)
+	case path == pathQueryFull && r.Method == http.MethodPost:
+		s.taskRunsFull(w, r)
+	case path == pathBatch && r.Method == http.MethodPost:
+		s.saveTaskRuns(w, r)
+	case path == pathTaskStats && r.Method == http.MethodPost:
+		s.taskStats(w, r)
+	case path == pathOutputSizeTrend && r.Method == http.MethodPost:
+		s.outputSizeTrend(w, r)
+	case strings.HasPrefix(path, pathTaskRuns+"/"):
+		s.serveTaskRun(w, r, strings.TrimPrefix(path, pathTaskRuns+"/"))
+	default:
+		s.writeError(w, http.StatusNotFound, fmt.Errorf("no handler for %s %s exists", r.Method, r.URL.Path))
+	}
+}
+
+// serveTaskRun serves requests for paths in the format
+// /task_runs/<ID>[/<SUBPATH>].
+func (s *Server) serveTaskRun(w http.ResponseWriter, r *http.Request, path string) {
+	var subPath string
+
+	spl := strings.SplitN(path, "/", 2)
+	if len(spl) == 2 {
+		subPath = spl[1]
+	}
+
+	id, err := strconv.Atoi(spl[0])
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid task run id %q", spl[0]))
+		return
+	}
+
+	switch {
+	case subPath == "" && r.Method == http.MethodGet:
+		s.taskRun(w, r, id)
+	case subPath == taskRunPathInputs && r.Method == http.MethodGet:
+		s.inputs(w, r, id)
+	case subPath == taskRunPathOutputs && r.Method == http.MethodGet:
+		s.outputs(w, r, id)
+	case subPath == taskRunPathLog && r.Method == http.MethodGet:
+		s.taskRunLog(w, r, id)
+	default:
+		s.writeError(w, http.StatusNotFound, fmt.Errorf("no handler for %s %s exists", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.debugf("httpstorage: writing response failed: %s", err)
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, err error) {
+	s.debugf("httpstorage: responding with status %d: %s", status, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(&errorResponse{Error: err.Error()}); err != nil {
+		s.debugf("httpstorage: writing response failed: %s", err)
+	}
+}
+
+// writeStorerError responds with the error that a storer method returned,
+// storage.ErrNotExist is sent with status code 404.
+func (s *Server) writeStorerError(w http.ResponseWriter, err error) {
+	if errors.Is(err, storage.ErrNotExist) {
+		s.writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	s.writeError(w, http.StatusInternalServerError, err)
+}
+
+func (s *Server) decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body := http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Errorf("decoding request body failed: %w", err))
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) init(w http.ResponseWriter, r *http.Request) {
+	if err := s.storer.Init(r.Context()); err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) isCompatible(w http.ResponseWriter, r *http.Request) {
+	if err := s.storer.IsCompatible(r.Context()); err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) saveTaskRun(w http.ResponseWriter, r *http.Request) {
+	var run storage.TaskRunFull
+
+	if !s.decodeBody(w, r, &run) {
+		return
+	}
+
+	id, err := s.storer.SaveTaskRun(r.Context(), &run)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, &saveTaskRunResponse{ID: id})
+}
+
+func (s *Server) saveTaskRuns(w http.ResponseWriter, r *http.Request) {
+	var runs []*storage.TaskRunFull
+
+	if !s.decodeBody(w, r, &runs) {
+		return
+	}
+
+	ids, err := s.storer.SaveTaskRuns(r.Context(), runs)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, &saveTaskRunsResponse{IDs: ids})
+}
+
+func (s *Server) latestTaskRunByDigest(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	digestVersion, err := strconv.Atoi(q.Get("digest_version"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid digest_version %q", q.Get("digest_version")))
+		return
+	}
+
+	run, err := s.storer.LatestTaskRunByDigest(r.Context(), q.Get("app"), q.Get("task"), q.Get("digest"), digestVersion)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, run)
+}
+
+func (s *Server) latestTaskRunsByDigest(w http.ResponseWriter, r *http.Request) {
+	var digests []*storage.TaskDigest
+
+	if !s.decodeBody(w, r, &digests) {
+		return
+	}
+
+	runs, err := s.storer.LatestTaskRunsByDigest(r.Context(), digests)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, runs)
+}
+
+func (s *Server) taskRun(w http.ResponseWriter, r *http.Request, id int) {
+	run, err := s.storer.TaskRun(r.Context(), id)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, run)
+}
+
+// taskRuns responds with the matching task runs in JSON-lines format.
+func (s *Server) taskRuns(w http.ResponseWriter, r *http.Request) {
+	var req queryRequest
+
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	filters, err := fromWireFilters(req.Filters)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Pagination != nil {
+		if err := req.Pagination.Validate(); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	s.streamQueryResponse(w, func(emit func(*queryResponseLine) error) error {
+		return s.storer.TaskRuns(r.Context(), filters, req.Sorters, req.Pagination, func(run *storage.TaskRunWithID) error {
+			return emit(&queryResponseLine{TaskRun: run})
+		})
+	})
+}
+
+// taskRunsFull responds with the matching task runs, including their inputs
+// and outputs, in JSON-lines format.
+func (s *Server) taskRunsFull(w http.ResponseWriter, r *http.Request) {
+	var req queryRequest
+
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	filters, err := fromWireFilters(req.Filters)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Pagination != nil {
+		s.writeError(w, http.StatusBadRequest, errors.New("pagination is not supported for full task run queries"))
+		return
+	}
+
+	if len(req.Sorters) != 0 {
+		s.writeError(w, http.StatusBadRequest, errors.New("sorting is not supported for full task run queries, they are ordered by start time"))
+		return
+	}
+
+	s.streamQueryResponse(w, func(emit func(*queryResponseLine) error) error {
+		return s.storer.TaskRunsFull(r.Context(), filters, func(run *storage.TaskRunFull) error {
+			return emit(&queryResponseLine{TaskRunFull: run})
+		})
+	})
+}
+
+// streamQueryResponse runs query and streams the lines that it emits to the
+// client while the storer iterates over the records.
+// If query fails before a line was sent, an error response is sent,
+// otherwise the error is sent as last line.
+func (s *Server) streamQueryResponse(w http.ResponseWriter, query func(emit func(*queryResponseLine) error) error) {
+	var headerWritten bool
+
+	enc := json.NewEncoder(w)
+
+	err := query(func(line *queryResponseLine) error {
+		if !headerWritten {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			headerWritten = true
+		}
+
+		return enc.Encode(line)
+	})
+	if err == nil {
+		return
+	}
+
+	if !headerWritten {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.debugf("httpstorage: query failed after sending records: %s", err)
+
+	if err := enc.Encode(&queryResponseLine{Error: err.Error()}); err != nil {
+		s.debugf("httpstorage: writing response failed: %s", err)
+	}
+}
+
+func (s *Server) taskStats(w http.ResponseWriter, r *http.Request) {
+	var req taskStatsRequest
+
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	filters, err := fromWireFilters(req.Filters)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := s.storer.TaskStats(r.Context(), filters)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, stats)
+}
+
+func (s *Server) outputSizeTrend(w http.ResponseWriter, r *http.Request) {
+	var req outputSizeTrendRequest
+
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	filters, err := fromWireFilters(req.Filters)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Interval <= 0 {
+		s.writeError(w, http.StatusBadRequest, errors.New("interval must be positive"))
+		return
+	}
+
+	buckets, err := s.storer.OutputSizeTrend(r.Context(), filters, req.Interval)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, buckets)
+}
+
+func (s *Server) inputs(w http.ResponseWriter, r *http.Request, id int) {
+	inputs, err := s.storer.Inputs(r.Context(), id)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, inputs)
+}
+
+func (s *Server) outputs(w http.ResponseWriter, r *http.Request, id int) {
+	outputs, err := s.storer.Outputs(r.Context(), id)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, outputs)
+}
+
+func (s *Server) taskRunLog(w http.ResponseWriter, r *http.Request, id int) {
+	runLog, err := s.storer.TaskRunLog(r.Context(), id)
+	if err != nil {
+		s.writeStorerError(w, err)
+		return
+	}
+
+	s.writeJSON(w, runLog)
+}
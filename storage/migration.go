@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSchemaOutdated indicates that the schema of the storage is older than
+// the schema that the baur version requires, it can be upgraded.
+var ErrSchemaOutdated = errors.New("database schema is outdated, it must be upgraded")
+
+// ErrSchemaTooNew indicates that the schema of the storage was created by a
+// newer baur version, this baur version can not use it.
+var ErrSchemaTooNew = errors.New("database schema is newer than supported by this baur version, baur must be updated")
+
+// Migration upgrades a storage schema to a new version.
+type Migration struct {
+	// Version is the schema version after the migration was applied.
+	Version     int
+	Description string
+	// Statement is the storage specific statement that applies the
+	// migration, e.g. SQL.
+	Statement string
+}
+
+// Upgrader is implemented by Storers whose schema can be upgraded.
+type Upgrader interface {
+	// PendingMigrations returns the migrations that upgrade the schema
+	// to the version that is required by the baur version.
+	PendingMigrations(context.Context) ([]*Migration, error)
+	// Upgrade applies all pending migrations and returns them.
+	Upgrade(context.Context) ([]*Migration, error)
+}
@@ -2,17 +2,37 @@ package postgres
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/simplesurance/baur/v1/storage"
 )
 
-// query assembles an SQL-Query described by storage Filters and Sorters
+// query assembles an SQL-Query described by storage Filters, Sorters and
+// Pagination.
+// BaseQuery must be a SELECT statement without a WHERE clause, the filters
+// are applied to it. The result is wrapped in a subquery named "tr" that is
+// sorted and paginated, sorters refer to the column names of BaseQuery.
 type query struct {
-	BaseQuery string
-	Filters   []*storage.Filter
-	Sorters   []*storage.Sorter
+	BaseQuery  string
+	Filters    []*storage.Filter
+	Sorters    []*storage.Sorter
+	Pagination *storage.Pagination
+	// After restricts the result to task runs that are ordered after the
+	// position by start_timestamp and ID, it is used for keyset
+	// pagination.
+	After *keysetPosition
 }
 
+// keysetPosition is the position of a task run in the order of
+// start_timestamp and ID.
+type keysetPosition struct {
+	StartTimestamp time.Time
+	ID             int
+}
+
+// columnName returns the name of the result column of the base query for
+// the field.
 func columnName(f storage.Field) (string, error) {
 	switch f {
 	case storage.FieldApplicationName:
@@ -25,6 +45,47 @@ func columnName(f storage.Field) (string, error) {
 		return "start_timestamp", nil
 	case storage.FieldID:
 		return "task_run_id", nil
+	case storage.FieldTotalInputDigest:
+		return "total_input_digest", nil
+	case storage.FieldResult:
+		return "result", nil
+	case storage.FieldVCSRevision:
+		return "revision", nil
+	case storage.FieldVCSIsDirty:
+		return "dirty", nil
+
+	default:
+		return "", fmt.Errorf("no postgresql mapping for storage field %s exists", f)
+	}
+}
+
+// durationExpr calculates the duration of a task run in nanoseconds.
+// idx_task_run_duration indexes the same expression.
+const durationExpr = "(EXTRACT(EPOCH FROM (task_run.stop_timestamp - task_run.start_timestamp))::bigint * 1000000000)"
+
+// filterExpr returns the expression that is used to filter the base query by
+// the field. The expressions refer to the table columns instead of the
+// result columns, to make use of their indexes.
+func filterExpr(f storage.Field) (string, error) {
+	switch f {
+	case storage.FieldApplicationName:
+		return "application.name", nil
+	case storage.FieldTaskName:
+		return "task.name", nil
+	case storage.FieldDuration:
+		return durationExpr, nil
+	case storage.FieldStartTime:
+		return "task_run.start_timestamp", nil
+	case storage.FieldID:
+		return "task_run.id", nil
+	case storage.FieldTotalInputDigest:
+		return "task_run.total_input_digest", nil
+	case storage.FieldResult:
+		return "task_run.result", nil
+	case storage.FieldVCSRevision:
+		return "vcs.revision", nil
+	case storage.FieldVCSIsDirty:
+		return "vcs.dirty", nil
 
 	default:
 		return "", fmt.Errorf("no postgresql mapping for storage field %s exists", f)
@@ -41,12 +102,30 @@ func compileOp(a string, op storage.Op, b string) (string, error) {
 		return a + " < " + b, nil
 	case storage.OpIN:
 		return fmt.Sprintf("%s = ANY (%s)", a, b), nil
+	case storage.OpPrefix:
+		return a + " LIKE " + b, nil
 
 	default:
 		return "", fmt.Errorf("no postgresql mapping for storage operator %s exists", op)
 	}
 }
 
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filterArg returns the argument for the placeholder of the filter.
+func filterArg(f *storage.Filter) (interface{}, error) {
+	if f.Operator != storage.OpPrefix {
+		return f.Value, nil
+	}
+
+	prefix, ok := f.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("value of %s filter must be a string, is %T", f.Operator, f.Value)
+	}
+
+	return likeEscaper.Replace(prefix) + "%", nil
+}
+
 func compileSortOrder(o storage.Order, column string) (string, error) {
 	switch o {
 	case storage.OrderAsc:
@@ -60,30 +139,38 @@ func compileSortOrder(o storage.Order, column string) (string, error) {
 }
 
 func (q *query) compileFilterStr() (filterStr string, args []interface{}, err error) {
-	if len(q.Filters) == 0 {
-		return
-	}
+	var conditions []string
 
 	for i, f := range q.Filters {
-		column, err := columnName(f.Field)
+		expr, err := filterExpr(f.Field)
 		if err != nil {
 			return "", nil, err
 		}
 
-		opStr, err := compileOp(column, f.Operator, fmt.Sprintf("$%d", i+1))
+		opStr, err := compileOp(expr, f.Operator, fmt.Sprintf("$%d", i+1))
 		if err != nil {
 			return "", nil, err
 		}
 
-		filterStr += opStr
-		args = append(args, f.Value)
-
-		if i+1 < len(q.Filters) {
-			filterStr += " AND "
+		arg, err := filterArg(f)
+		if err != nil {
+			return "", nil, err
 		}
+
+		conditions = append(conditions, opStr)
+		args = append(args, arg)
+	}
+
+	if q.After != nil {
+		args = append(args, q.After.StartTimestamp, q.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(task_run.start_timestamp, task_run.id) > ($%d, $%d)", len(args)-1, len(args)))
 	}
 
-	return "WHERE " + filterStr, args, err
+	if len(conditions) == 0 {
+		return "", nil, nil
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args, nil
 }
 
 func (q *query) compileSorterStr() (string, error) {
@@ -113,12 +200,35 @@ func (q *query) compileSorterStr() (string, error) {
 	return "ORDER BY " + sorterStr, nil
 }
 
-// Compile creates the SQL query string and returns it with the arguments for the query
-func (q *query) Compile() (query string, args []interface{}, err error) {
-	if len(q.Filters) == 0 && len(q.Sorters) == 0 {
-		return q.BaseQuery, nil, nil
+// compilePaginationStr returns the LIMIT and OFFSET clauses, their
+// placeholders are numbered starting at argOffset+1.
+func (q *query) compilePaginationStr(argOffset int) (string, []interface{}, error) {
+	var result string
+	var args []interface{}
+
+	if q.Pagination == nil {
+		return "", nil, nil
+	}
+
+	if err := q.Pagination.Validate(); err != nil {
+		return "", nil, err
 	}
 
+	if q.Pagination.Limit > 0 {
+		args = append(args, q.Pagination.Limit)
+		result = fmt.Sprintf("LIMIT $%d", argOffset+len(args))
+	}
+
+	if q.Pagination.Offset > 0 {
+		args = append(args, q.Pagination.Offset)
+		result = strings.TrimSpace(fmt.Sprintf("%s OFFSET $%d", result, argOffset+len(args)))
+	}
+
+	return result, args, nil
+}
+
+// Compile creates the SQL query string and returns it with the arguments for the query
+func (q *query) Compile() (query string, args []interface{}, err error) {
 	filterStr, args, err := q.compileFilterStr()
 	if err != nil {
 		return "", nil, err
@@ -129,5 +239,12 @@ func (q *query) Compile() (query string, args []interface{}, err error) {
 		return "", nil, err
 	}
 
-	return fmt.Sprintf("%s %s %s", q.BaseQuery, filterStr, orderStr), args, nil
+	paginationStr, paginationArgs, err := q.compilePaginationStr(len(args))
+	if err != nil {
+		return "", nil, err
+	}
+
+	args = append(args, paginationArgs...)
+
+	return fmt.Sprintf("SELECT * FROM (%s %s) tr %s %s", q.BaseQuery, filterStr, orderStr, paginationStr), args, nil
 }
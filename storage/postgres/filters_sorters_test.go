@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+func TestCompileFiltersUseTableColumns(t *testing.T) {
+	q := query{
+		BaseQuery: "SELECT 1",
+		Filters: []*storage.Filter{
+			{Field: storage.FieldResult, Operator: storage.OpIN, Value: []storage.Result{storage.ResultFailure}},
+			{Field: storage.FieldVCSIsDirty, Operator: storage.OpEQ, Value: true},
+			{Field: storage.FieldDuration, Operator: storage.OpGT, Value: time.Minute},
+		},
+	}
+
+	sql, args, err := q.Compile()
+	require.NoError(t, err)
+
+	assert.Contains(t, sql, "task_run.result = ANY ($1) AND vcs.dirty = $2 AND "+durationExpr+" > $3")
+	assert.Equal(t, []interface{}{[]storage.Result{storage.ResultFailure}, true, time.Minute}, args)
+}
+
+func TestCompileKeysetPosition(t *testing.T) {
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	q := query{
+		BaseQuery: "SELECT 1",
+		Filters: []*storage.Filter{
+			{Field: storage.FieldTaskName, Operator: storage.OpEQ, Value: "build"},
+		},
+		After: &keysetPosition{StartTimestamp: ts, ID: 5},
+	}
+
+	sql, args, err := q.Compile()
+	require.NoError(t, err)
+
+	assert.Contains(t, sql, "WHERE task.name = $1 AND (task_run.start_timestamp, task_run.id) > ($2, $3)")
+	assert.Equal(t, []interface{}{"build", ts, 5}, args)
+}
+
+func TestCompilePrefixFilterEscapesWildcards(t *testing.T) {
+	q := query{
+		BaseQuery: "SELECT 1",
+		Filters: []*storage.Filter{
+			{Field: storage.FieldVCSRevision, Operator: storage.OpPrefix, Value: `a_b%c\`},
+		},
+	}
+
+	sql, args, err := q.Compile()
+	require.NoError(t, err)
+
+	assert.Contains(t, sql, "vcs.revision LIKE $1")
+	assert.Equal(t, []interface{}{`a\_b\%c\\%`}, args)
+}
+
+func TestCompilePrefixFilterRequiresString(t *testing.T) {
+	q := query{
+		BaseQuery: "SELECT 1",
+		Filters: []*storage.Filter{
+			{Field: storage.FieldVCSRevision, Operator: storage.OpPrefix, Value: 1},
+		},
+	}
+
+	_, _, err := q.Compile()
+	assert.Error(t, err)
+}
+
+func TestCompilePagination(t *testing.T) {
+	testcases := []struct {
+		name       string
+		pagination *storage.Pagination
+
+		expectedSuffix string
+		expectedArgs   []interface{}
+	}{
+		{
+			name:           "nil",
+			expectedSuffix: "tr",
+		},
+		{
+			name:           "limit",
+			pagination:     &storage.Pagination{Limit: 5},
+			expectedSuffix: "LIMIT $2",
+			expectedArgs:   []interface{}{"app", 5},
+		},
+		{
+			name:           "offset",
+			pagination:     &storage.Pagination{Offset: 3},
+			expectedSuffix: "OFFSET $2",
+			expectedArgs:   []interface{}{"app", 3},
+		},
+		{
+			name:           "limitAndOffset",
+			pagination:     &storage.Pagination{Limit: 5, Offset: 3},
+			expectedSuffix: "LIMIT $2 OFFSET $3",
+			expectedArgs:   []interface{}{"app", 5, 3},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := query{
+				BaseQuery: "SELECT 1",
+				Filters: []*storage.Filter{
+					{Field: storage.FieldApplicationName, Operator: storage.OpEQ, Value: "app"},
+				},
+				Pagination: tc.pagination,
+			}
+
+			sql, args, err := q.Compile()
+			require.NoError(t, err)
+
+			assert.True(t, strings.HasSuffix(strings.TrimSpace(sql), tc.expectedSuffix), "unexpected query: %q", sql)
+
+			if tc.expectedArgs != nil {
+				assert.Equal(t, tc.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestCompileRejectsNegativePagination(t *testing.T) {
+	q := query{
+		BaseQuery:  "SELECT 1",
+		Pagination: &storage.Pagination{Limit: -1},
+	}
+
+	_, _, err := q.Compile()
+	assert.Error(t, err)
+}
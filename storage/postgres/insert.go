@@ -179,21 +179,25 @@ func insertInputIfNotExist(ctx context.Context, db dbConn, inputs []*storage.Inp
 	return ids, nil
 }
 
-func insertTaskRunInputsIfNotExist(ctx context.Context, db dbConn, taskRunID int, taskRun *storage.TaskRunFull) error {
+func insertTaskRunInputsIfNotExist(ctx context.Context, db dbConn, taskRunID int, inputs []*storage.Input) error {
 	const stmt1 = `
-	INSERT INTO task_run_input (task_run_id, total_digest, input_id)
+	INSERT INTO task_run_input (task_run_id, input_id)
 	VALUES
 	`
 
-	inputIDs, err := insertInputIfNotExist(ctx, db, taskRun.Inputs)
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	inputIDs, err := insertInputIfNotExist(ctx, db, inputs)
 	if err != nil {
 		return err
 	}
 
 	var stmtVals strings.Builder
-	argNr := 3
+	argNr := 2
 	for i := 0; i < len(inputIDs); i++ {
-		fmt.Fprintf(&stmtVals, "($1, $2, $%d)", argNr)
+		fmt.Fprintf(&stmtVals, "($1, $%d)", argNr)
 		argNr++
 
 		if i < len(inputIDs)-1 {
@@ -201,9 +205,8 @@ func insertTaskRunInputsIfNotExist(ctx context.Context, db dbConn, taskRunID int
 		}
 	}
 
-	queryArgs := make([]interface{}, 2, (len(inputIDs)*2)+2)
+	queryArgs := make([]interface{}, 1, len(inputIDs)+1)
 	queryArgs[0] = taskRunID
-	queryArgs[1] = taskRun.TotalInputDigest
 
 	for _, inputID := range inputIDs {
 		queryArgs = append(queryArgs, inputID)
@@ -330,10 +333,49 @@ func insertTaskOutputsIfNotExist(ctx context.Context, db dbConn, taskRunID int,
 	return nil
 }
 
+// insertTaskRunLog stores the command output of the task run with the ID
+// taskRunID.
+func insertTaskRunLog(ctx context.Context, db dbConn, taskRunID int, log *storage.TaskRunLog) error {
+	const query = `
+	INSERT INTO task_run_log (task_run_id, compression, truncated, data)
+	VALUES($1, $2, $3, $4)
+	`
+
+	queryArgs := []interface{}{taskRunID, log.Compression, log.Truncated, log.Data}
+
+	_, err := db.Exec(ctx, query, queryArgs...)
+	if err != nil {
+		return newQueryError(query, err, taskRunID, log.Compression, log.Truncated, fmt.Sprintf("<%d bytes>", len(log.Data)))
+	}
+
+	return nil
+}
+
+// nonNilStrSlice returns an empty slice if s is nil, otherwise s.
+// pgx stores nil slices as NULL.
+func nonNilStrSlice(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
+
+// nonNilStrMap returns an empty map if m is nil, otherwise m.
+func nonNilStrMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+
+	return m
+}
+
 func (c *Client) saveTaskRun(ctx context.Context, tx pgx.Tx, taskRun *storage.TaskRunFull) (int, error) {
 	const query = `
-		   INSERT INTO task_run (vcs_id, task_id, start_timestamp, stop_timestamp, result)
-		   VALUES($1, $2, $3, $4, $5)
+		   INSERT INTO task_run (vcs_id, task_id, total_input_digest, input_digest_version, start_timestamp,
+					 stop_timestamp, result, exit_code, hostname, username, baur_version, command,
+					 env_vars)
+		   VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING ID
 		`
 
@@ -352,9 +394,17 @@ func (c *Client) saveTaskRun(ctx context.Context, tx pgx.Tx, taskRun *storage.Ta
 	queryArgs := []interface{}{
 		vcsID,
 		taskID,
+		taskRun.TotalInputDigest,
+		taskRun.InputDigestVersion,
 		taskRun.StartTimestamp,
 		taskRun.StopTimestamp,
 		taskRun.Result,
+		taskRun.ExitCode,
+		taskRun.Hostname,
+		taskRun.Username,
+		taskRun.BaurVersion,
+		nonNilStrSlice(taskRun.Command),
+		nonNilStrMap(taskRun.EnvVars),
 	}
 
 	err = tx.QueryRow(
@@ -366,7 +416,7 @@ func (c *Client) saveTaskRun(ctx context.Context, tx pgx.Tx, taskRun *storage.Ta
 		return -1, newQueryError(query, err, queryArgs...)
 	}
 
-	err = insertTaskRunInputsIfNotExist(ctx, tx, taskRunID, taskRun)
+	err = insertTaskRunInputsIfNotExist(ctx, tx, taskRunID, taskRun.Inputs)
 	if err != nil {
 		return -1, err
 	}
@@ -376,6 +426,13 @@ func (c *Client) saveTaskRun(ctx context.Context, tx pgx.Tx, taskRun *storage.Ta
 		return -1, err
 	}
 
+	if taskRun.Log != nil {
+		err = insertTaskRunLog(ctx, tx, taskRunID, taskRun.Log)
+		if err != nil {
+			return -1, err
+		}
+	}
+
 	return taskRunID, nil
 }
 
@@ -397,3 +454,29 @@ func (c *Client) SaveTaskRun(ctx context.Context, taskRun *storage.TaskRunFull)
 
 	return id, nil
 }
+
+// SaveTaskRuns stores the task runs in a single transaction.
+func (c *Client) SaveTaskRuns(ctx context.Context, taskRuns []*storage.TaskRunFull) ([]int, error) {
+	ids := make([]int, 0, len(taskRuns))
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, taskRun := range taskRuns {
+		id, err := c.saveTaskRun(ctx, tx, taskRun)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// migrations upgrade the database schema from one version to the next.
+// They must be ordered by their version, the version of the last migration
+// is the schema version that baur requires.
+// Existing migrations must never be modified, schema changes are done by
+// appending a new migration.
+var migrations = []*storage.Migration{
+	{
+		Version:     2,
+		Description: "record exit codes of task runs",
+		Statement: `
+ALTER TABLE task_run ADD COLUMN exit_code integer NOT NULL DEFAULT 0;
+ALTER TABLE task_run ALTER COLUMN exit_code DROP DEFAULT;
+`,
+	},
+	{
+		Version:     3,
+		Description: "store command output of task runs",
+		Statement: `
+CREATE TABLE task_run_log (
+	task_run_id integer PRIMARY KEY REFERENCES task_run (id) ON DELETE CASCADE,
+	compression text NOT NULL,
+	truncated boolean NOT NULL,
+	data bytea NOT NULL
+);
+`,
+	},
+	{
+		Version:     4,
+		Description: "add timeout and cancelled task run results",
+		Statement: `
+ALTER TABLE task_run DROP CONSTRAINT result_check;
+ALTER TABLE task_run ADD CONSTRAINT result_check CHECK (result in ('success', 'failure', 'timeout', 'cancelled'));
+`,
+	},
+	{
+		Version:     5,
+		Description: "record provenance of task runs",
+		Statement: `
+ALTER TABLE task_run ADD COLUMN hostname text NOT NULL DEFAULT '';
+ALTER TABLE task_run ADD COLUMN username text NOT NULL DEFAULT '';
+ALTER TABLE task_run ADD COLUMN baur_version text NOT NULL DEFAULT '';
+ALTER TABLE task_run ADD COLUMN command text[] NOT NULL DEFAULT '{}';
+ALTER TABLE task_run ADD COLUMN env_vars jsonb NOT NULL DEFAULT '{}';
+`,
+	},
+	{
+		Version:     6,
+		Description: "add indexes for filtering task runs",
+		Statement: `
+CREATE INDEX idx_task_run_task_id ON task_run(task_id);
+CREATE INDEX idx_task_run_start_timestamp ON task_run(start_timestamp);
+CREATE INDEX idx_task_run_result ON task_run(result);
+CREATE INDEX idx_task_run_duration ON task_run(((EXTRACT(EPOCH FROM (stop_timestamp - start_timestamp))::bigint * 1000000000)));
+CREATE INDEX idx_vcs_revision_prefix ON vcs(revision text_pattern_ops);
+`,
+	},
+	{
+		Version:     7,
+		Description: "store total input digests in the task_run table",
+		Statement: `
+ALTER TABLE task_run ADD COLUMN total_input_digest text;
+
+UPDATE task_run
+   SET total_input_digest = tri.total_digest
+  FROM (SELECT DISTINCT ON (task_run_id) task_run_id, total_digest FROM task_run_input) tri
+ WHERE tri.task_run_id = task_run.id;
+
+UPDATE task_run SET total_input_digest = '' WHERE total_input_digest IS NULL;
+
+ALTER TABLE task_run ALTER COLUMN total_input_digest SET NOT NULL;
+
+CREATE INDEX idx_task_run_task_id_total_input_digest_stop_timestamp ON task_run(task_id, total_input_digest, stop_timestamp);
+DROP INDEX idx_task_run_task_id;
+
+DROP INDEX idx_task_run_input_total_digest;
+ALTER TABLE task_run_input DROP COLUMN total_digest;
+`,
+	},
+	{
+		Version:     8,
+		Description: "record input digest versions of task runs",
+		Statement: `
+ALTER TABLE task_run ADD COLUMN input_digest_version integer NOT NULL DEFAULT 1;
+ALTER TABLE task_run ALTER COLUMN input_digest_version DROP DEFAULT;
+`,
+	},
+}
+
+// schemaVer is the database schema version that baur requires.
+var schemaVer = migrations[len(migrations)-1].Version
+
+// pendingMigrations returns the migrations with a version > currentVer.
+func pendingMigrations(currentVer int, migrations []*storage.Migration) []*storage.Migration {
+	for i, m := range migrations {
+		if m.Version > currentVer {
+			return migrations[i:]
+		}
+	}
+
+	return nil
+}
+
+// applyMigrations applies the migrations with a version > currentVer and
+// updates the version in the migrations table.
+func applyMigrations(ctx context.Context, tx pgx.Tx, currentVer int, migrations []*storage.Migration) error {
+	for _, m := range pendingMigrations(currentVer, migrations) {
+		_, err := tx.Exec(ctx, m.Statement)
+		if err != nil {
+			return fmt.Errorf("applying migration to version %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE migrations SET schema_version = $1", m.Version)
+		if err != nil {
+			return fmt.Errorf("updating schema version to %d failed: %w", m.Version, err)
+		}
+	}
+
+	return nil
+}
+
+// PendingMigrations returns the migrations that must be applied to upgrade
+// the database schema to the version that baur requires.
+func (c *Client) PendingMigrations(ctx context.Context) ([]*storage.Migration, error) {
+	if err := c.v0SchemaNotExits(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := c.schemaExist(ctx); err != nil {
+		return nil, err
+	}
+
+	ver, err := schemaVersion(ctx, c.db)
+	if err != nil {
+		return nil, err
+	}
+
+	if ver > schemaVer {
+		return nil, fmt.Errorf("schema version: %d, newest supported version: %d: %w", ver, schemaVer, storage.ErrSchemaTooNew)
+	}
+
+	return pendingMigrations(ver, migrations), nil
+}
+
+// Upgrade applies all pending migrations in a single transaction.
+// The migrations table is locked while the migrations are applied, to prevent
+// that concurrent upgrades apply the same migrations.
+// It returns the applied migrations.
+func (c *Client) Upgrade(ctx context.Context) ([]*storage.Migration, error) {
+	if err := c.v0SchemaNotExits(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := c.schemaExist(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := upgrade(ctx, tx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+func upgrade(ctx context.Context, tx pgx.Tx) ([]*storage.Migration, error) {
+	_, err := tx.Exec(ctx, "LOCK TABLE migrations IN ACCESS EXCLUSIVE MODE")
+	if err != nil {
+		return nil, fmt.Errorf("locking migrations table failed: %w", err)
+	}
+
+	ver, err := schemaVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if ver > schemaVer {
+		return nil, fmt.Errorf("schema version: %d, newest supported version: %d: %w", ver, schemaVer, storage.ErrSchemaTooNew)
+	}
+
+	if err := applyMigrations(ctx, tx, ver, migrations); err != nil {
+		return nil, err
+	}
+
+	return pendingMigrations(ver, migrations), nil
+}
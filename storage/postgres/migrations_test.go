@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationVersionsAreConsecutive(t *testing.T) {
+	for i, m := range migrations {
+		assert.Equal(t, i+2, m.Version, "migration %d (%s) has unexpected version", i, m.Description)
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	assert.Equal(t, migrations, pendingMigrations(1, migrations))
+	assert.Equal(t, migrations[1:], pendingMigrations(2, migrations))
+	assert.Empty(t, pendingMigrations(schemaVer, migrations))
+}
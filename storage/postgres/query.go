@@ -21,7 +21,7 @@ func (c *Client) TaskRun(ctx context.Context, id int) (*storage.TaskRunWithID, e
 		},
 	}
 
-	err := c.TaskRuns(ctx, idFilter, nil, func(tr *storage.TaskRunWithID) error {
+	err := c.TaskRuns(ctx, idFilter, nil, nil, func(tr *storage.TaskRunWithID) error {
 		taskRun = tr
 
 		return nil
@@ -38,33 +38,41 @@ func (c *Client) TaskRun(ctx context.Context, id int) (*storage.TaskRunWithID, e
 	return taskRun, nil
 }
 
-func (c *Client) LatestTaskRunByDigest(ctx context.Context, appName, taskName, totalInputDigest string) (*storage.TaskRunWithID, error) {
-	// TODO: improve the query, retrieving the newest record via LIMIT should be slow
+func (c *Client) LatestTaskRunByDigest(ctx context.Context, appName, taskName, totalInputDigest string, inputDigestVersion int) (*storage.TaskRunWithID, error) {
 	const query = `
 	SELECT task_run.id,
 	       application.name,
 	       task.name,
 	       vcs.revision,
 	       vcs.dirty,
-	       task_run_input.total_digest,
+	       task_run.total_input_digest,
+	       task_run.input_digest_version,
 	       task_run.start_timestamp,
 	       task_run.stop_timestamp,
-	       task_run.result
+	       task_run.result,
+	       task_run.exit_code,
+	       task_run.hostname,
+	       task_run.username,
+	       task_run.baur_version,
+	       task_run.command,
+	       task_run.env_vars,
+	       EXISTS (SELECT 1 FROM task_run_log WHERE task_run_log.task_run_id = task_run.id)
 	  FROM application
 	  JOIN task ON application.id = task.application_id
 	  JOIN task_run ON task.id = task_run.task_id
-	  JOIN task_run_input ON task_run_input.task_run_id = task_run.id
 	  LEFT OUTER JOIN vcs ON vcs.id = task_run.vcs_id
 	 WHERE application.name = $1
 	   AND task.name = $2
-	   AND task_run_input.total_digest = $3
+	   AND task_run.total_input_digest = $3
+	   AND task_run.input_digest_version = $4
+	   AND task_run.result = 'success'
 	 ORDER BY task_run.stop_timestamp DESC
 	 LIMIT 1
 	 `
 
 	var result storage.TaskRunWithID
 
-	row := c.db.QueryRow(ctx, query, appName, taskName, totalInputDigest)
+	row := c.db.QueryRow(ctx, query, appName, taskName, totalInputDigest, inputDigestVersion)
 
 	err := row.Scan(
 		&result.ID,
@@ -73,21 +81,348 @@ func (c *Client) LatestTaskRunByDigest(ctx context.Context, appName, taskName, t
 		&result.VCSRevision,
 		&result.VCSIsDirty,
 		&result.TotalInputDigest,
+		&result.InputDigestVersion,
 		&result.StartTimestamp,
 		&result.StopTimestamp,
 		&result.Result,
+		&result.ExitCode,
+		&result.Hostname,
+		&result.Username,
+		&result.BaurVersion,
+		&result.Command,
+		&result.EnvVars,
+		&result.HasLog,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrNotExist
 		}
 
-		return nil, fmt.Errorf("query %s with args: %s failed: %w", query, strArgList(appName, taskName, totalInputDigest), err)
+		return nil, fmt.Errorf("query %s with args: %s failed: %w", query, strArgList(appName, taskName, totalInputDigest, inputDigestVersion), err)
 	}
 
+	emptyProvenanceToNil(&result.TaskRun)
+
 	return &result, nil
 }
 
+// LatestTaskRunsByDigest looks up the latest successful runs of all passed
+// tasks with a single query. The digests are passed as arrays and joined
+// laterally with the runs, the lookup per element uses the same index as
+// LatestTaskRunByDigest.
+func (c *Client) LatestTaskRunsByDigest(ctx context.Context, digests []*storage.TaskDigest) ([]*storage.TaskRunWithID, error) {
+	const query = `
+	SELECT d.idx,
+	       tr.*
+	  FROM unnest($1::text[], $2::text[], $3::text[], $4::integer[]) WITH ORDINALITY AS d(app_name, task_name, total_input_digest, input_digest_version, idx)
+	 CROSS JOIN LATERAL (
+		SELECT task_run.id,
+		       application.name,
+		       task.name,
+		       vcs.revision,
+		       vcs.dirty,
+		       task_run.total_input_digest,
+		       task_run.input_digest_version,
+		       task_run.start_timestamp,
+		       task_run.stop_timestamp,
+		       task_run.result,
+		       task_run.exit_code,
+		       task_run.hostname,
+		       task_run.username,
+		       task_run.baur_version,
+		       task_run.command,
+		       task_run.env_vars,
+		       EXISTS (SELECT 1 FROM task_run_log WHERE task_run_log.task_run_id = task_run.id)
+		  FROM application
+		  JOIN task ON application.id = task.application_id
+		  JOIN task_run ON task.id = task_run.task_id
+		  LEFT OUTER JOIN vcs ON vcs.id = task_run.vcs_id
+		 WHERE application.name = d.app_name
+		   AND task.name = d.task_name
+		   AND task_run.total_input_digest = d.total_input_digest
+		   AND task_run.input_digest_version = d.input_digest_version
+		   AND task_run.result = 'success'
+		 ORDER BY task_run.stop_timestamp DESC
+		 LIMIT 1
+	       ) tr
+	`
+
+	result := make([]*storage.TaskRunWithID, len(digests))
+
+	if len(digests) == 0 {
+		return result, nil
+	}
+
+	appNames := make([]string, 0, len(digests))
+	taskNames := make([]string, 0, len(digests))
+	totalInputDigests := make([]string, 0, len(digests))
+	inputDigestVersions := make([]int, 0, len(digests))
+
+	for _, d := range digests {
+		appNames = append(appNames, d.ApplicationName)
+		taskNames = append(taskNames, d.TaskName)
+		totalInputDigests = append(totalInputDigests, d.TotalInputDigest)
+		inputDigestVersions = append(inputDigestVersions, d.InputDigestVersion)
+	}
+
+	rows, err := c.db.Query(ctx, query, appNames, taskNames, totalInputDigests, inputDigestVersions)
+	if err != nil {
+		return nil, newQueryError(query, err, appNames, taskNames, totalInputDigests, inputDigestVersions)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var idx int
+		var run storage.TaskRunWithID
+
+		err := rows.Scan(
+			&idx,
+			&run.ID,
+			&run.ApplicationName,
+			&run.TaskName,
+			&run.VCSRevision,
+			&run.VCSIsDirty,
+			&run.TotalInputDigest,
+			&run.InputDigestVersion,
+			&run.StartTimestamp,
+			&run.StopTimestamp,
+			&run.Result,
+			&run.ExitCode,
+			&run.Hostname,
+			&run.Username,
+			&run.BaurVersion,
+			&run.Command,
+			&run.EnvVars,
+			&run.HasLog,
+		)
+		if err != nil {
+			return nil, newQueryError(query, err, appNames, taskNames, totalInputDigests)
+		}
+
+		emptyProvenanceToNil(&run.TaskRun)
+
+		// the ordinality starts at 1
+		result[idx-1] = &run
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(query, err, appNames, taskNames, totalInputDigests)
+	}
+
+	return result, nil
+}
+
+// emptyProvenanceToNil sets the Command and EnvVars fields to nil when they
+// are empty, like they are when they were not set on insertion.
+func emptyProvenanceToNil(taskRun *storage.TaskRun) {
+	if len(taskRun.Command) == 0 {
+		taskRun.Command = nil
+	}
+
+	if len(taskRun.EnvVars) == 0 {
+		taskRun.EnvVars = nil
+	}
+}
+
+// taskRunsFullPageSize is the number of task runs that TaskRunsFull queries
+// at once.
+const taskRunsFullPageSize = 1000
+
+// TaskRunsFull queries the task runs page by page, ordered by their start
+// timestamp and ID. A page continues after the last run of the previous
+// page (keyset pagination), the inputs and outputs of all runs of a page are
+// queried at once.
+func (c *Client) TaskRunsFull(
+	ctx context.Context,
+	filters []*storage.Filter,
+	cb func(*storage.TaskRunFull) error,
+) error {
+	var found bool
+
+	q := query{
+		BaseQuery: taskRunsQuery,
+		Filters:   filters,
+		Sorters: []*storage.Sorter{
+			{Field: storage.FieldStartTime, Order: storage.OrderAsc},
+			{Field: storage.FieldID, Order: storage.OrderAsc},
+		},
+		Pagination: &storage.Pagination{Limit: taskRunsFullPageSize},
+	}
+
+	for {
+		var runs []*storage.TaskRunWithID
+
+		err := c.queryTaskRuns(ctx, &q, func(tr *storage.TaskRunWithID) error {
+			runs = append(runs, tr)
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, storage.ErrNotExist) {
+				break
+			}
+
+			return err
+		}
+
+		found = true
+
+		ids := make([]int, 0, len(runs))
+		for _, run := range runs {
+			ids = append(ids, run.ID)
+		}
+
+		inputs, err := c.inputsByTaskRun(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("querying inputs of task runs failed: %w", err)
+		}
+
+		outputs, err := c.outputsByTaskRun(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("querying outputs of task runs failed: %w", err)
+		}
+
+		for _, run := range runs {
+			err = cb(&storage.TaskRunFull{
+				TaskRun: run.TaskRun,
+				Inputs:  inputs[run.ID],
+				Outputs: outputs[run.ID],
+			})
+			if err != nil {
+				return fmt.Errorf("callback failed: %w", err)
+			}
+		}
+
+		if len(runs) < taskRunsFullPageSize {
+			break
+		}
+
+		last := runs[len(runs)-1]
+		q.After = &keysetPosition{
+			StartTimestamp: last.StartTimestamp,
+			ID:             last.ID,
+		}
+	}
+
+	if !found {
+		return storage.ErrNotExist
+	}
+
+	return nil
+}
+
+// inputsByTaskRun returns the inputs of the task runs with the given IDs,
+// mapped by task run ID.
+func (c *Client) inputsByTaskRun(ctx context.Context, taskRunIDs []int) (map[int][]*storage.Input, error) {
+	const query = `
+	SELECT task_run_input.task_run_id,
+	       input.uri,
+	       input.digest
+	  FROM input
+	  JOIN task_run_input ON input.id = task_run_input.input_id
+	 WHERE task_run_input.task_run_id = ANY($1)
+	 `
+
+	result := map[int][]*storage.Input{}
+
+	rows, err := c.db.Query(ctx, query, taskRunIDs)
+	if err != nil {
+		return nil, newQueryError(query, err, taskRunIDs)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskRunID int
+		var input storage.Input
+
+		if err := rows.Scan(&taskRunID, &input.URI, &input.Digest); err != nil {
+			return nil, newQueryError(query, err, taskRunIDs)
+		}
+
+		result[taskRunID] = append(result[taskRunID], &input)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(query, err, taskRunIDs)
+	}
+
+	return result, nil
+}
+
+// outputsByTaskRun returns the outputs of the task runs with the given IDs,
+// mapped by task run ID.
+func (c *Client) outputsByTaskRun(ctx context.Context, taskRunIDs []int) (map[int][]*storage.Output, error) {
+	const query = `
+	SELECT task_run_output.task_run_id,
+	       output.id,
+	       output.name,
+	       output.type,
+	       output.digest,
+	       output.size_bytes,
+	       upload.uri,
+	       upload.method,
+	       upload.start_timestamp,
+	       upload.stop_timestamp
+	  FROM output
+	  JOIN task_run_output ON task_run_output.output_id = output.id
+	  JOIN upload ON upload.id = task_run_output.upload_id
+	 WHERE task_run_output.task_run_id = ANY($1)
+	 `
+
+	type outputKey struct {
+		taskRunID int
+		outputID  int
+	}
+
+	result := map[int][]*storage.Output{}
+	outputs := map[outputKey]*storage.Output{}
+
+	rows, err := c.db.Query(ctx, query, taskRunIDs)
+	if err != nil {
+		return nil, newQueryError(query, err, taskRunIDs)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var key outputKey
+		var upload storage.Upload
+		output := &storage.Output{}
+
+		err := rows.Scan(
+			&key.taskRunID,
+			&key.outputID,
+			&output.Name,
+			&output.Type,
+			&output.Digest,
+			&output.SizeBytes,
+			&upload.URI,
+			&upload.Method,
+			&upload.UploadStartTimestamp,
+			&upload.UploadStopTimestamp,
+		)
+		if err != nil {
+			return nil, newQueryError(query, err, taskRunIDs)
+		}
+
+		if rec := outputs[key]; rec == nil {
+			outputs[key] = output
+			result[key.taskRunID] = append(result[key.taskRunID], output)
+		} else {
+			output = rec
+		}
+
+		output.Uploads = append(output.Uploads, &upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(query, err, taskRunIDs)
+	}
+
+	return result, nil
+}
+
 func (c *Client) Inputs(ctx context.Context, taskRunID int) ([]*storage.Input, error) {
 	const query = `
 	SELECT input.uri,
@@ -200,42 +535,51 @@ func (c *Client) Outputs(ctx context.Context, taskRunID int) ([]*storage.Output,
 	return result, nil
 }
 
+// taskRunsQuery is the base query for TaskRuns and TaskRunsFull.
+const taskRunsQuery = `
+	SELECT task_run.id AS task_run_id,
+	       application.name AS application_name,
+	       task.name AS task_name,
+	       vcs.revision,
+	       vcs.dirty,
+	       task_run.total_input_digest,
+	       task_run.input_digest_version,
+	       task_run.start_timestamp AS start_timestamp,
+	       task_run.stop_timestamp,
+	       task_run.result,
+	       task_run.exit_code,
+	       task_run.hostname,
+	       task_run.username,
+	       task_run.baur_version,
+	       task_run.command,
+	       task_run.env_vars,
+	       EXISTS (SELECT 1 FROM task_run_log WHERE task_run_log.task_run_id = task_run.id) AS has_log,
+	       ` + durationExpr + ` AS duration
+	  FROM application
+	  JOIN task ON application.id = task.application_id
+	  JOIN task_run ON task.id = task_run.task_id
+	  LEFT OUTER JOIN vcs ON vcs.id = task_run.vcs_id
+	  `
+
 func (c *Client) TaskRuns(
 	ctx context.Context,
 	filters []*storage.Filter,
 	sorters []*storage.Sorter,
+	pagination *storage.Pagination,
 	cb func(*storage.TaskRunWithID) error,
 ) error {
-	const queryStr = `
-	SELECT *
-	  FROM (
-	       SELECT DISTINCT ON (task_run.id)
-		      task_run.id AS task_run_id,
-	              application.name AS application_name,
-	              task.name AS task_name,
-	              vcs.revision,
-	              vcs.dirty,
-	              task_run_input.total_digest,
-	              task_run.start_timestamp AS start_timestamp,
-	              task_run.stop_timestamp,
-	              task_run.result,
-	              (EXTRACT(EPOCH FROM (task_run.stop_timestamp - task_run.start_timestamp))::bigint * 1000000000) AS duration
-	         FROM application
-	         JOIN task ON application.id = task.application_id
-	         JOIN task_run ON task.id = task_run.task_id
-	         JOIN task_run_input ON task_run_input.task_run_id = task_run.id
-	         LEFT OUTER JOIN vcs ON vcs.id = task_run.vcs_id
-	       ) tr
-	  `
+	return c.queryTaskRuns(ctx, &query{
+		BaseQuery:  taskRunsQuery,
+		Filters:    filters,
+		Sorters:    sorters,
+		Pagination: pagination,
+	}, cb)
+}
 
+// queryTaskRuns runs q, it's BaseQuery must be taskRunsQuery.
+func (c *Client) queryTaskRuns(ctx context.Context, q *query, cb func(*storage.TaskRunWithID) error) error {
 	var queryReturnedRows bool
 
-	q := query{
-		BaseQuery: queryStr,
-		Filters:   filters,
-		Sorters:   sorters,
-	}
-
 	query, args, err := q.Compile()
 	if err != nil {
 		return fmt.Errorf("compiling query string failed: %w", err)
@@ -258,9 +602,17 @@ func (c *Client) TaskRuns(
 			&taskRun.VCSRevision,
 			&taskRun.VCSIsDirty,
 			&taskRun.TotalInputDigest,
+			&taskRun.InputDigestVersion,
 			&taskRun.StartTimestamp,
 			&taskRun.StopTimestamp,
 			&taskRun.Result,
+			&taskRun.ExitCode,
+			&taskRun.Hostname,
+			&taskRun.Username,
+			&taskRun.BaurVersion,
+			&taskRun.Command,
+			&taskRun.EnvVars,
+			&taskRun.HasLog,
 			nil, // skip scanning of duration value, it's only used for filtering and sorting
 		)
 
@@ -269,6 +621,8 @@ func (c *Client) TaskRuns(
 			return fmt.Errorf("query %s with args: %s failed: %w", query, strArgList(args), err)
 		}
 
+		emptyProvenanceToNil(&taskRun.TaskRun)
+
 		if err := cb(&taskRun); err != nil {
 			rows.Close()
 			return fmt.Errorf("callback failed: %w", err)
@@ -285,3 +639,30 @@ func (c *Client) TaskRuns(
 
 	return nil
 }
+
+func (c *Client) TaskRunLog(ctx context.Context, taskRunID int) (*storage.TaskRunLog, error) {
+	const query = `
+	SELECT compression,
+	       truncated,
+	       data
+	  FROM task_run_log
+	 WHERE task_run_id = $1
+	 `
+
+	var result storage.TaskRunLog
+
+	err := c.db.QueryRow(ctx, query, taskRunID).Scan(
+		&result.Compression,
+		&result.Truncated,
+		&result.Data,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotExist
+		}
+
+		return nil, fmt.Errorf("query %s with arg: %d failed: %w", query, taskRunID, err)
+	}
+
+	return &result, nil
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-)
 
-const schemaVer = 1
+	"github.com/jackc/pgx/v4"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
 
+// initQuery creates the database schema in version 1, newer versions are
+// created by applying the migrations afterwards.
 const initQuery = `
 CREATE TABLE migrations (
 	schema_version integer NOT NULL
@@ -89,11 +93,27 @@ CREATE TABLE task_run_output (
 CREATE INDEX idx_task_run_output_task_run_id ON task_run_output(task_run_id);
 `
 
-// Init creates the baur tables in the postgresql database
+// Init creates the baur tables in the postgresql database and applies all
+// migrations.
 func (c *Client) Init(ctx context.Context) error {
-	_, err := c.db.Exec(ctx, initQuery)
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, initQuery)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	err = applyMigrations(ctx, tx, 1, migrations)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
 
-	return err
+	return tx.Commit(ctx)
 }
 
 // IsCompatible checks if the database schema exist and has the required
@@ -111,43 +131,60 @@ func (c *Client) IsCompatible(ctx context.Context) error {
 }
 
 func (c *Client) ensureSchemaIsCompatible(ctx context.Context) error {
+	ver, err := schemaVersion(ctx, c.db)
+	if err != nil {
+		return err
+	}
+
+	if ver < schemaVer {
+		return fmt.Errorf("schema version: %d, required version: %d: %w", ver, schemaVer, storage.ErrSchemaOutdated)
+	}
+
+	if ver > schemaVer {
+		return fmt.Errorf("schema version: %d, newest supported version: %d: %w", ver, schemaVer, storage.ErrSchemaTooNew)
+	}
+
+	return nil
+}
+
+type queryer interface {
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+}
+
+// schemaVersion returns the version that is stored in the migrations table.
+func schemaVersion(ctx context.Context, db queryer) (int, error) {
 	var rowsCount int
+	var ver int
 
-	rows, err := c.db.Query(ctx, "SELECT schema_version from migrations")
+	rows, err := db.Query(ctx, "SELECT schema_version from migrations")
 	if err != nil {
-		return fmt.Errorf("querying schema_version failed: %w", err)
+		return -1, fmt.Errorf("querying schema_version failed: %w", err)
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
-		var ver int
-
 		if rowsCount != 0 {
-			return errors.New("migrations table contains >1 rows")
+			return -1, errors.New("migrations table contains >1 rows")
 		}
 
 		err = rows.Scan(&ver)
 		if err != nil {
-			return err
-		}
-
-		if ver != schemaVer {
-			return fmt.Errorf("database schema version is not compatible with baur version, schema version: %d, expected version: %d", ver, schemaVer)
+			return -1, err
 		}
 
 		rowsCount++
 	}
 
 	if err := rows.Err(); err != nil {
-		return err
+		return -1, err
 	}
 
 	if rowsCount != 1 {
-		return fmt.Errorf("read %d rows from migrations table, expected 1", rowsCount)
+		return -1, fmt.Errorf("read %d rows from migrations table, expected 1", rowsCount)
 	}
 
-	return nil
+	return ver, nil
 }
 
 func (c *Client) tableExists(ctx context.Context, tableName string) (bool, error) {
@@ -3,10 +3,13 @@
 package postgres
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/storage"
 )
 
 func TestIsCompatible_AfterInit(t *testing.T) {
@@ -49,3 +52,94 @@ func TestIsCompatible_SchemaVersionDoesNotMatch(t *testing.T) {
 	err = client.IsCompatible(ctx)
 	assert.Error(t, err, "database schema version is not compatible")
 }
+
+func TestIsCompatible_OutdatedSchema(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	_, err := client.db.Exec(ctx, initQuery)
+	require.NoError(t, err)
+
+	err = client.IsCompatible(ctx)
+	assert.True(t, errors.Is(err, storage.ErrSchemaOutdated))
+}
+
+func TestIsCompatible_NewerSchema(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	_, err := client.db.Exec(ctx, "UPDATE migrations set schema_version = $1", schemaVer+1)
+	require.NoError(t, err)
+
+	err = client.IsCompatible(ctx)
+	assert.True(t, errors.Is(err, storage.ErrSchemaTooNew))
+
+	_, err = client.Upgrade(ctx)
+	assert.True(t, errors.Is(err, storage.ErrSchemaTooNew))
+}
+
+func TestUpgradeFromFirstSchemaVersion(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	_, err := client.db.Exec(ctx, initQuery)
+	require.NoError(t, err)
+
+	pending, err := client.PendingMigrations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrations, pending)
+
+	applied, err := client.Upgrade(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrations, applied)
+
+	require.NoError(t, client.IsCompatible(ctx))
+
+	pending, err = client.PendingMigrations(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestUpgradeMovesTotalInputDigestToTaskRun(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	_, err := client.db.Exec(ctx, initQuery)
+	require.NoError(t, err)
+
+	tx, err := client.db.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, applyMigrations(ctx, tx, 1, migrations[:len(migrations)-1]))
+	require.NoError(t, tx.Commit(ctx))
+
+	_, err = client.db.Exec(ctx, `
+	INSERT INTO application (name) VALUES ('calc');
+	INSERT INTO task (name, application_id) SELECT 'build', id FROM application;
+	INSERT INTO task_run (id, task_id, start_timestamp, stop_timestamp, result, exit_code)
+	     SELECT 1, id, now(), now(), 'success', 0 FROM task;
+	INSERT INTO task_run (id, task_id, start_timestamp, stop_timestamp, result, exit_code)
+	     SELECT 2, id, now(), now(), 'success', 0 FROM task;
+	INSERT INTO input (uri, digest) VALUES ('main.go', 'sha384:1'), ('go.mod', 'sha384:2');
+	INSERT INTO task_run_input (task_run_id, input_id, total_digest)
+	     SELECT 1, id, 'sha384:total' FROM input;
+	`)
+	require.NoError(t, err)
+
+	_, err = client.Upgrade(ctx)
+	require.NoError(t, err)
+
+	run, err := client.LatestTaskRunByDigest(ctx, "calc", "build", "sha384:total", storage.LegacyInputDigestVersion)
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.ID)
+	assert.Equal(t, "sha384:total", run.TotalInputDigest)
+
+	inputs, err := client.Inputs(ctx, run.ID)
+	require.NoError(t, err)
+	assert.Len(t, inputs, 2)
+
+	run, err = client.TaskRun(ctx, 2)
+	require.NoError(t, err)
+	assert.Empty(t, run.TotalInputDigest)
+}
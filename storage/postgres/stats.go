@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+// statsRunsQuery selects the task runs that statistics are calculated for.
+// The filters are appended as WHERE clause.
+const statsRunsQuery = `
+	SELECT task_run.id,
+	       application.name AS application_name,
+	       task.name AS task_name,
+	       task_run.result,
+	       task_run.start_timestamp,
+	       EXTRACT(EPOCH FROM (task_run.stop_timestamp - task_run.start_timestamp))::double precision AS duration_sec
+	  FROM application
+	  JOIN task ON application.id = task.application_id
+	  JOIN task_run ON task.id = task_run.task_id
+	  LEFT OUTER JOIN vcs ON vcs.id = task_run.vcs_id
+	`
+
+// secondsToDuration converts seconds to a duration, rounded to microseconds,
+// the precision of PostgreSQL timestamps.
+func secondsToDuration(sec float64) time.Duration {
+	return time.Duration(math.Round(sec*1e6)) * time.Microsecond
+}
+
+func (c *Client) TaskStats(ctx context.Context, filters []*storage.Filter) ([]*storage.TaskStats, error) {
+	var result []*storage.TaskStats
+
+	q := query{Filters: filters}
+
+	filterStr, args, err := q.compileFilterStr()
+	if err != nil {
+		return nil, fmt.Errorf("compiling query string failed: %w", err)
+	}
+
+	queryStr := fmt.Sprintf(`
+	SELECT application_name,
+	       task_name,
+	       count(*),
+	       count(*) FILTER (WHERE result = 'success'),
+	       percentile_cont(0.5) WITHIN GROUP (ORDER BY duration_sec),
+	       percentile_cont(0.95) WITHIN GROUP (ORDER BY duration_sec),
+	       max(duration_sec)
+	  FROM (%s %s) tr
+	 GROUP BY application_name, task_name
+	 ORDER BY application_name, task_name
+	`, statsRunsQuery, filterStr)
+
+	rows, err := c.db.Query(ctx, queryStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query %s with args: %s failed: %w", queryStr, strArgList(args), err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var stats storage.TaskStats
+		var p50, p95, maxDuration float64
+
+		err := rows.Scan(
+			&stats.ApplicationName,
+			&stats.TaskName,
+			&stats.Runs,
+			&stats.SuccessfulRuns,
+			&p50,
+			&p95,
+			&maxDuration,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("query %s with args: %s failed: %w", queryStr, strArgList(args), err)
+		}
+
+		stats.DurationP50 = secondsToDuration(p50)
+		stats.DurationP95 = secondsToDuration(p95)
+		stats.DurationMax = secondsToDuration(maxDuration)
+
+		result = append(result, &stats)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query %s with args: %s failed: %w", queryStr, strArgList(args), err)
+	}
+
+	if len(result) == 0 {
+		return nil, storage.ErrNotExist
+	}
+
+	return result, nil
+}
+
+func (c *Client) OutputSizeTrend(ctx context.Context, filters []*storage.Filter, interval time.Duration) ([]*storage.OutputSizeBucket, error) {
+	var result []*storage.OutputSizeBucket
+
+	if interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+
+	q := query{Filters: filters}
+
+	filterStr, args, err := q.compileFilterStr()
+	if err != nil {
+		return nil, fmt.Errorf("compiling query string failed: %w", err)
+	}
+
+	args = append(args, interval.Seconds())
+	intervalPlaceholder := fmt.Sprintf("$%d::double precision", len(args))
+
+	// an output can be referenced multiple times by a task run, once per
+	// upload, the size is only accounted once
+	queryStr := fmt.Sprintf(`
+	SELECT to_timestamp(floor(EXTRACT(EPOCH FROM tr.start_timestamp) / %[1]s) * %[1]s) AS bucket,
+	       count(DISTINCT tr.id),
+	       COALESCE(sum(output.size_bytes), 0)::bigint
+	  FROM (%[2]s %[3]s) tr
+	  LEFT OUTER JOIN (SELECT DISTINCT task_run_id, output_id FROM task_run_output) tro ON tro.task_run_id = tr.id
+	  LEFT OUTER JOIN output ON output.id = tro.output_id
+	 GROUP BY bucket
+	 ORDER BY bucket
+	`, intervalPlaceholder, statsRunsQuery, filterStr)
+
+	rows, err := c.db.Query(ctx, queryStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query %s with args: %s failed: %w", queryStr, strArgList(args), err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket storage.OutputSizeBucket
+		var sizeBytes int64
+
+		err := rows.Scan(&bucket.Start, &bucket.Runs, &sizeBytes)
+		if err != nil {
+			return nil, fmt.Errorf("query %s with args: %s failed: %w", queryStr, strArgList(args), err)
+		}
+
+		bucket.OutputSizeBytes = uint64(sizeBytes)
+
+		result = append(result, &bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query %s with args: %s failed: %w", queryStr, strArgList(args), err)
+	}
+
+	if len(result) == 0 {
+		return nil, storage.ErrNotExist
+	}
+
+	return result, nil
+}
@@ -0,0 +1,16 @@
+// +build dbtest
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/simplesurance/baur/v1/internal/testutils/storagetest"
+	"github.com/simplesurance/baur/v1/storage"
+)
+
+func TestStorer(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) (storage.Storer, func()) {
+		return newTestClient(t)
+	})
+}
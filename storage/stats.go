@@ -0,0 +1,41 @@
+package storage
+
+import "time"
+
+// TaskStats contains statistics about the runs of a task.
+type TaskStats struct {
+	ApplicationName string
+	TaskName        string
+	// Runs is the number of runs of the task.
+	Runs int
+	// SuccessfulRuns is the number of runs with the result ResultSuccess.
+	SuccessfulRuns int
+	// DurationP50 and DurationP95 are the 50th and 95th percentile of the
+	// run durations, they are calculated by linear interpolation between
+	// the closest ranks.
+	DurationP50 time.Duration
+	DurationP95 time.Duration
+	DurationMax time.Duration
+}
+
+// SuccessRate returns the ratio of successful runs to all runs, in the
+// range 0 to 1.
+func (s *TaskStats) SuccessRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+
+	return float64(s.SuccessfulRuns) / float64(s.Runs)
+}
+
+// OutputSizeBucket contains the accumulated size of the outputs of the task
+// runs that were started in a time interval.
+type OutputSizeBucket struct {
+	// Start is the beginning of the interval. Intervals are aligned to
+	// the Unix epoch.
+	Start time.Time
+	// Runs is the number of task runs in the interval.
+	Runs int
+	// OutputSizeBytes is the sum of the sizes of the outputs of the runs.
+	OutputSizeBytes uint64
+}
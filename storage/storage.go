@@ -9,6 +9,10 @@ import (
 // ErrNotExist indicates that a record does not exist
 var ErrNotExist = errors.New("does not exist")
 
+// LegacyInputDigestVersion is the input digest version of task runs that
+// were recorded before the version was stored.
+const LegacyInputDigestVersion = 1
+
 type Input struct {
 	URI    string
 	Digest string
@@ -54,6 +58,12 @@ type Result string
 const (
 	ResultSuccess Result = "success"
 	ResultFailure Result = "failure"
+	// ResultTimeout is the result of runs that were terminated because
+	// they exceeded their timeout.
+	ResultTimeout Result = "timeout"
+	// ResultCancelled is the result of runs that were terminated because
+	// baur was interrupted.
+	ResultCancelled Result = "cancelled"
 )
 
 type TaskRun struct {
@@ -65,17 +75,52 @@ type TaskRun struct {
 	StopTimestamp    time.Time
 	TotalInputDigest string
 	Result           Result
+	ExitCode         int
+
+	// InputDigestVersion is the version of the format of the input
+	// digests and TotalInputDigest. Digests of different versions can
+	// not be compared.
+	InputDigestVersion int
+
+	// Hostname is the name of the host on that the task was run.
+	Hostname string
+	// Username is the name of the OS user that ran the task.
+	Username string
+	// BaurVersion is the version of baur that ran the task.
+	BaurVersion string
+	// Command is the command that was executed for the task.
+	Command []string
+	// EnvVars contains the recorded environment variables of the run,
+	// e.g. the URL of the CI job that ran it.
+	EnvVars map[string]string
 }
 
 type TaskRunFull struct {
 	TaskRun
 	Inputs  []*Input
 	Outputs []*Output
+	// Log is the command output of the run, it is stored together with
+	// the run. It is optional and not set by the query methods, use
+	// Storer.TaskRunLog() to retrieve it.
+	Log *TaskRunLog `json:",omitempty"`
 }
 
 type TaskRunWithID struct {
 	ID int
 	TaskRun
+	// HasLog is true when a TaskRunLog was stored for the run.
+	HasLog bool
+}
+
+// TaskDigest identifies the runs of a task that were recorded for a total
+// input digest.
+type TaskDigest struct {
+	ApplicationName  string
+	TaskName         string
+	TotalInputDigest string
+	// InputDigestVersion is the version of the algorithm that
+	// TotalInputDigest was calculated with.
+	InputDigestVersion int
 }
 
 // Storer is an interface for storing and retrieving baur task runs
@@ -88,10 +133,23 @@ type Storer interface {
 	IsCompatible(context.Context) error
 
 	SaveTaskRun(context.Context, *TaskRunFull) (id int, err error)
-	LatestTaskRunByDigest(ctx context.Context, appName, taskName, totalInputDigest string) (*TaskRunWithID, error)
+	// SaveTaskRuns stores multiple task runs at once and returns their
+	// IDs in the same order. Either all or none of the runs are stored.
+	SaveTaskRuns(context.Context, []*TaskRunFull) (ids []int, err error)
+	// LatestTaskRunByDigest returns the most recent successful run of the
+	// task with the given total input digest, that was calculated with
+	// the algorithm in version inputDigestVersion.
+	LatestTaskRunByDigest(ctx context.Context, appName, taskName, totalInputDigest string, inputDigestVersion int) (*TaskRunWithID, error)
+	// LatestTaskRunsByDigest looks up the most recent successful runs for
+	// multiple tasks at once, like LatestTaskRunByDigest.
+	// The returned slice has the same length and order as digests, it
+	// contains nil for the elements that no run exists for.
+	LatestTaskRunsByDigest(ctx context.Context, digests []*TaskDigest) ([]*TaskRunWithID, error)
 
 	TaskRun(ctx context.Context, id int) (*TaskRunWithID, error)
 	// TaskRuns queries the storage for runs that match the filters.
+	// If pagination is not nil, only the records in the described range
+	// are returned.
 	// The found results are passed in iterative manner to the callback
 	// function. When the callback function returns an error, the iteration
 	// stops.
@@ -99,9 +157,35 @@ type Storer interface {
 	TaskRuns(ctx context.Context,
 		filters []*Filter,
 		sorters []*Sorter,
+		pagination *Pagination,
 		callback func(*TaskRunWithID) error,
 	) error
 
+	// TaskRunsFull queries the storage for runs that match the filters,
+	// like TaskRuns, and passes them with their inputs and outputs to the
+	// callback function. The runs are passed in ascending order of their
+	// start timestamp and ID.
+	// When no matching records exist, the method returns ErrNotExist.
+	TaskRunsFull(ctx context.Context,
+		filters []*Filter,
+		callback func(*TaskRunFull) error,
+	) error
+
 	Inputs(ctx context.Context, taskRunID int) ([]*Input, error)
 	Outputs(ctx context.Context, taskRunID int) ([]*Output, error)
+
+	// TaskRunLog returns the command output of a task run.
+	// If no log was stored for the run, ErrNotExist is returned.
+	TaskRunLog(ctx context.Context, taskRunID int) (*TaskRunLog, error)
+
+	// TaskStats returns statistics about the runs that match the filters,
+	// aggregated per task and sorted by application and task name.
+	// When no matching records exist, the method returns ErrNotExist.
+	TaskStats(ctx context.Context, filters []*Filter) ([]*TaskStats, error)
+	// OutputSizeTrend returns the accumulated output sizes of the runs
+	// that match the filters, grouped by their start time into intervals
+	// of the passed length. Only intervals that contain runs are returned,
+	// sorted by their start time.
+	// When no matching records exist, the method returns ErrNotExist.
+	OutputSizeTrend(ctx context.Context, filters []*Filter, interval time.Duration) ([]*OutputSizeBucket, error)
 }
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+)
+
+// LogCompression is the compression algorithm of a stored TaskRunLog.
+type LogCompression string
+
+const (
+	LogCompressionGzip LogCompression = "gzip"
+)
+
+// TaskRunLog is the output that the command of a task run printed to STDOUT
+// and STDERR.
+type TaskRunLog struct {
+	Compression LogCompression
+	Data        []byte
+	// Truncated is true when the output was bigger then the max. size
+	// and only the end of it was stored.
+	Truncated bool
+}
+
+// NewTaskRunLog creates a gzip compressed TaskRunLog from output.
+// If output is bigger than maxSize bytes, only the last maxSize bytes are
+// stored.
+func NewTaskRunLog(output []byte, maxSize int) (*TaskRunLog, error) {
+	var buf bytes.Buffer
+	var truncated bool
+
+	if len(output) > maxSize {
+		output = output[len(output)-maxSize:]
+		truncated = true
+	}
+
+	w := gzip.NewWriter(&buf)
+
+	if _, err := w.Write(output); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return &TaskRunLog{
+		Compression: LogCompressionGzip,
+		Data:        buf.Bytes(),
+		Truncated:   truncated,
+	}, nil
+}
+
+// Output returns the uncompressed log.
+func (l *TaskRunLog) Output() ([]byte, error) {
+	switch l.Compression {
+	case LogCompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(l.Data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		return ioutil.ReadAll(r)
+
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", l.Compression)
+	}
+}
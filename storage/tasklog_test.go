@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskRunLogRoundtrip(t *testing.T) {
+	output := []byte("building app\nbuild successful\n")
+
+	l, err := NewTaskRunLog(output, 1024)
+	require.NoError(t, err)
+	assert.False(t, l.Truncated)
+
+	res, err := l.Output()
+	require.NoError(t, err)
+	assert.Equal(t, output, res)
+}
+
+func TestTaskRunLogIsTruncated(t *testing.T) {
+	l, err := NewTaskRunLog([]byte("0123456789"), 4)
+	require.NoError(t, err)
+	assert.True(t, l.Truncated)
+
+	res, err := l.Output()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("6789"), res)
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// TransferFormatVersion is the version of the format that Export writes.
+// It is increased when the format changes incompatibly.
+const TransferFormatVersion = 1
+
+// importBatchSize is the max. number of task runs that Import stores at once.
+const importBatchSize = 500
+
+// importBatchMaxBytes is the max. JSON encoded size of the task runs that
+// Import stores at once. It keeps the requests of the httpstorage client
+// below the max. request size of the server.
+const importBatchMaxBytes = 16 * 1024 * 1024
+
+// transferRecord is a line in the JSON-lines format of Export.
+type transferRecord struct {
+	Version int          `json:"version"`
+	TaskRun *TaskRunFull `json:"task_run"`
+}
+
+// Export writes the task runs that match the filters, with their inputs and
+// outputs, in JSON-lines format to w. Runs are written in the order of
+// their start time.
+// If progress is not nil, it is called with the number of written runs
+// after every run.
+// It returns the number of written runs.
+// When no matching runs exist, ErrNotExist is returned.
+func Export(ctx context.Context, s Storer, w io.Writer, filters []*Filter, progress func(exported int)) (int, error) {
+	var cnt int
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	err := s.TaskRunsFull(
+		ctx,
+		filters,
+		func(run *TaskRunFull) error {
+			err := enc.Encode(&transferRecord{
+				Version: TransferFormatVersion,
+				TaskRun: run,
+			})
+			if err != nil {
+				return err
+			}
+
+			cnt++
+
+			if progress != nil {
+				progress(cnt)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		if errors.Is(err, ErrNotExist) {
+			return 0, ErrNotExist
+		}
+
+		return cnt, err
+	}
+
+	return cnt, nil
+}
+
+// ImportProgress describes the state of an import.
+type ImportProgress struct {
+	// Read is the number of task runs that were read.
+	Read int
+	// Imported is the number of task runs that were stored.
+	Imported int
+	// Skipped is the number of task runs that were not stored because
+	// they already existed.
+	Skipped int
+}
+
+// taskRunIdentity identifies a task run independent of the storage that it
+// is stored in.
+// The start timestamp is rounded to microseconds, the precision of the
+// PostgreSQL storage.
+type taskRunIdentity struct {
+	appName          string
+	taskName         string
+	totalInputDigest string
+	startTimestamp   int64
+}
+
+func newTaskRunIdentity(run *TaskRun) taskRunIdentity {
+	return taskRunIdentity{
+		appName:          run.ApplicationName,
+		taskName:         run.TaskName,
+		totalInputDigest: run.TotalInputDigest,
+		startTimestamp:   run.StartTimestamp.Round(time.Microsecond).UnixNano(),
+	}
+}
+
+// Import reads task runs in the format that Export writes from r and stores
+// them in s.
+// Runs that already exist in s or that are contained multiple times in r are
+// skipped. Runs are identified by their application name, task name, total
+// input digest and start timestamp.
+// Runs are stored in batches, if progress is not nil it is called after
+// every stored batch.
+func Import(ctx context.Context, s Storer, r io.Reader, progress func(*ImportProgress)) (*ImportProgress, error) {
+	var result ImportProgress
+
+	known, err := taskRunIdentities(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := make([]*TaskRunFull, 0, importBatchSize)
+	var batchBytes int
+
+	storeBatch := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if _, err := s.SaveTaskRuns(ctx, batch); err != nil {
+			return fmt.Errorf("storing task runs failed: %w", err)
+		}
+
+		result.Imported += len(batch)
+		batch = batch[:0]
+		batchBytes = 0
+
+		if progress != nil {
+			progress(&result)
+		}
+
+		return nil
+	}
+
+	dec := json.NewDecoder(r)
+
+	for {
+		var raw json.RawMessage
+		var rec transferRecord
+
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = json.Unmarshal(raw, &rec)
+		}
+		if err != nil {
+			return &result, fmt.Errorf("record %d: decoding failed: %w", result.Read+1, err)
+		}
+
+		result.Read++
+
+		if rec.Version != TransferFormatVersion {
+			return &result, fmt.Errorf("record %d: unsupported format version %d, supported is %d", result.Read, rec.Version, TransferFormatVersion)
+		}
+
+		if rec.TaskRun == nil {
+			return &result, fmt.Errorf("record %d: task_run field is missing", result.Read)
+		}
+
+		// runs that were exported before the input digest version was
+		// recorded have the legacy version
+		if rec.TaskRun.InputDigestVersion == 0 {
+			rec.TaskRun.InputDigestVersion = LegacyInputDigestVersion
+		}
+
+		id := newTaskRunIdentity(&rec.TaskRun.TaskRun)
+		if _, exist := known[id]; exist {
+			result.Skipped++
+			continue
+		}
+
+		known[id] = struct{}{}
+
+		if batchBytes+len(raw) > importBatchMaxBytes {
+			if err := storeBatch(); err != nil {
+				return &result, err
+			}
+		}
+
+		batch = append(batch, rec.TaskRun)
+		batchBytes += len(raw)
+
+		if len(batch) == importBatchSize {
+			if err := storeBatch(); err != nil {
+				return &result, err
+			}
+		}
+	}
+
+	if err := storeBatch(); err != nil {
+		return &result, err
+	}
+
+	return &result, nil
+}
+
+// taskRunIdentities returns the identities of all task runs in s.
+func taskRunIdentities(ctx context.Context, s Storer) (map[taskRunIdentity]struct{}, error) {
+	result := map[taskRunIdentity]struct{}{}
+
+	err := s.TaskRuns(ctx, nil, nil, nil, func(run *TaskRunWithID) error {
+		result[newTaskRunIdentity(&run.TaskRun)] = struct{}{}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, fmt.Errorf("querying existing task runs failed: %w", err)
+	}
+
+	return result, nil
+}
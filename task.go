@@ -3,6 +3,7 @@ package baur
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/simplesurance/baur/v1/cfg"
 )
@@ -20,10 +21,24 @@ type Task struct {
 	Command          []string
 	UnresolvedInputs *cfg.Input
 	Outputs          *cfg.Output
+	MutexGroups      []string
+	// DependsOn contains the IDs of the tasks that must be run before the
+	// task.
+	DependsOn []string
+	// Timeout is the max. duration the command of the task may run, 0
+	// means no timeout.
+	Timeout time.Duration
+	// IncludeFiles are the absolute paths of the include files that the
+	// task definition was merged from.
+	IncludeFiles []string
 }
 
 // NewTask returns a new Task.
 func NewTask(cfg *cfg.Task, appName, repositoryRootdir, workingDir string) *Task {
+	// the timeout was validated when the config was loaded, parsing can
+	// only fail if it is empty
+	timeout, _ := time.ParseDuration(cfg.Timeout)
+
 	return &Task{
 		RepositoryRoot:   repositoryRootdir,
 		Directory:        workingDir,
@@ -32,6 +47,10 @@ func NewTask(cfg *cfg.Task, appName, repositoryRootdir, workingDir string) *Task
 		Name:             cfg.Name,
 		AppName:          appName,
 		UnresolvedInputs: &cfg.Input,
+		MutexGroups:      cfg.MutexGroups,
+		DependsOn:        cfg.DependsOn,
+		Timeout:          timeout,
+		IncludeFiles:     cfg.IncludeFiles(),
 	}
 }
 
@@ -0,0 +1,161 @@
+package baur
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDependencyCycle is returned when the dependencies of tasks form a cycle.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// SortTasksByDependencies returns the tasks in topological order, a task is
+// always placed after the tasks it depends on.
+// Tasks that do not depend on each other are ordered by their ID.
+// Dependencies that are not part of tasks are ignored.
+// If the dependencies form a cycle, an error wrapping ErrDependencyCycle is
+// returned.
+func SortTasksByDependencies(tasks []*Task) ([]*Task, error) {
+	byID := make(map[string]*Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID()] = task
+	}
+
+	if err := checkDependencyCycle(byID); err != nil {
+		return nil, err
+	}
+
+	unfinishedDeps := make(map[string]int, len(byID))
+	dependents := make(map[string][]string, len(byID))
+	ready := make([]string, 0, len(byID))
+
+	for id, task := range byID {
+		for _, depID := range uniqStrings(task.DependsOn) {
+			if _, exist := byID[depID]; !exist {
+				continue
+			}
+
+			unfinishedDeps[id]++
+			dependents[depID] = append(dependents[depID], id)
+		}
+
+		if unfinishedDeps[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	result := make([]*Task, 0, len(byID))
+
+	for len(ready) > 0 {
+		sort.Strings(ready)
+
+		id := ready[0]
+		ready = ready[1:]
+
+		result = append(result, byID[id])
+
+		for _, dependentID := range dependents[id] {
+			unfinishedDeps[dependentID]--
+
+			if unfinishedDeps[dependentID] == 0 {
+				ready = append(ready, dependentID)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// checkDependencyCycle returns an error wrapping ErrDependencyCycle if the
+// dependencies of tasks form a cycle.
+func checkDependencyCycle(tasks map[string]*Task) error {
+	if cycle := findDependencyCycle(tasks); len(cycle) != 0 {
+		return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+	}
+
+	return nil
+}
+
+// findDependencyCycle returns the IDs of the tasks that form a dependency
+// cycle. The first and last element of the returned slice are the same.
+// If no cycle exists, nil is returned.
+func findDependencyCycle(tasks map[string]*Task) []string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	state := make(map[string]int, len(tasks))
+	var path []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = inProgress
+		path = append(path, id)
+
+		deps := uniqStrings(tasks[id].DependsOn)
+		sort.Strings(deps)
+
+		for _, depID := range deps {
+			if _, exist := tasks[depID]; !exist {
+				continue
+			}
+
+			switch state[depID] {
+			case inProgress:
+				for i, elem := range path {
+					if elem == depID {
+						cycle := append([]string{}, path[i:]...)
+						return append(cycle, depID)
+					}
+				}
+
+			case unvisited:
+				if cycle := visit(depID); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[id] = done
+
+		return nil
+	}
+
+	ids := make([]string, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if state[id] != unvisited {
+			continue
+		}
+
+		if cycle := visit(id); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
+
+func uniqStrings(sl []string) []string {
+	seen := make(map[string]struct{}, len(sl))
+	result := make([]string, 0, len(sl))
+
+	for _, s := range sl {
+		if _, exist := seen[s]; exist {
+			continue
+		}
+
+		seen[s] = struct{}{}
+		result = append(result, s)
+	}
+
+	return result
+}
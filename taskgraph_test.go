@@ -0,0 +1,66 @@
+package baur
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func taskIDs(tasks []*Task) []string {
+	result := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		result = append(result, t.ID())
+	}
+
+	return result
+}
+
+func TestSortTasksByDependencies(t *testing.T) {
+	tasks := []*Task{
+		{AppName: "app-b", Name: "build", DependsOn: []string{"app-a.build"}},
+		{AppName: "app-c", Name: "check", DependsOn: []string{"app-b.build", "app-a.build"}},
+		{AppName: "app-a", Name: "build", DependsOn: []string{"app-z.build"}},
+		{AppName: "app-a", Name: "check"},
+	}
+
+	sorted, err := SortTasksByDependencies(tasks)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]string{"app-a.build", "app-a.check", "app-b.build", "app-c.check"},
+		taskIDs(sorted),
+	)
+}
+
+func TestSortTasksByDependenciesDetectsCycles(t *testing.T) {
+	testcases := []struct {
+		name  string
+		tasks []*Task
+	}{
+		{
+			name: "selfDependency",
+			tasks: []*Task{
+				{AppName: "app-a", Name: "build", DependsOn: []string{"app-a.build"}},
+			},
+		},
+		{
+			name: "transitive",
+			tasks: []*Task{
+				{AppName: "app-a", Name: "build", DependsOn: []string{"app-c.build"}},
+				{AppName: "app-b", Name: "build", DependsOn: []string{"app-a.build"}},
+				{AppName: "app-c", Name: "build", DependsOn: []string{"app-b.build"}},
+				{AppName: "app-d", Name: "build"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := SortTasksByDependencies(tc.tasks)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrDependencyCycle), "unexpected error: %s", err)
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package baur
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
@@ -9,33 +13,160 @@ import (
 	"github.com/simplesurance/baur/v1/internal/exec"
 )
 
-type TaskRunner struct{}
+// RunStatus describes how the execution of a task command ended.
+type RunStatus int
+
+const (
+	RunStatusUndefined RunStatus = iota
+	// RunStatusSuccess means that the command exited with code 0.
+	RunStatusSuccess
+	// RunStatusFailure means that the command exited with a code != 0.
+	RunStatusFailure
+	// RunStatusTimeout means that the command was terminated because it
+	// exceeded the timeout of the task.
+	RunStatusTimeout
+	// RunStatusCancelled means that the command was terminated because
+	// the context was cancelled.
+	RunStatusCancelled
+)
+
+func (s RunStatus) String() string {
+	switch s {
+	case RunStatusSuccess:
+		return "successful"
+	case RunStatusFailure:
+		return "failed"
+	case RunStatusTimeout:
+		return "timed out"
+	case RunStatusCancelled:
+		return "cancelled"
+	default:
+		return "undefined"
+	}
+}
+
+// TaskRunner executes the command of tasks.
+// It is safe to call Run() concurrently. To prevent that tasks that share a
+// mutex group run at the same time, LockMutexGroups() must be called before
+// Run().
+type TaskRunner struct {
+	// LogFn is optional, when it is set every line that the command
+	// prints is passed to it, prefixed with the task ID.
+	LogFn func(format string, v ...interface{})
+
+	mutexGroups     map[string]*sync.Mutex
+	mutexGroupsLock sync.Mutex // protects mutexGroups
+}
 
 func NewTaskRunner() *TaskRunner {
-	return &TaskRunner{}
+	return &TaskRunner{
+		mutexGroups: map[string]*sync.Mutex{},
+	}
 }
 
 type RunResult struct {
 	*exec.Result
+	Status    RunStatus
 	StartTime time.Time
 	StopTime  time.Time
 }
 
-func (t *TaskRunner) Run(task *Task) (*RunResult, error) {
+// Run executes the command of the task.
+// When the timeout of the task expires or ctx is cancelled, the command and
+// all processes it started are terminated. If the command then does not exit
+// with code 0, the returned RunResult has the status RunStatusTimeout or
+// RunStatusCancelled.
+func (t *TaskRunner) Run(ctx context.Context, task *Task) (*RunResult, error) {
+	runCtx := ctx
+	if task.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		runCtx, cancel = context.WithTimeout(ctx, task.Timeout)
+		defer cancel()
+	}
+
 	startTime := time.Now()
 
 	// TODO: rework exec, stream the output instead of storing all in memory
-	execResult, err := exec.Command(task.Command[0], task.Command[1:]...).
+	cmd := exec.CommandContext(runCtx, task.Command[0], task.Command[1:]...).
 		Directory(task.Directory).
-		DebugfPrefix(color.YellowString(fmt.Sprintf("%s: ", task))).
-		Run()
+		DebugfPrefix(color.YellowString(fmt.Sprintf("%s: ", task)))
+	if t.LogFn != nil {
+		cmd.DebugfFunc(t.LogFn)
+	}
+
+	execResult, err := cmd.Run()
 	if err != nil {
 		return nil, err
 	}
 
+	stopTime := time.Now()
+
+	var status RunStatus
+	switch {
+	case execResult.ExitCode == 0:
+		status = RunStatusSuccess
+	case ctx.Err() != nil:
+		status = RunStatusCancelled
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		status = RunStatusTimeout
+	default:
+		status = RunStatusFailure
+	}
+
 	return &RunResult{
 		Result:    execResult,
+		Status:    status,
 		StartTime: startTime,
-		StopTime:  time.Now(),
+		StopTime:  stopTime,
 	}, nil
 }
+
+// LockMutexGroups acquires the mutexes of all mutex groups of the task and
+// returns a function that releases them.
+// The mutexes are always acquired in the same order, to prevent deadlocks
+// between tasks that belong to multiple groups.
+func (t *TaskRunner) LockMutexGroups(task *Task) func() {
+	groups := task.MutexGroups
+	if len(groups) == 0 {
+		return func() {}
+	}
+
+	names := make([]string, 0, len(groups))
+	seen := make(map[string]struct{}, len(groups))
+
+	for _, g := range groups {
+		if _, exist := seen[g]; exist {
+			continue
+		}
+
+		seen[g] = struct{}{}
+		names = append(names, g)
+	}
+
+	sort.Strings(names)
+
+	mutexes := make([]*sync.Mutex, 0, len(names))
+
+	t.mutexGroupsLock.Lock()
+	for _, name := range names {
+		m, exist := t.mutexGroups[name]
+		if !exist {
+			m = &sync.Mutex{}
+			t.mutexGroups[name] = m
+		}
+
+		mutexes = append(mutexes, m)
+	}
+	t.mutexGroupsLock.Unlock()
+
+	for _, m := range mutexes {
+		m.Lock()
+	}
+
+	return func() {
+		for i := len(mutexes) - 1; i >= 0; i-- {
+			mutexes[i].Unlock()
+		}
+	}
+}
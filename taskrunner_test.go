@@ -0,0 +1,144 @@
+package baur
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStatus(t *testing.T) {
+	testcases := []struct {
+		name           string
+		command        []string
+		timeout        time.Duration
+		cancelAfter    time.Duration
+		expectedStatus RunStatus
+	}{
+		{
+			name:           "success",
+			command:        []string{"true"},
+			expectedStatus: RunStatusSuccess,
+		},
+		{
+			name:           "failure",
+			command:        []string{"false"},
+			expectedStatus: RunStatusFailure,
+		},
+		{
+			name:           "timeout",
+			command:        []string{"sleep", "60"},
+			timeout:        100 * time.Millisecond,
+			expectedStatus: RunStatusTimeout,
+		},
+		{
+			name:           "cancelled",
+			command:        []string{"sleep", "60"},
+			cancelAfter:    100 * time.Millisecond,
+			expectedStatus: RunStatusCancelled,
+		},
+		{
+			name:           "exit code 0 after timeout",
+			command:        []string{"sh", "-c", "trap 'exit 0' TERM; sleep 60 & wait"},
+			timeout:        100 * time.Millisecond,
+			expectedStatus: RunStatusSuccess,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if tc.cancelAfter != 0 {
+				var cancel context.CancelFunc
+
+				ctx, cancel = context.WithCancel(ctx)
+				time.AfterFunc(tc.cancelAfter, cancel)
+				defer cancel()
+			}
+
+			task := Task{
+				AppName:   "app",
+				Name:      "build",
+				Directory: t.TempDir(),
+				Command:   tc.command,
+				Timeout:   tc.timeout,
+			}
+
+			res, err := NewTaskRunner().Run(ctx, &task)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatus, res.Status)
+		})
+	}
+}
+
+func TestLockMutexGroups(t *testing.T) {
+	var lock sync.Mutex
+	var running int
+	var overlapped bool
+
+	runner := NewTaskRunner()
+	tasks := []*Task{
+		{AppName: "app1", Name: "build", MutexGroups: []string{"a", "b"}},
+		{AppName: "app2", Name: "build", MutexGroups: []string{"b", "a"}},
+		{AppName: "app3", Name: "build", MutexGroups: []string{"b"}},
+	}
+
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+
+		go func(task *Task) {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				unlock := runner.LockMutexGroups(task)
+
+				lock.Lock()
+				running++
+				overlapped = overlapped || running > 1
+				lock.Unlock()
+
+				time.Sleep(time.Microsecond)
+
+				lock.Lock()
+				running--
+				lock.Unlock()
+
+				unlock()
+			}
+		}(task)
+	}
+
+	wg.Wait()
+
+	assert.False(t, overlapped, "tasks of the same mutex group held the lock at the same time")
+}
+
+func TestRunPassesPrefixedOutputToLogFn(t *testing.T) {
+	var lines []string
+
+	runner := NewTaskRunner()
+	runner.LogFn = func(format string, v ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, v...))
+	}
+
+	task := Task{
+		AppName:   "app",
+		Name:      "build",
+		Directory: t.TempDir(),
+		Command:   []string{"sh", "-c", "echo 100%; echo done"},
+	}
+
+	res, err := runner.Run(context.Background(), &task)
+	require.NoError(t, err)
+	require.Equal(t, RunStatusSuccess, res.Status)
+
+	assert.Contains(t, lines, "app.build: 100%")
+	assert.Contains(t, lines, "app.build: done")
+}
@@ -2,8 +2,11 @@ package baur
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime"
 
+	"github.com/simplesurance/baur/v1/internal/routines"
 	"github.com/simplesurance/baur/v1/storage"
 )
 
@@ -16,6 +19,10 @@ type TaskStatusEvaluator struct {
 
 	inputStr       string
 	lookupInputStr string
+
+	// parallel is the max. number of tasks whose inputs StatusBatch
+	// resolves concurrently.
+	parallel uint
 }
 
 // NewTaskStatusEvaluator returns a new TaskSNewTaskStatusEvaluator.
@@ -32,6 +39,7 @@ func NewTaskStatusEvaluator(
 		store:          store,
 		inputStr:       inputStr,
 		lookupInputStr: lookupInputStr,
+		parallel:       uint(runtime.NumCPU()),
 	}
 }
 
@@ -77,7 +85,7 @@ func (t *TaskStatusEvaluator) getTaskStatus(ctx context.Context, inputs *Inputs,
 		return TaskStatusUndefined, nil, fmt.Errorf("calculating total input digest failed: %w", err)
 	}
 
-	run, err := t.store.LatestTaskRunByDigest(ctx, task.AppName, task.Name, totalInputDigest.String())
+	run, err := t.store.LatestTaskRunByDigest(ctx, task.AppName, task.Name, totalInputDigest.String(), InputDigestVersion)
 	if err != nil {
 		if err == storage.ErrNotExist {
 			return TaskStatusExecutionPending, nil, nil
@@ -88,3 +96,323 @@ func (t *TaskStatusEvaluator) getTaskStatus(ctx context.Context, inputs *Inputs,
 
 	return TaskStatusRunExist, run, nil
 }
+
+// TaskStatusResult is the status of a task that was evaluated by
+// StatusBatch.
+type TaskStatusResult struct {
+	Task   *Task
+	Status TaskStatus
+	// Inputs are the resolved inputs of the task, including the inputStr
+	// but not the lookupInputStr.
+	Inputs *Inputs
+	// Run is the existing run of the task, it is nil if Status is
+	// TaskStatusExecutionPending.
+	Run *storage.TaskRunWithID
+}
+
+// StatusBatch evaluates the status of multiple tasks, like Status does for
+// a single one.
+// The inputs of all tasks are resolved and their digests calculated
+// concurrently first, the runs are then looked up in the storage with a single
+// query. If a lookupInputStr is set, the runs of
+// the tasks that are still pending are looked up with a second query.
+// The results are returned in the order of tasks.
+func (t *TaskStatusEvaluator) StatusBatch(ctx context.Context, tasks []*Task) ([]*TaskStatusResult, error) {
+	resolved := t.resolveInputs(ctx, tasks)
+
+	results := make([]*TaskStatusResult, 0, len(tasks))
+	digests := make([]*storage.TaskDigest, 0, len(tasks))
+
+	for i, r := range resolved {
+		if r.err != nil {
+			return nil, fmt.Errorf("%s: %w", tasks[i], r.err)
+		}
+
+		results = append(results, &TaskStatusResult{Task: tasks[i], Inputs: r.inputs})
+		digests = append(digests, r.digest)
+	}
+
+	runs, err := t.store.LatestTaskRunsByDigest(ctx, digests)
+	if err != nil {
+		return nil, fmt.Errorf("querying storage for task run status failed: %w", err)
+	}
+
+	var pendingIdxs []int
+	var lookupDigests []*storage.TaskDigest
+
+	for i, run := range runs {
+		if run != nil {
+			results[i].Status = TaskStatusRunExist
+			results[i].Run = run
+			continue
+		}
+
+		results[i].Status = TaskStatusExecutionPending
+
+		if t.lookupInputStr == "" {
+			continue
+		}
+
+		// a new slice is allocated, appending to the resolved files
+		// could overwrite the inputStr element of the result inputs
+		lookupFiles := make([]Input, 0, len(resolved[i].files)+1)
+		lookupFiles = append(lookupFiles, resolved[i].files...)
+		lookupFiles = append(lookupFiles, NewInputString(t.lookupInputStr))
+
+		digest, err := taskDigest(results[i].Task, NewInputs(lookupFiles))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", results[i].Task, err)
+		}
+
+		pendingIdxs = append(pendingIdxs, i)
+		lookupDigests = append(lookupDigests, digest)
+	}
+
+	if len(lookupDigests) == 0 {
+		return results, nil
+	}
+
+	runs, err = t.store.LatestTaskRunsByDigest(ctx, lookupDigests)
+	if err != nil {
+		return nil, fmt.Errorf("querying storage for task run status failed: %w", err)
+	}
+
+	for i, run := range runs {
+		if run == nil {
+			continue
+		}
+
+		result := results[pendingIdxs[i]]
+		result.Status = TaskStatusRunExist
+		result.Run = run
+	}
+
+	return results, nil
+}
+
+// resolvedInputs are the inputs of a task, resolved by resolveInputs.
+type resolvedInputs struct {
+	// files are the resolved inputs, without the inputStr.
+	files  []Input
+	inputs *Inputs
+	digest *storage.TaskDigest
+	err    error
+}
+
+// resolveInputs resolves the inputs of the tasks and calculates their total
+// input digests concurrently. The results are returned in the order of tasks.
+func (t *TaskStatusEvaluator) resolveInputs(ctx context.Context, tasks []*Task) []*resolvedInputs {
+	result := make([]*resolvedInputs, len(tasks))
+	pool := routines.NewPool(t.parallel)
+
+	for i, task := range tasks {
+		i, task := i, task
+
+		pool.Queue(func() {
+			var r resolvedInputs
+
+			r.files, r.err = t.inputResolver.Resolve(ctx, t.repositoryDir, task)
+			if r.err != nil {
+				r.err = fmt.Errorf("resolving inputs failed: %w", r.err)
+				result[i] = &r
+
+				return
+			}
+
+			r.inputs = NewInputs(InputAddStrIfNotEmpty(r.files, t.inputStr))
+			r.digest, r.err = taskDigest(task, r.inputs)
+			result[i] = &r
+		})
+	}
+
+	pool.Wait()
+
+	return result
+}
+
+func taskDigest(task *Task, inputs *Inputs) (*storage.TaskDigest, error) {
+	totalInputDigest, err := inputs.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("calculating total input digest failed: %w", err)
+	}
+
+	return &storage.TaskDigest{
+		ApplicationName:    task.AppName,
+		TaskName:           task.Name,
+		TotalInputDigest:   totalInputDigest.String(),
+		InputDigestVersion: InputDigestVersion,
+	}, nil
+}
+
+// LatestFailedRun returns the most recent run of the task that was recorded
+// for the total input digest of inputs, if it's result is not successful.
+// If no run for the digest exist, the most recent one was successful or its
+// digest was calculated with a different InputDigestVersion,
+// storage.ErrNotExist is returned.
+func (t *TaskStatusEvaluator) LatestFailedRun(ctx context.Context, task *Task, inputs *Inputs) (*storage.TaskRunWithID, error) {
+	var run *storage.TaskRunWithID
+
+	totalInputDigest, err := inputs.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("calculating total input digest failed: %w", err)
+	}
+
+	err = t.store.TaskRuns(
+		ctx,
+		[]*storage.Filter{
+			{
+				Field:    storage.FieldApplicationName,
+				Operator: storage.OpEQ,
+				Value:    task.AppName,
+			},
+			{
+				Field:    storage.FieldTaskName,
+				Operator: storage.OpEQ,
+				Value:    task.Name,
+			},
+			{
+				Field:    storage.FieldTotalInputDigest,
+				Operator: storage.OpEQ,
+				Value:    totalInputDigest.String(),
+			},
+		},
+		[]*storage.Sorter{
+			{
+				Field: storage.FieldStartTime,
+				Order: storage.OrderDesc,
+			},
+		},
+		&storage.Pagination{Limit: 1},
+		func(tr *storage.TaskRunWithID) error {
+			run = tr
+			return nil
+		},
+	)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			return nil, storage.ErrNotExist
+		}
+
+		return nil, fmt.Errorf("querying storage for task runs failed: %w", err)
+	}
+
+	if run == nil ||
+		run.Result == storage.ResultSuccess ||
+		run.InputDigestVersion != InputDigestVersion {
+		return nil, storage.ErrNotExist
+	}
+
+	return run, nil
+}
+
+// TaskStatusExplanation describes how the inputs of a task differ from the
+// inputs of its most recent recorded run.
+type TaskStatusExplanation struct {
+	// PreviousRun is the most recent recorded run of the task, it is nil
+	// if no run of the task was recorded.
+	PreviousRun *storage.TaskRunWithID
+	// InputDiffs are the differences between the inputs of PreviousRun
+	// and the current inputs. It is nil if PreviousRun has a different
+	// input digest version.
+	InputDiffs []*InputDiff
+}
+
+// DigestVersionDiffers returns true if the input digests of PreviousRun
+// were calculated with a different InputDigestVersion.
+// The inputs can not be compared then.
+func (e *TaskStatusExplanation) DigestVersionDiffers() bool {
+	return e.PreviousRun != nil && e.PreviousRun.InputDigestVersion != InputDigestVersion
+}
+
+// Explain compares inputs with the inputs of the most recent recorded run
+// of the task, independent of its total input digest and result.
+// It is used to find out why a task has the status TaskStatusExecutionPending.
+func (t *TaskStatusEvaluator) Explain(ctx context.Context, task *Task, inputs *Inputs) (*TaskStatusExplanation, error) {
+	var run *storage.TaskRunWithID
+
+	err := t.store.TaskRuns(
+		ctx,
+		[]*storage.Filter{
+			{
+				Field:    storage.FieldApplicationName,
+				Operator: storage.OpEQ,
+				Value:    task.AppName,
+			},
+			{
+				Field:    storage.FieldTaskName,
+				Operator: storage.OpEQ,
+				Value:    task.Name,
+			},
+		},
+		[]*storage.Sorter{
+			{
+				Field: storage.FieldStartTime,
+				Order: storage.OrderDesc,
+			},
+		},
+		&storage.Pagination{Limit: 1},
+		func(tr *storage.TaskRunWithID) error {
+			run = tr
+			return nil
+		},
+	)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			return &TaskStatusExplanation{}, nil
+		}
+
+		return nil, fmt.Errorf("querying storage for task runs failed: %w", err)
+	}
+
+	result := TaskStatusExplanation{PreviousRun: run}
+	if result.DigestVersionDiffers() {
+		return &result, nil
+	}
+
+	prevInputs, err := t.store.Inputs(ctx, run.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotExist) {
+		return nil, fmt.Errorf("querying storage for inputs of task run %d failed: %w", run.ID, err)
+	}
+
+	curInputs, err := InputsToStorageInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	result.InputDiffs = DiffInputs(prevInputs, curInputs)
+
+	return &result, nil
+}
+
+// ExplainBatch calls Explain for every result with the status
+// TaskStatusExecutionPending. The storage lookups of the tasks are done
+// concurrently.
+// The explanations are returned in the order of results, the elements of
+// results with another status are nil.
+func (t *TaskStatusEvaluator) ExplainBatch(ctx context.Context, results []*TaskStatusResult) ([]*TaskStatusExplanation, error) {
+	explanations := make([]*TaskStatusExplanation, len(results))
+	errs := make([]error, len(results))
+	pool := routines.NewPool(t.parallel)
+
+	for i, r := range results {
+		if r.Status != TaskStatusExecutionPending {
+			continue
+		}
+
+		i, r := i, r
+
+		pool.Queue(func() {
+			explanations[i], errs[i] = t.Explain(ctx, r.Task, r.Inputs)
+		})
+	}
+
+	pool.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", results[i].Task, err)
+		}
+	}
+
+	return explanations, nil
+}
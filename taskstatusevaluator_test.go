@@ -0,0 +1,305 @@
+package baur
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v1/cfg"
+	"github.com/simplesurance/baur/v1/storage"
+	"github.com/simplesurance/baur/v1/storage/filedb"
+)
+
+func TestExplainWithoutPreviousRun(t *testing.T) {
+	ctx := context.Background()
+
+	store := filedb.New(filepath.Join(t.TempDir(), "baur.db"))
+	require.NoError(t, store.Init(ctx))
+
+	task := &Task{AppName: "calc", Name: "build"}
+	evaluator := NewTaskStatusEvaluator(t.TempDir(), store, NewInputResolver(), "", "")
+
+	explanation, err := evaluator.Explain(ctx, task, NewInputs([]Input{NewInputString("a")}))
+	require.NoError(t, err)
+
+	assert.Nil(t, explanation.PreviousRun)
+	assert.Empty(t, explanation.InputDiffs)
+}
+
+func TestExplainComparesWithMostRecentRun(t *testing.T) {
+	ctx := context.Background()
+
+	store := filedb.New(filepath.Join(t.TempDir(), "baur.db"))
+	require.NoError(t, store.Init(ctx))
+
+	task := &Task{AppName: "calc", Name: "build"}
+	inputA := NewInputString("a")
+	inputB := NewInputString("b")
+	inputC := NewInputString("c")
+
+	saveRun := func(start time.Time, inputs ...Input) int {
+		storageInputs, err := InputsToStorageInputs(NewInputs(inputs))
+		require.NoError(t, err)
+
+		id, err := store.SaveTaskRun(ctx, &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:    task.AppName,
+				TaskName:           task.Name,
+				StartTimestamp:     start,
+				StopTimestamp:      start.Add(time.Second),
+				TotalInputDigest:   "1",
+				InputDigestVersion: InputDigestVersion,
+				Result:             storage.ResultSuccess,
+			},
+			Inputs: storageInputs,
+		})
+		require.NoError(t, err)
+
+		return id
+	}
+
+	now := time.Now()
+	saveRun(now.Add(-time.Hour), inputC)
+	latestID := saveRun(now, inputA, inputB)
+
+	evaluator := NewTaskStatusEvaluator(t.TempDir(), store, NewInputResolver(), "", "")
+
+	explanation, err := evaluator.Explain(ctx, task, NewInputs([]Input{inputA, inputC}))
+	require.NoError(t, err)
+
+	require.NotNil(t, explanation.PreviousRun)
+	assert.Equal(t, latestID, explanation.PreviousRun.ID)
+
+	require.Len(t, explanation.InputDiffs, 2)
+	assert.Equal(t, InputDiffRemoved, explanation.InputDiffs[0].State)
+	assert.Equal(t, inputB.String(), explanation.InputDiffs[0].URI)
+	assert.Equal(t, InputDiffAdded, explanation.InputDiffs[1].State)
+	assert.Equal(t, inputC.String(), explanation.InputDiffs[1].URI)
+}
+
+func TestExplainDoesNotCompareDifferentDigestVersions(t *testing.T) {
+	ctx := context.Background()
+
+	store := filedb.New(filepath.Join(t.TempDir(), "baur.db"))
+	require.NoError(t, store.Init(ctx))
+
+	task := &Task{AppName: "calc", Name: "build"}
+	input := NewInputString("a")
+
+	storageInputs, err := InputsToStorageInputs(NewInputs([]Input{input}))
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, err = store.SaveTaskRun(ctx, &storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:    task.AppName,
+			TaskName:           task.Name,
+			StartTimestamp:     now,
+			StopTimestamp:      now.Add(time.Second),
+			TotalInputDigest:   "1",
+			InputDigestVersion: storage.LegacyInputDigestVersion,
+			Result:             storage.ResultSuccess,
+		},
+		Inputs: storageInputs,
+	})
+	require.NoError(t, err)
+
+	evaluator := NewTaskStatusEvaluator(t.TempDir(), store, NewInputResolver(), "", "")
+
+	explanation, err := evaluator.Explain(ctx, task, NewInputs([]Input{input}))
+	require.NoError(t, err)
+
+	require.NotNil(t, explanation.PreviousRun)
+	assert.True(t, explanation.DigestVersionDiffers())
+	assert.Nil(t, explanation.InputDiffs)
+}
+
+func TestExplainBatch(t *testing.T) {
+	ctx := context.Background()
+
+	store := filedb.New(filepath.Join(t.TempDir(), "baur.db"))
+	require.NoError(t, store.Init(ctx))
+
+	pendingTask := &Task{AppName: "calc", Name: "build"}
+	pendingTaskWithoutRun := &Task{AppName: "calc", Name: "check"}
+	taskWithRun := &Task{AppName: "calc", Name: "test"}
+
+	inputA := NewInputString("a")
+	inputB := NewInputString("b")
+
+	storageInputs, err := InputsToStorageInputs(NewInputs([]Input{inputA}))
+	require.NoError(t, err)
+
+	now := time.Now()
+	prevRunID, err := store.SaveTaskRun(ctx, &storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:    pendingTask.AppName,
+			TaskName:           pendingTask.Name,
+			StartTimestamp:     now,
+			StopTimestamp:      now.Add(time.Second),
+			TotalInputDigest:   "1",
+			InputDigestVersion: InputDigestVersion,
+			Result:             storage.ResultSuccess,
+		},
+		Inputs: storageInputs,
+	})
+	require.NoError(t, err)
+
+	evaluator := NewTaskStatusEvaluator(t.TempDir(), store, NewInputResolver(), "", "")
+
+	explanations, err := evaluator.ExplainBatch(ctx, []*TaskStatusResult{
+		{
+			Task:   pendingTask,
+			Status: TaskStatusExecutionPending,
+			Inputs: NewInputs([]Input{inputB}),
+		},
+		{
+			Task:   taskWithRun,
+			Status: TaskStatusRunExist,
+			Inputs: NewInputs([]Input{inputA}),
+		},
+		{
+			Task:   pendingTaskWithoutRun,
+			Status: TaskStatusExecutionPending,
+			Inputs: NewInputs([]Input{inputA}),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, explanations, 3)
+
+	require.NotNil(t, explanations[0])
+	require.NotNil(t, explanations[0].PreviousRun)
+	assert.Equal(t, prevRunID, explanations[0].PreviousRun.ID)
+	assert.Len(t, explanations[0].InputDiffs, 2)
+
+	assert.Nil(t, explanations[1])
+
+	require.NotNil(t, explanations[2])
+	assert.Nil(t, explanations[2].PreviousRun)
+}
+
+func TestLatestFailedRunIgnoresDifferentDigestVersions(t *testing.T) {
+	ctx := context.Background()
+
+	store := filedb.New(filepath.Join(t.TempDir(), "baur.db"))
+	require.NoError(t, store.Init(ctx))
+
+	task := &Task{AppName: "calc", Name: "build"}
+	inputs := NewInputs([]Input{NewInputString("a")})
+
+	digest, err := inputs.Digest()
+	require.NoError(t, err)
+
+	saveFailedRun := func(digestVersion int) int {
+		now := time.Now()
+
+		id, err := store.SaveTaskRun(ctx, &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:    task.AppName,
+				TaskName:           task.Name,
+				StartTimestamp:     now,
+				StopTimestamp:      now.Add(time.Second),
+				TotalInputDigest:   digest.String(),
+				InputDigestVersion: digestVersion,
+				Result:             storage.ResultFailure,
+			},
+		})
+		require.NoError(t, err)
+
+		return id
+	}
+
+	evaluator := NewTaskStatusEvaluator(t.TempDir(), store, NewInputResolver(), "", "")
+
+	saveFailedRun(storage.LegacyInputDigestVersion)
+
+	_, err = evaluator.LatestFailedRun(ctx, task, inputs)
+	assert.Equal(t, storage.ErrNotExist, err)
+
+	id := saveFailedRun(InputDigestVersion)
+
+	run, err := evaluator.LatestFailedRun(ctx, task, inputs)
+	require.NoError(t, err)
+	assert.Equal(t, id, run.ID)
+}
+
+func TestStatusBatch(t *testing.T) {
+	ctx := context.Background()
+
+	store := filedb.New(filepath.Join(t.TempDir(), "baur.db"))
+	require.NoError(t, store.Init(ctx))
+
+	repoDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, AppCfgFile), []byte("name = \"calc\""), 0644))
+
+	newTask := func(name string) *Task {
+		return &Task{
+			Directory:        repoDir,
+			AppName:          "calc",
+			Name:             name,
+			UnresolvedInputs: &cfg.Input{},
+		}
+	}
+
+	taskWithRun := newTask("build")
+	taskWithLookupRun := newTask("test")
+	pendingTask := newTask("check")
+
+	resolvedInputs, err := NewInputResolver().Resolve(ctx, repoDir, taskWithRun)
+	require.NoError(t, err)
+
+	saveRun := func(task *Task, str string) int {
+		inputs := append([]Input{NewInputString(str)}, resolvedInputs...)
+		digest, err := NewInputs(inputs).Digest()
+		require.NoError(t, err)
+
+		id, err := store.SaveTaskRun(ctx, &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:    task.AppName,
+				TaskName:           task.Name,
+				StartTimestamp:     time.Now(),
+				StopTimestamp:      time.Now(),
+				TotalInputDigest:   digest.String(),
+				InputDigestVersion: InputDigestVersion,
+				Result:             storage.ResultSuccess,
+			},
+		})
+		require.NoError(t, err)
+
+		return id
+	}
+
+	runID := saveRun(taskWithRun, "in")
+	lookupRunID := saveRun(taskWithLookupRun, "lookup")
+
+	evaluator := NewTaskStatusEvaluator(repoDir, store, NewInputResolver(), "in", "lookup")
+
+	results, err := evaluator.StatusBatch(ctx, []*Task{taskWithRun, taskWithLookupRun, pendingTask})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, taskWithRun, results[0].Task)
+	assert.Equal(t, TaskStatusRunExist, results[0].Status)
+	require.NotNil(t, results[0].Run)
+	assert.Equal(t, runID, results[0].Run.ID)
+
+	assert.Equal(t, taskWithLookupRun, results[1].Task)
+	assert.Equal(t, TaskStatusRunExist, results[1].Status)
+	require.NotNil(t, results[1].Run)
+	assert.Equal(t, lookupRunID, results[1].Run.ID)
+	var inputStrs []string
+	for _, in := range results[1].Inputs.Inputs() {
+		inputStrs = append(inputStrs, in.String())
+	}
+	assert.Contains(t, inputStrs, NewInputString("in").String())
+	assert.NotContains(t, inputStrs, NewInputString("lookup").String())
+
+	assert.Equal(t, pendingTask, results[2].Task)
+	assert.Equal(t, TaskStatusExecutionPending, results[2].Status)
+	assert.Nil(t, results[2].Run)
+}
@@ -140,7 +140,7 @@ func (u *Uploader) S3(o *OutputFile) (*UploadResult, error) {
 	return &UploadResult{
 		Start:  startTime,
 		Stop:   time.Now(),
-		Method: UploadMethodFilecopy,
+		Method: UploadMethodS3,
 		Output: o,
 		URL:    url,
 	}, nil